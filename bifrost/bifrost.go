@@ -2,11 +2,14 @@ package bifrost
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/pkg/errors"
 
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/eirini"
 	"code.cloudfoundry.org/eirini/models/cf"
 	"code.cloudfoundry.org/eirini/opi"
 	"code.cloudfoundry.org/lager"
@@ -22,7 +25,7 @@ func (b *Bifrost) Transfer(ctx context.Context, request cf.DesireLRPRequest) err
 	desiredLRP, err := b.Converter.Convert(request)
 	if err != nil {
 		b.Logger.Error("failed-to-convert-request", err, lager.Data{"desire-lrp-request": request})
-		return err
+		return opi.NewInvalidError("failed to convert request: %s", err.Error())
 	}
 	return b.Desirer.Desire(&desiredLRP)
 }
@@ -56,38 +59,214 @@ func (b *Bifrost) Update(ctx context.Context, update cf.UpdateDesiredLRPRequest)
 		Version: update.Version,
 	}
 
+	if update.Update == nil {
+		return opi.NewInvalidError("update request for %s has no update", identifier.ProcessGUID())
+	}
+
 	lrp, err := b.Desirer.Get(identifier)
 	if err != nil {
 		b.Logger.Error("application-not-found", err, lager.Data{"process-guid": update.ProcessGuid})
 		return err
 	}
 
-	lrp.TargetInstances = int(*update.Update.Instances)
-	lrp.Metadata[cf.LastUpdated] = *update.Update.Annotation
-
-	routes, err := getURIs(update)
-	if err != nil {
-		return err
+	if update.Update.Instances != nil {
+		lrp.TargetInstances = int(*update.Update.Instances)
+	}
+	if update.Update.Annotation != nil {
+		lrp.Metadata[cf.LastUpdated] = *update.Update.Annotation
 	}
 
-	lrp.Metadata[cf.VcapAppUris] = routes
+	// an update without routes keeps those of the app
+	if update.Update.Routes != nil {
+		if lrp.Metadata[cf.VcapAppUris], err = getURIs(update); err != nil {
+			return err
+		}
+		if lrp.InternalRoutes, err = getInternalRoutes(*update.Update.Routes); err != nil {
+			b.Logger.Error("failed-to-parse-internal-routes", err, lager.Data{"process-guid": update.ProcessGuid})
+			return err
+		}
+	}
+	b.applyUpdate(lrp, update)
 
 	return b.Desirer.Update(lrp)
 }
 
-func (b *Bifrost) GetApp(ctx context.Context, identifier opi.LRPIdentifier) *models.DesiredLRP {
+func (b *Bifrost) applyUpdate(lrp *opi.LRP, update cf.UpdateDesiredLRPRequest) {
+	if update.MemoryMB != nil {
+		lrp.MemoryMB = *update.MemoryMB
+	}
+	if update.DiskMB != nil {
+		lrp.DiskMB = *update.DiskMB
+	}
+	if update.CPUWeight != nil {
+		lrp.CPUWeight = *update.CPUWeight
+	}
+	if update.HealthCheckType != nil {
+		lrp.Health.Type = *update.HealthCheckType
+	}
+	if update.HealthCheckHTTPEndpoint != nil {
+		lrp.Health.Endpoint = *update.HealthCheckHTTPEndpoint
+	}
+	if update.HealthCheckTimeoutMs != nil {
+		lrp.Health.TimeoutMs = *update.HealthCheckTimeoutMs
+	}
+	if update.HealthCheckReadinessHTTPEndpoint != nil {
+		lrp.Health.ReadinessEndpoint = *update.HealthCheckReadinessHTTPEndpoint
+	}
+	if update.HealthCheckCommand != nil {
+		lrp.Health.Command = update.HealthCheckCommand
+	}
+	if update.HealthCheckInvocationTimeoutMs != nil {
+		lrp.Health.InvocationTimeoutMs = *update.HealthCheckInvocationTimeoutMs
+	}
+	if update.HealthCheckIntervalMs != nil {
+		lrp.Health.IntervalMs = *update.HealthCheckIntervalMs
+	}
+	if update.HealthCheckPort != nil {
+		lrp.Health.Port = *update.HealthCheckPort
+	} else if update.Ports != nil && !containsPort(update.Ports, lrp.Health.Port) {
+		// the health check falls back to the first of the new ports
+		lrp.Health.Port = 0
+	}
+	if update.Ports != nil {
+		lrp.Ports = update.Ports
+	}
+	lrp.Health.Port = lrp.HealthCheckPort()
+	lrp.KeepSensitiveEnv = update.Environment == nil
+	if update.Environment != nil || update.Ports != nil {
+		env := lrp.Env
+		if update.Environment != nil {
+			env = update.Environment
+		}
+		lrp.Env = mergeMaps(env, eirini.SetupEnv(lrp.Env["START_COMMAND"], lrp.Ports))
+	}
+	if update.DockerImageURL != "" {
+		lrp.Image = update.DockerImageURL
+	} else if update.DropletGUID != "" && update.DropletHash != "" {
+		lrp.Image = b.Converter.ImageURI(update.DropletGUID, update.DropletHash)
+	}
+}
+
+func (b *Bifrost) GetApp(ctx context.Context, identifier opi.LRPIdentifier) (*models.DesiredLRP, error) {
 	lrp, err := b.Desirer.Get(identifier)
 	if err != nil {
 		b.Logger.Error("failed-to-get-deployment", err, lager.Data{"process-guid": identifier.GUID})
-		return nil
+		return nil, errors.Wrap(err, "failed to get app")
+	}
+
+	var originalRequest cf.DesireLRPRequest
+	if lrp.LRP != "" {
+		if err = json.Unmarshal([]byte(lrp.LRP), &originalRequest); err != nil {
+			b.Logger.Error("failed-to-parse-original-request", err, lager.Data{"process-guid": identifier.GUID})
+		}
+	}
+
+	health := lrp.Health
+	if health.Type == "" {
+		health.Type = originalRequest.HealthCheckType
+		health.TimeoutMs = originalRequest.HealthCheckTimeoutMs
+	}
+
+	// apps desired before their disk quota was enforced have none
+	diskMB := lrp.DiskMB
+	if diskMB == 0 {
+		diskMB = originalRequest.DiskMB
 	}
 
 	desiredLRP := &models.DesiredLRP{
-		ProcessGuid: identifier.ProcessGUID(),
-		Instances:   int32(lrp.TargetInstances),
+		ProcessGuid:          identifier.ProcessGUID(),
+		Instances:            int32(lrp.TargetInstances),
+		Annotation:           lrp.Metadata[cf.LastUpdated],
+		Routes:               toRoutes(lrp.Metadata[cf.VcapAppUris], lrp.InternalRoutes),
+		Ports:                toPorts(lrp.Ports),
+		MemoryMb:             int32(lrp.MemoryMB),
+		DiskMb:               int32(diskMB),
+		CpuWeight:            uint32(lrp.CPUWeight),
+		EnvironmentVariables: toEnvironmentVariables(lrp.Env),
+		StartTimeoutMs:       int64(health.TimeoutMs),
+		CheckDefinition:      toCheckDefinition(health),
+		PlacementTags:        lrp.PlacementTags,
+	}
+
+	return desiredLRP, nil
+}
+
+func toRoutes(cfRouterRoutes string, internalHostnames []string) *models.Routes {
+	routes := models.Routes{}
+	if cfRouterRoutes != "" {
+		raw := json.RawMessage(cfRouterRoutes)
+		routes["cf-router"] = &raw
+	}
+
+	if len(internalHostnames) > 0 {
+		internalRoutes := make([]cf.InternalRoute, 0, len(internalHostnames))
+		for _, hostname := range internalHostnames {
+			internalRoutes = append(internalRoutes, cf.InternalRoute{Hostname: hostname})
+		}
+		data, err := json.Marshal(internalRoutes)
+		if err != nil {
+			panic(err)
+		}
+		raw := json.RawMessage(data)
+		routes[internalRouter] = &raw
+	}
+
+	if len(routes) == 0 {
+		return nil
+	}
+	return &routes
+}
+
+func containsPort(ports []int32, port int32) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+func toPorts(ports []int32) []uint32 {
+	result := make([]uint32, 0, len(ports))
+	for _, p := range ports {
+		result = append(result, uint32(p))
+	}
+	return result
+}
+
+func toEnvironmentVariables(env map[string]string) []*models.EnvironmentVariable {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	envVars := make([]*models.EnvironmentVariable, 0, len(env))
+	for _, name := range names {
+		envVars = append(envVars, &models.EnvironmentVariable{Name: name, Value: env[name]})
+	}
+	return envVars
+}
+
+func toCheckDefinition(health opi.Healtcheck) *models.CheckDefinition {
+	var check *models.Check
+	switch health.Type {
+	case opi.HTTPHealthCheck:
+		check = &models.Check{HttpCheck: &models.HTTPCheck{
+			Port:             uint32(health.Port),
+			Path:             health.Endpoint,
+			RequestTimeoutMs: uint64(health.InvocationTimeoutMs),
+		}}
+	case opi.PortHealthCheck:
+		check = &models.Check{TcpCheck: &models.TCPCheck{
+			Port:             uint32(health.Port),
+			ConnectTimeoutMs: uint64(health.InvocationTimeoutMs),
+		}}
+	default:
+		return nil
 	}
 
-	return desiredLRP
+	return &models.CheckDefinition{Checks: []*models.Check{check}}
 }
 
 func (b *Bifrost) Stop(ctx context.Context, identifier opi.LRPIdentifier) error {
@@ -116,6 +295,7 @@ func (b *Bifrost) GetInstances(ctx context.Context, identifier opi.LRPIdentifier
 			Index:          i.Index,
 			State:          i.State,
 			PlacementError: i.PlacementError,
+			Zone:           i.Zone,
 		})
 	}
 
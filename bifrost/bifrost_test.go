@@ -189,6 +189,7 @@ var _ = Describe("Bifrost", func() {
 		var (
 			bfrst         bifrost.Bifrost
 			updateRequest cf.UpdateDesiredLRPRequest
+			converter     *bifrostfakes.FakeConverter
 		)
 
 		BeforeEach(func() {
@@ -197,14 +198,16 @@ var _ = Describe("Bifrost", func() {
 				Version: "version_1234",
 			}
 			opiClient = new(opifakes.FakeDesirer)
+			converter = new(bifrostfakes.FakeConverter)
 
 			lager = lagertest.NewTestLogger("bifrost-update-test")
 		})
 
 		JustBeforeEach(func() {
 			bfrst = bifrost.Bifrost{
-				Desirer: opiClient,
-				Logger:  lager,
+				Converter: converter,
+				Desirer:   opiClient,
+				Logger:    lager,
 			}
 
 			err = bfrst.Update(context.Background(), updateRequest)
@@ -246,6 +249,11 @@ var _ = Describe("Bifrost", func() {
 					Expect(lrp.Metadata[cf.LastUpdated]).To(Equal("21421321.3"))
 				})
 
+				It("should keep the routes of the app", func() {
+					lrp := opiClient.UpdateArgsForCall(0)
+					Expect(lrp.Metadata[cf.VcapAppUris]).To(Equal(`[{"hostname":"my.route","port":8080},{"hostname":"your.route","port":5555}]`))
+				})
+
 				It("should not return an error", func() {
 					Expect(err).ToNot(HaveOccurred())
 				})
@@ -324,12 +332,170 @@ var _ = Describe("Bifrost", func() {
 						Expect(lrp.Metadata[cf.VcapAppUris]).To(Equal(`[]`))
 					})
 				})
+
+				Context("When internal routes are provided", func() {
+					BeforeEach(func() {
+						rawJSON := json.RawMessage(`[{"hostname":"my.apps.internal"}]`)
+						(*updateRequest.Update.Routes)["internal-router"] = &rawJSON
+					})
+
+					It("should update the internal routes", func() {
+						Expect(opiClient.UpdateCallCount()).To(Equal(1))
+						lrp := opiClient.UpdateArgsForCall(0)
+						Expect(lrp.InternalRoutes).To(ConsistOf("my.apps.internal"))
+					})
+				})
+			})
+
+			Context("with the app configuration modified", func() {
+				BeforeEach(func() {
+					lrp := opi.LRP{
+						TargetInstances: 2,
+						Image:           "eirini/app:old",
+						MemoryMB:        256,
+						CPUWeight:       10,
+						Ports:           []int32{8080},
+						Env:             map[string]string{"START_COMMAND": "bundle exec rackup", "OLD": "value"},
+						Health:          opi.Healtcheck{Type: "port", Port: 8080, TimeoutMs: 1000},
+						Metadata:        map[string]string{},
+					}
+					opiClient.GetReturns(&lrp, nil)
+
+					instances := int32(2)
+					annotation := "4567.8"
+					memory := int64(1024)
+					cpuWeight := uint8(50)
+					healthCheckType := "http"
+					healthCheckEndpoint := "/healthz"
+					healthCheckTimeout := uint(5000)
+					updateRequest.Update = &models.DesiredLRPUpdate{Instances: &instances, Annotation: &annotation}
+					updateRequest.MemoryMB = &memory
+					updateRequest.CPUWeight = &cpuWeight
+					updateRequest.Environment = map[string]string{"NEW": "value"}
+					updateRequest.HealthCheckType = &healthCheckType
+					updateRequest.HealthCheckHTTPEndpoint = &healthCheckEndpoint
+					updateRequest.HealthCheckTimeoutMs = &healthCheckTimeout
+					healthCheckInterval := uint(10000)
+					updateRequest.HealthCheckIntervalMs = &healthCheckInterval
+					updateRequest.Ports = []int32{8080, 9090}
+					updateRequest.DropletGUID = "droplet-guid"
+					updateRequest.DropletHash = "droplet-hash"
+
+					converter.ImageURIReturns("eirini/droplet-guid:droplet-hash")
+				})
+
+				It("should submit the updated configuration", func() {
+					Expect(opiClient.UpdateCallCount()).To(Equal(1))
+					lrp := opiClient.UpdateArgsForCall(0)
+					Expect(lrp.MemoryMB).To(Equal(int64(1024)))
+					Expect(lrp.CPUWeight).To(Equal(uint8(50)))
+					Expect(lrp.Ports).To(Equal([]int32{8080, 9090}))
+					Expect(lrp.Health).To(Equal(opi.Healtcheck{
+						Type:       "http",
+						Endpoint:   "/healthz",
+						Port:       8080,
+						TimeoutMs:  5000,
+						IntervalMs: 10000,
+					}))
+				})
+
+				It("should replace the app environment and keep the launcher environment", func() {
+					lrp := opiClient.UpdateArgsForCall(0)
+					Expect(lrp.Env).To(HaveKeyWithValue("NEW", "value"))
+					Expect(lrp.Env).To(HaveKeyWithValue("START_COMMAND", "bundle exec rackup"))
+					Expect(lrp.Env).ToNot(HaveKey("OLD"))
+					Expect(lrp.KeepSensitiveEnv).To(BeFalse())
+				})
+
+				Context("when the update carries no environment", func() {
+					BeforeEach(func() {
+						updateRequest.Environment = nil
+					})
+
+					It("should keep the sensitive environment of the app", func() {
+						lrp := opiClient.UpdateArgsForCall(0)
+						Expect(lrp.KeepSensitiveEnv).To(BeTrue())
+					})
+				})
+
+				It("should use the new droplet image", func() {
+					Expect(converter.ImageURICallCount()).To(Equal(1))
+					dropletGUID, dropletHash := converter.ImageURIArgsForCall(0)
+					Expect(dropletGUID).To(Equal("droplet-guid"))
+					Expect(dropletHash).To(Equal("droplet-hash"))
+
+					lrp := opiClient.UpdateArgsForCall(0)
+					Expect(lrp.Image).To(Equal("eirini/droplet-guid:droplet-hash"))
+				})
+
+				It("should list the new ports in the launcher environment", func() {
+					lrp := opiClient.UpdateArgsForCall(0)
+					Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_PORTS", `[{"external":8080,"internal":8080},{"external":9090,"internal":9090}]`))
+				})
+
+				Context("when the health check port is no longer declared", func() {
+					BeforeEach(func() {
+						updateRequest.Ports = []int32{9000, 9090}
+					})
+
+					It("should check the health of the first new port", func() {
+						lrp := opiClient.UpdateArgsForCall(0)
+						Expect(lrp.Health.Port).To(Equal(int32(9000)))
+						Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_PORT", "9000"))
+					})
+				})
+
+				Context("when the health check port is updated", func() {
+					BeforeEach(func() {
+						healthCheckPort := int32(9090)
+						updateRequest.HealthCheckPort = &healthCheckPort
+					})
+
+					It("should check the health of the requested port", func() {
+						lrp := opiClient.UpdateArgsForCall(0)
+						Expect(lrp.Health.Port).To(Equal(int32(9090)))
+					})
+				})
+
+				Context("when a docker image is provided", func() {
+					BeforeEach(func() {
+						updateRequest.DockerImageURL = "eirini/dorini"
+					})
+
+					It("should use the docker image", func() {
+						Expect(converter.ImageURICallCount()).To(Equal(0))
+						lrp := opiClient.UpdateArgsForCall(0)
+						Expect(lrp.Image).To(Equal("eirini/dorini"))
+					})
+				})
+
+				Context("when the configuration is not part of the update", func() {
+					BeforeEach(func() {
+						updateRequest = cf.UpdateDesiredLRPRequest{
+							GUID:    "guid_1234",
+							Version: "version_1234",
+						}
+						instances := int32(3)
+						annotation := "4567.8"
+						updateRequest.Update = &models.DesiredLRPUpdate{Instances: &instances, Annotation: &annotation}
+					})
+
+					It("should keep the existing configuration", func() {
+						lrp := opiClient.UpdateArgsForCall(0)
+						Expect(lrp.Image).To(Equal("eirini/app:old"))
+						Expect(lrp.MemoryMB).To(Equal(int64(256)))
+						Expect(lrp.Env).To(HaveKeyWithValue("OLD", "value"))
+						Expect(lrp.Health.Type).To(Equal("port"))
+					})
+				})
 			})
 		})
 
 		Context("when the app does not exist", func() {
 
 			BeforeEach(func() {
+				instances := int32(5)
+				updateRequest.Update = &models.DesiredLRPUpdate{Instances: &instances}
 				opiClient.GetReturns(nil, errors.New("app does not exist"))
 			})
 
@@ -349,6 +515,17 @@ var _ = Describe("Bifrost", func() {
 				Expect(err).To(HaveOccurred())
 			})
 		})
+
+		Context("when the request has no update", func() {
+
+			It("should return an invalid error", func() {
+				Expect(opi.IsInvalid(err)).To(BeTrue())
+			})
+
+			It("should not submit anything to be updated", func() {
+				Expect(opiClient.UpdateCallCount()).To(Equal(0))
+			})
+		})
 	})
 
 	Context("get an App", func() {
@@ -373,13 +550,29 @@ var _ = Describe("Bifrost", func() {
 				Version: "version_1234",
 			}
 
-			desiredLRP = bfrst.GetApp(context.Background(), identifier)
+			desiredLRP, err = bfrst.GetApp(context.Background(), identifier)
 		})
 
 		Context("when the app exists", func() {
 			BeforeEach(func() {
 				lrp = &opi.LRP{
 					TargetInstances: 5,
+					Ports:           []int32{8080, 9090},
+					InternalRoutes:  []string{"my.apps.internal"},
+					MemoryMB:        512,
+					CPUWeight:       20,
+					Env:             map[string]string{"VCAP_SERVICES": "{}", "HOWARD": "the alien"},
+					Health: opi.Healtcheck{
+						Type:      "http",
+						Endpoint:  "/heat",
+						Port:      8080,
+						TimeoutMs: 60000,
+					},
+					Metadata: map[string]string{
+						cf.LastUpdated: "123214.2",
+						cf.VcapAppUris: `[{"hostnames":["my.route"],"port":8080}]`,
+					},
+					LRP: `{"disk_mb": 2048, "health_check_type": "http"}`,
 				}
 
 				opiClient.GetReturns(lrp, nil)
@@ -397,6 +590,91 @@ var _ = Describe("Bifrost", func() {
 				Expect(desiredLRP.ProcessGuid).To(Equal("guid_1234-version_1234"))
 				Expect(desiredLRP.Instances).To(Equal(int32(5)))
 			})
+
+			It("should reconstruct the rest of the DesiredLRP", func() {
+				Expect(desiredLRP.Annotation).To(Equal("123214.2"))
+				Expect(desiredLRP.Ports).To(Equal([]uint32{8080, 9090}))
+				Expect(desiredLRP.MemoryMb).To(Equal(int32(512)))
+				Expect(desiredLRP.DiskMb).To(Equal(int32(2048)))
+				Expect(desiredLRP.CpuWeight).To(Equal(uint32(20)))
+				Expect(desiredLRP.StartTimeoutMs).To(Equal(int64(60000)))
+			})
+
+			Context("when the disk quota of the app is enforced", func() {
+				BeforeEach(func() {
+					lrp.DiskMB = 1024
+				})
+
+				It("should return the enforced disk quota", func() {
+					Expect(desiredLRP.DiskMb).To(Equal(int32(1024)))
+				})
+			})
+
+			It("should return the routes", func() {
+				Expect(desiredLRP.Routes).ToNot(BeNil())
+				cfRouterRoutes := (*desiredLRP.Routes)["cf-router"]
+				Expect(string(*cfRouterRoutes)).To(MatchJSON(`[{"hostnames":["my.route"],"port":8080}]`))
+			})
+
+			It("should return the internal routes", func() {
+				internalRoutes := (*desiredLRP.Routes)["internal-router"]
+				Expect(string(*internalRoutes)).To(MatchJSON(`[{"hostname":"my.apps.internal"}]`))
+			})
+
+			It("should return the environment variables sorted by name", func() {
+				Expect(desiredLRP.EnvironmentVariables).To(Equal([]*models.EnvironmentVariable{
+					{Name: "HOWARD", Value: "the alien"},
+					{Name: "VCAP_SERVICES", Value: "{}"},
+				}))
+			})
+
+			It("should return the health check", func() {
+				Expect(desiredLRP.CheckDefinition).To(Equal(&models.CheckDefinition{
+					Checks: []*models.Check{
+						{HttpCheck: &models.HTTPCheck{Port: 8080, Path: "/heat"}},
+					},
+				}))
+			})
+
+			Context("when the app uses a port health check", func() {
+				BeforeEach(func() {
+					lrp.Health = opi.Healtcheck{Type: "port", Port: 8080}
+				})
+
+				It("should return a tcp check", func() {
+					Expect(desiredLRP.CheckDefinition).To(Equal(&models.CheckDefinition{
+						Checks: []*models.Check{
+							{TcpCheck: &models.TCPCheck{Port: 8080}},
+						},
+					}))
+				})
+			})
+
+			Context("when the app uses a process health check", func() {
+				BeforeEach(func() {
+					lrp.Health = opi.Healtcheck{}
+					lrp.LRP = `{"health_check_type": "process", "health_check_timeout_ms": 3000}`
+				})
+
+				It("should not return a check definition", func() {
+					Expect(desiredLRP.CheckDefinition).To(BeNil())
+				})
+
+				It("should take the start timeout from the original request", func() {
+					Expect(desiredLRP.StartTimeoutMs).To(Equal(int64(3000)))
+				})
+			})
+
+			Context("when the app has no routes", func() {
+				BeforeEach(func() {
+					lrp.Metadata[cf.VcapAppUris] = ""
+					lrp.InternalRoutes = nil
+				})
+
+				It("should not return routes", func() {
+					Expect(desiredLRP.Routes).To(BeNil())
+				})
+			})
 		})
 
 		Context("when the app does not exist", func() {
@@ -406,6 +684,7 @@ var _ = Describe("Bifrost", func() {
 
 			It("should return an error", func() {
 				Expect(opiClient.GetCallCount()).To(Equal(1))
+				Expect(err).To(HaveOccurred())
 				Expect(desiredLRP).To(BeNil())
 			})
 		})
@@ -495,7 +774,7 @@ var _ = Describe("Bifrost", func() {
 			opiClient = new(opifakes.FakeDesirer)
 			lager = lagertest.NewTestLogger("bifrost-get-instances-test")
 			opiInstances = []*opi.Instance{
-				{Index: 0, Since: 123, State: opi.RunningState},
+				{Index: 0, Since: 123, State: opi.RunningState, Zone: "zone-a"},
 				{Index: 1, Since: 345, State: opi.CrashedState},
 				{Index: 2, Since: 678, State: opi.ErrorState, PlacementError: "this is not the place"},
 			}
@@ -525,7 +804,7 @@ var _ = Describe("Bifrost", func() {
 
 		It("should return all running instances", func() {
 			Expect(instances).To(Equal([]*cf.Instance{
-				{Index: 0, Since: 123, State: opi.RunningState},
+				{Index: 0, Since: 123, State: opi.RunningState, Zone: "zone-a"},
 				{Index: 1, Since: 345, State: opi.CrashedState},
 				{Index: 2, Since: 678, State: opi.ErrorState, PlacementError: "this is not the place"},
 			}))
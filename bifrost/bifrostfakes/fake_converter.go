@@ -23,6 +23,18 @@ type FakeConverter struct {
 		result1 opi.LRP
 		result2 error
 	}
+	ImageURIStub        func(string, string) string
+	imageURIMutex       sync.RWMutex
+	imageURIArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	imageURIReturns struct {
+		result1 string
+	}
+	imageURIReturnsOnCall map[int]struct {
+		result1 string
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -33,15 +45,16 @@ func (fake *FakeConverter) Convert(arg1 cf.DesireLRPRequest) (opi.LRP, error) {
 	fake.convertArgsForCall = append(fake.convertArgsForCall, struct {
 		arg1 cf.DesireLRPRequest
 	}{arg1})
+	stub := fake.ConvertStub
+	fakeReturns := fake.convertReturns
 	fake.recordInvocation("Convert", []interface{}{arg1})
 	fake.convertMutex.Unlock()
-	if fake.ConvertStub != nil {
-		return fake.ConvertStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.convertReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -90,11 +103,75 @@ func (fake *FakeConverter) ConvertReturnsOnCall(i int, result1 opi.LRP, result2
 	}{result1, result2}
 }
 
+func (fake *FakeConverter) ImageURI(arg1 string, arg2 string) string {
+	fake.imageURIMutex.Lock()
+	ret, specificReturn := fake.imageURIReturnsOnCall[len(fake.imageURIArgsForCall)]
+	fake.imageURIArgsForCall = append(fake.imageURIArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ImageURIStub
+	fakeReturns := fake.imageURIReturns
+	fake.recordInvocation("ImageURI", []interface{}{arg1, arg2})
+	fake.imageURIMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeConverter) ImageURICallCount() int {
+	fake.imageURIMutex.RLock()
+	defer fake.imageURIMutex.RUnlock()
+	return len(fake.imageURIArgsForCall)
+}
+
+func (fake *FakeConverter) ImageURICalls(stub func(string, string) string) {
+	fake.imageURIMutex.Lock()
+	defer fake.imageURIMutex.Unlock()
+	fake.ImageURIStub = stub
+}
+
+func (fake *FakeConverter) ImageURIArgsForCall(i int) (string, string) {
+	fake.imageURIMutex.RLock()
+	defer fake.imageURIMutex.RUnlock()
+	argsForCall := fake.imageURIArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeConverter) ImageURIReturns(result1 string) {
+	fake.imageURIMutex.Lock()
+	defer fake.imageURIMutex.Unlock()
+	fake.ImageURIStub = nil
+	fake.imageURIReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConverter) ImageURIReturnsOnCall(i int, result1 string) {
+	fake.imageURIMutex.Lock()
+	defer fake.imageURIMutex.Unlock()
+	fake.ImageURIStub = nil
+	if fake.imageURIReturnsOnCall == nil {
+		fake.imageURIReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.imageURIReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeConverter) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.convertMutex.RLock()
 	defer fake.convertMutex.RUnlock()
+	fake.imageURIMutex.RLock()
+	defer fake.imageURIMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
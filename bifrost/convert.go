@@ -1,6 +1,8 @@
 package bifrost
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"code.cloudfoundry.org/eirini"
@@ -10,6 +12,8 @@ import (
 	"code.cloudfoundry.org/lager"
 )
 
+const internalRouter = "internal-router"
+
 type DropletToImageConverter struct {
 	logger     lager.Logger
 	registryIP string
@@ -31,7 +35,7 @@ func (c *DropletToImageConverter) Convert(request cf.DesireLRPRequest) (opi.LRP,
 	}
 
 	if request.DockerImageURL == "" {
-		request.DockerImageURL = c.imageURI(request.DropletGUID, request.DropletHash)
+		request.DockerImageURL = c.ImageURI(request.DropletGUID, request.DropletHash)
 	}
 
 	routesJSON, err := getRequestedRoutes(request)
@@ -40,13 +44,25 @@ func (c *DropletToImageConverter) Convert(request cf.DesireLRPRequest) (opi.LRP,
 		panic(err)
 	}
 
-	lev := eirini.SetupEnv(request.StartCommand)
+	internalRoutes, err := getInternalRoutes(request.Routes)
+	if err != nil {
+		c.logger.Error("failed-to-parse-internal-routes", err, lager.Data{"app-guid": vcap.AppID})
+		return opi.LRP{}, err
+	}
+
+	lev := eirini.SetupEnv(request.StartCommand, request.Ports)
 
 	identifier := opi.LRPIdentifier{
 		GUID:    request.GUID,
 		Version: request.Version,
 	}
 
+	originalRequest, err := redactRequest(request.LRP)
+	if err != nil {
+		c.logger.Error("failed-to-redact-request", err, lager.Data{"app-guid": vcap.AppID})
+		return opi.LRP{}, err
+	}
+
 	volumeMounts := []opi.VolumeMount{}
 
 	for _, vm := range request.VolumeMounts {
@@ -56,21 +72,28 @@ func (c *DropletToImageConverter) Convert(request cf.DesireLRPRequest) (opi.LRP,
 		})
 	}
 
-	return opi.LRP{
+	lrp := opi.LRP{
 		AppName:         vcap.AppName,
 		SpaceName:       vcap.SpaceName,
+		SpaceGUID:       vcap.SpaceID,
+		OrgGUID:         vcap.OrgID,
 		LRPIdentifier:   identifier,
 		Image:           request.DockerImageURL,
 		TargetInstances: request.NumInstances,
 		Command:         append(eirini.InitProcess, eirini.Launch),
 		Env:             mergeMaps(request.Environment, lev),
 		Health: opi.Healtcheck{
-			Type:      request.HealthCheckType,
-			Endpoint:  request.HealthCheckHTTPEndpoint,
-			TimeoutMs: request.HealthCheckTimeoutMs,
-			Port:      int32(8080),
+			Type:                request.HealthCheckType,
+			Endpoint:            request.HealthCheckHTTPEndpoint,
+			ReadinessEndpoint:   request.HealthCheckReadinessHTTPEndpoint,
+			Command:             request.HealthCheckCommand,
+			TimeoutMs:           request.HealthCheckTimeoutMs,
+			InvocationTimeoutMs: request.HealthCheckInvocationTimeoutMs,
+			IntervalMs:          request.HealthCheckIntervalMs,
+			Port:                request.HealthCheckPort,
 		},
-		Ports: request.Ports,
+		Ports:          request.Ports,
+		InternalRoutes: internalRoutes,
 		Metadata: map[string]string{
 			cf.VcapAppName: vcap.AppName,
 			cf.VcapAppID:   vcap.AppID,
@@ -79,10 +102,51 @@ func (c *DropletToImageConverter) Convert(request cf.DesireLRPRequest) (opi.LRP,
 			cf.VcapAppUris: routesJSON,
 			cf.LastUpdated: request.LastUpdated,
 		},
-		MemoryMB:     request.MemoryMB,
-		CPUWeight:    request.CPUWeight,
-		VolumeMounts: volumeMounts,
-		LRP:          request.LRP,
+		MemoryMB:      request.MemoryMB,
+		DiskMB:        request.DiskMB,
+		CPUWeight:     request.CPUWeight,
+		VolumeMounts:  volumeMounts,
+		PlacementTags: request.PlacementTags,
+		LRP:           originalRequest,
+	}
+	lrp.Health.Port = lrp.HealthCheckPort()
+	return lrp, nil
+}
+
+func (c *DropletToImageConverter) ConvertTask(taskGUID string, request cf.TaskRequest) (opi.Task, error) {
+	image := request.DockerImageURL
+	if image == "" {
+		if request.DropletGUID == "" || request.DropletHash == "" {
+			err := errors.New("task request has neither a docker image nor a droplet")
+			c.logger.Error("failed-to-determine-task-image", err, lager.Data{"task-guid": taskGUID})
+			return opi.Task{}, err
+		}
+		image = c.ImageURI(request.DropletGUID, request.DropletHash)
+	}
+
+	env := map[string]string{}
+	for _, e := range request.Environment {
+		env[e.Name] = e.Value
+	}
+
+	// the namespace of a task is decided by its space and org, but a task
+	// without them can still run in the default namespace
+	vcap, err := parseVcapApplication(env["VCAP_APPLICATION"])
+	if err != nil {
+		c.logger.Info("task-without-vcap-application", lager.Data{"task-guid": taskGUID})
+	}
+
+	return opi.Task{
+		GUID:               taskGUID,
+		AppGUID:            request.AppGUID,
+		SpaceGUID:          vcap.SpaceID,
+		OrgGUID:            vcap.OrgID,
+		Image:              image,
+		Command:            append(eirini.InitProcess, eirini.Launch),
+		Env:                mergeMaps(env, eirini.SetupEnv(request.Command, nil)),
+		MemoryMB:           request.MemoryMB,
+		DiskMB:             request.DiskMB,
+		CompletionCallback: request.CompletionCallback,
 	}, nil
 }
 
@@ -104,7 +168,25 @@ func getRequestedRoutes(request cf.DesireLRPRequest) (string, error) {
 	return string(data), nil
 }
 
-func (c *DropletToImageConverter) imageURI(dropletGUID, dropletHash string) string {
+// getInternalRoutes returns the hostnames of the internal-router routes
+func getInternalRoutes(routes map[string]*json.RawMessage) ([]string, error) {
+	hostnames := []string{}
+	internalRoutesJSON, ok := routes[internalRouter]
+	if !ok || internalRoutesJSON == nil {
+		return hostnames, nil
+	}
+
+	var internalRoutes []cf.InternalRoute
+	if err := json.Unmarshal(*internalRoutesJSON, &internalRoutes); err != nil {
+		return nil, err
+	}
+	for _, route := range internalRoutes {
+		hostnames = append(hostnames, route.Hostname)
+	}
+	return hostnames, nil
+}
+
+func (c *DropletToImageConverter) ImageURI(dropletGUID, dropletHash string) string {
 	return fmt.Sprintf("%s/cloudfoundry/%s:%s", c.registryIP, dropletGUID, dropletHash)
 }
 
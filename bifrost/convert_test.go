@@ -45,6 +45,7 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 		Expect(marshalErr).ToNot(HaveOccurred())
 
 		rawJSON := json.RawMessage(routesJSON)
+		internalRoutesJSON := json.RawMessage(`[{"hostname":"bumblebee.apps.internal"}]`)
 		desireLRPRequest = cf.DesireLRPRequest{
 			GUID:           "b194809b-88c0-49af-b8aa-69da097fc360",
 			Version:        "2fdc448f-6bac-4085-9426-87d0124c433a",
@@ -55,19 +56,25 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 			LastUpdated:    "23534635232.3",
 			NumInstances:   3,
 			MemoryMB:       456,
+			DiskMB:         2048,
 			CPUWeight:      50,
 			Environment: map[string]string{
-				"VCAP_APPLICATION": `{"application_name":"bumblebee", "space_name":"transformers", "application_id":"b194809b-88c0-49af-b8aa-69da097fc360", "version": "something-something-uuid", "application_uris":["bumblebee.example.com", "transformers.example.com"]}`,
+				"VCAP_APPLICATION": `{"application_name":"bumblebee", "space_name":"transformers", "space_id":"space-guid", "organization_id":"org-guid", "application_id":"b194809b-88c0-49af-b8aa-69da097fc360", "version": "something-something-uuid", "application_uris":["bumblebee.example.com", "transformers.example.com"]}`,
 				"VCAP_SERVICES":    `"user-provided": [{"binding_name": "bind-it-like-beckham","credentials": {"password": "notpassword1","username": "admin"},"instance_name": "dora","name": "serve"}]`,
 				"PORT":             "8080",
 			},
-			StartCommand:            "start me",
-			HealthCheckType:         "http",
-			HealthCheckHTTPEndpoint: "/heat",
-			HealthCheckTimeoutMs:    400,
-			Ports:                   []int32{8080, 8888},
+			StartCommand:                     "start me",
+			PlacementTags:                    []string{"dedicated"},
+			HealthCheckType:                  "http",
+			HealthCheckHTTPEndpoint:          "/heat",
+			HealthCheckTimeoutMs:             400,
+			HealthCheckReadinessHTTPEndpoint: "/ready",
+			HealthCheckInvocationTimeoutMs:   2000,
+			HealthCheckIntervalMs:            10000,
+			Ports:                            []int32{8080, 8888},
 			Routes: map[string]*json.RawMessage{
-				"cf-router": &rawJSON,
+				"cf-router":       &rawJSON,
+				"internal-router": &internalRoutesJSON,
 			},
 			VolumeMounts: []cf.VolumeMount{
 				{
@@ -79,7 +86,7 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 					MountDir: "/path/two",
 				},
 			},
-			LRP: "full LRP request",
+			LRP: `{"process_guid": "b194809b-88c0-49af-b8aa-69da097fc360-2fdc448f-6bac-4085-9426-87d0124c433a", "environment": {"PORT": "8080", "VCAP_SERVICES": "{\"credentials\": \"secret\"}"}}`,
 		}
 	})
 
@@ -107,6 +114,11 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 				Expect(lrp.SpaceName).To(Equal("transformers"))
 			})
 
+			It("should set the space and org guids", func() {
+				Expect(lrp.SpaceGUID).To(Equal("space-guid"))
+				Expect(lrp.OrgGUID).To(Equal("org-guid"))
+			})
+
 			It("should set the correct TargetInstances", func() {
 				Expect(lrp.TargetInstances).To(Equal(3))
 			})
@@ -124,6 +136,10 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 				Expect(lrp.MemoryMB).To(Equal(int64(456)))
 			})
 
+			It("should set the lrp disk quota", func() {
+				Expect(lrp.DiskMB).To(Equal(int64(2048)))
+			})
+
 			It("should store the VCAP env variable as metadata", func() {
 				Expect(lrp.Metadata[cf.VcapAppName]).To(Equal("bumblebee"))
 				Expect(lrp.Metadata[cf.VcapAppID]).To(Equal("b194809b-88c0-49af-b8aa-69da097fc360"))
@@ -160,6 +176,12 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 				Expect(val).To(Equal("8080"))
 			})
 
+			It("should set the instance environment from the ports", func() {
+				Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_ADDR", "0.0.0.0:8080"))
+				Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_PORT", "8080"))
+				Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_PORTS", `[{"external":8080,"internal":8080},{"external":8888,"internal":8888}]`))
+			})
+
 			It("should set the start command env variable", func() {
 				val, ok := lrp.Env["START_COMMAND"]
 				Expect(ok).To(BeTrue())
@@ -172,12 +194,23 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 				Expect(health.Port).To(Equal(int32(8080)))
 				Expect(health.Endpoint).To(Equal("/heat"))
 				Expect(health.TimeoutMs).To(Equal(uint(400)))
+				Expect(health.ReadinessEndpoint).To(Equal("/ready"))
+				Expect(health.InvocationTimeoutMs).To(Equal(uint(2000)))
+				Expect(health.IntervalMs).To(Equal(uint(10000)))
 			})
 
 			It("sets the app routes", func() {
 				Expect(lrp.Metadata[cf.VcapAppUris]).To(Equal(`[{"hostname":"bumblebee.example.com","port":8080},{"hostname":"transformers.example.com","port":7070}]`))
 			})
 
+			It("sets the placement tags", func() {
+				Expect(lrp.PlacementTags).To(ConsistOf("dedicated"))
+			})
+
+			It("sets the internal routes", func() {
+				Expect(lrp.InternalRoutes).To(ConsistOf("bumblebee.apps.internal"))
+			})
+
 			It("should set the ports", func() {
 				Expect(lrp.Ports).To(Equal([]int32{8080, 8888}))
 			})
@@ -195,8 +228,13 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 				}))
 			})
 
-			It("should set the LRP request", func() {
-				Expect(lrp.LRP).To(Equal("full LRP request"))
+			It("should set the LRP request without the service credentials", func() {
+				var request cf.DesireLRPRequest
+				Expect(json.Unmarshal([]byte(lrp.LRP), &request)).To(Succeed())
+				Expect(request.ProcessGUID).To(Equal("b194809b-88c0-49af-b8aa-69da097fc360-2fdc448f-6bac-4085-9426-87d0124c433a"))
+				Expect(request.Environment).To(HaveKeyWithValue("PORT", "8080"))
+				Expect(request.Environment).To(HaveKeyWithValue("VCAP_SERVICES", bifrost.RedactedValue))
+				Expect(lrp.LRP).ToNot(ContainSubstring("secret"))
 			})
 		}
 
@@ -208,6 +246,43 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 			verifyLRPConvertedSuccessfully()
 		})
 
+		Context("When the app listens on other ports", func() {
+			BeforeEach(func() {
+				desireLRPRequest.Ports = []int32{9000, 9001}
+			})
+
+			It("should check the health of the first port", func() {
+				Expect(lrp.Health.Port).To(Equal(int32(9000)))
+			})
+
+			It("should set the instance environment from the first port", func() {
+				Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_ADDR", "0.0.0.0:9000"))
+				Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_PORT", "9000"))
+				Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_PORTS", `[{"external":9000,"internal":9000},{"external":9001,"internal":9001}]`))
+			})
+
+			Context("and the health check port is requested", func() {
+				BeforeEach(func() {
+					desireLRPRequest.HealthCheckPort = 9001
+				})
+
+				It("should check the health of the requested port", func() {
+					Expect(lrp.Health.Port).To(Equal(int32(9001)))
+				})
+			})
+		})
+
+		Context("When the app declares no ports", func() {
+			BeforeEach(func() {
+				desireLRPRequest.Ports = nil
+			})
+
+			It("should default to port 8080", func() {
+				Expect(lrp.Health.Port).To(Equal(int32(8080)))
+				Expect(lrp.Env).To(HaveKeyWithValue("CF_INSTANCE_PORTS", `[{"external":8080,"internal":8080}]`))
+			})
+		})
+
 		Context("When the Docker Image Url is not provided", func() {
 			BeforeEach(func() {
 				desireLRPRequest.DockerImageURL = ""
@@ -229,6 +304,16 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 	})
 
 	Context("When the request fails to be converted", func() {
+		Context("When the original request is not valid JSON", func() {
+			BeforeEach(func() {
+				desireLRPRequest.LRP = `{something is wrong`
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
 		Context("When VCAP_APPLICATION env variable is invalid", func() {
 			BeforeEach(func() {
 				desireLRPRequest.Environment = map[string]string{
@@ -242,3 +327,100 @@ var _ = Describe("Convert CC DesiredApp into an opi LRP", func() {
 		})
 	})
 })
+
+var _ = Describe("Convert CC TaskRequest into an opi Task", func() {
+	var (
+		task      opi.Task
+		err       error
+		request   cf.TaskRequest
+		converter bifrost.TaskConverter
+	)
+
+	BeforeEach(func() {
+		request = cf.TaskRequest{
+			AppGUID:            "app-guid",
+			Name:               "migrate",
+			Command:            "rake db:migrate",
+			CompletionCallback: "example.com/call/me/maybe",
+			DropletGUID:        "the-droplet-guid",
+			DropletHash:        "the-droplet-hash",
+			MemoryMB:           256,
+			DiskMB:             1024,
+			Environment: []cf.EnvironmentVariable{
+				{Name: "HOWARD", Value: "the alien"},
+				{Name: "START_COMMAND", Value: "should be overridden"},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		converter = bifrost.NewConverter(lagertest.NewTestLogger("test"), "eirini-registry.service.cf.internal")
+		task, err = converter.ConvertTask("task-guid", request)
+	})
+
+	It("should not return an error", func() {
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should set the task and app guids", func() {
+		Expect(task.GUID).To(Equal("task-guid"))
+		Expect(task.AppGUID).To(Equal("app-guid"))
+	})
+
+	It("should use the droplet image from the registry", func() {
+		Expect(task.Image).To(Equal("eirini-registry.service.cf.internal/cloudfoundry/the-droplet-guid:the-droplet-hash"))
+	})
+
+	It("should run the command through the launcher", func() {
+		Expect(task.Command).To(Equal(append(eirini.InitProcess, eirini.Launch)))
+		Expect(task.Env).To(HaveKeyWithValue("START_COMMAND", "rake db:migrate"))
+	})
+
+	It("should set the request environment", func() {
+		Expect(task.Env).To(HaveKeyWithValue("HOWARD", "the alien"))
+	})
+
+	It("should set the memory and disk", func() {
+		Expect(task.MemoryMB).To(Equal(int64(256)))
+		Expect(task.DiskMB).To(Equal(int64(1024)))
+	})
+
+	It("should set the completion callback", func() {
+		Expect(task.CompletionCallback).To(Equal("example.com/call/me/maybe"))
+	})
+
+	Context("When the VCAP_APPLICATION env variable is provided", func() {
+		BeforeEach(func() {
+			request.Environment = append(request.Environment, cf.EnvironmentVariable{
+				Name:  "VCAP_APPLICATION",
+				Value: `{"space_id":"space-guid", "organization_id":"org-guid"}`,
+			})
+		})
+
+		It("should set the space and org guids", func() {
+			Expect(task.SpaceGUID).To(Equal("space-guid"))
+			Expect(task.OrgGUID).To(Equal("org-guid"))
+		})
+	})
+
+	Context("When the Docker image is provided", func() {
+		BeforeEach(func() {
+			request.DockerImageURL = "the-image-url"
+		})
+
+		It("should use the docker image", func() {
+			Expect(task.Image).To(Equal("the-image-url"))
+		})
+	})
+
+	Context("When neither a droplet nor a docker image is provided", func() {
+		BeforeEach(func() {
+			request.DropletGUID = ""
+			request.DropletHash = ""
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
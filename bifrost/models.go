@@ -3,6 +3,7 @@ package bifrost
 import (
 	"encoding/json"
 
+	"code.cloudfoundry.org/eirini"
 	"code.cloudfoundry.org/eirini/models/cf"
 	"code.cloudfoundry.org/eirini/opi"
 )
@@ -10,6 +11,12 @@ import (
 //go:generate counterfeiter . Converter
 type Converter interface {
 	Convert(request cf.DesireLRPRequest) (opi.LRP, error)
+	ImageURI(dropletGUID, dropletHash string) string
+}
+
+//go:generate counterfeiter . TaskConverter
+type TaskConverter interface {
+	ConvertTask(taskGUID string, request cf.TaskRequest) (opi.Task, error)
 }
 
 func parseVcapApplication(vcap string) (cf.VcapApp, error) {
@@ -20,3 +27,46 @@ func parseVcapApplication(vcap string) (cf.VcapApp, error) {
 
 	return vcapApp, nil
 }
+
+// RedactedValue replaces the values of sensitive environment variables in
+// the requests which are stored with apps
+const RedactedValue = "[REDACTED]"
+
+// redactRequest replaces the values of sensitive environment variables in a
+// raw desire request, so that it can be stored with the app. Requests with
+// different credentials are told apart by the env secret of the app.
+func redactRequest(request string) (string, error) {
+	if request == "" {
+		return "", nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(request), &fields); err != nil {
+		return "", err
+	}
+
+	envJSON, ok := fields["environment"]
+	if !ok {
+		return request, nil
+	}
+
+	var env map[string]string
+	if err := json.Unmarshal(envJSON, &env); err != nil {
+		return "", err
+	}
+
+	for name := range env {
+		if eirini.IsSensitiveEnvVar(name) {
+			env[name] = RedactedValue
+		}
+	}
+
+	redactedEnv, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+	fields["environment"] = redactedEnv
+
+	redacted, err := json.Marshal(fields)
+	return string(redacted), err
+}
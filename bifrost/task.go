@@ -0,0 +1,120 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/eirini/models/cf"
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/lager"
+)
+
+const TaskCancelledReason = "task was cancelled"
+
+type Task struct {
+	Converter   TaskConverter
+	TaskDesirer opi.TaskDesirer
+	HTTPClient  *http.Client
+	Logger      lager.Logger
+}
+
+func (t *Task) TransferTask(ctx context.Context, taskGUID string, request cf.TaskRequest) error {
+	task, err := t.Converter.ConvertTask(taskGUID, request)
+	if err != nil {
+		t.Logger.Error("failed-to-convert-task-request", err, lager.Data{"task-guid": taskGUID})
+		return opi.NewInvalidError("failed to convert task request: %s", err.Error())
+	}
+	return t.TaskDesirer.Desire(&task)
+}
+
+func (t *Task) GetTask(ctx context.Context, taskGUID string) (*cf.TaskResponse, error) {
+	status, err := t.TaskDesirer.Get(taskGUID)
+	if err != nil {
+		t.Logger.Error("failed-to-get-task", err, lager.Data{"task-guid": taskGUID})
+		return nil, errors.Wrap(err, "failed to get task")
+	}
+
+	return &cf.TaskResponse{
+		TaskGUID:      status.GUID,
+		State:         status.State,
+		FailureReason: status.FailureReason,
+	}, nil
+}
+
+func (t *Task) CancelTask(ctx context.Context, taskGUID string) error {
+	status, err := t.TaskDesirer.Get(taskGUID)
+	if err != nil {
+		t.Logger.Error("failed-to-get-task", err, lager.Data{"task-guid": taskGUID})
+		return errors.Wrap(err, "failed to get task")
+	}
+
+	if err = t.TaskDesirer.Delete(taskGUID); err != nil {
+		t.Logger.Error("failed-to-delete-task", err, lager.Data{"task-guid": taskGUID})
+		return errors.Wrap(err, "failed to delete task")
+	}
+
+	return t.sendCompletionCallback(status.CompletionCallback, &models.TaskCallbackResponse{
+		TaskGuid:      taskGUID,
+		Failed:        true,
+		FailureReason: TaskCancelledReason,
+	})
+}
+
+func (t *Task) CompleteTask(ctx context.Context, taskGUID string) error {
+	status, err := t.TaskDesirer.Get(taskGUID)
+	if err != nil {
+		t.Logger.Error("failed-to-get-task", err, lager.Data{"task-guid": taskGUID})
+		return errors.Wrap(err, "failed to get task")
+	}
+
+	if status.State != opi.TaskSucceededState && status.State != opi.TaskFailedState {
+		return opi.NewConflictError("task %s has not finished, current state: %s", taskGUID, status.State)
+	}
+
+	err = t.sendCompletionCallback(status.CompletionCallback, &models.TaskCallbackResponse{
+		TaskGuid:      taskGUID,
+		Failed:        status.State == opi.TaskFailedState,
+		FailureReason: status.FailureReason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return t.TaskDesirer.Delete(taskGUID)
+}
+
+func (t *Task) sendCompletionCallback(callbackURI string, response *models.TaskCallbackResponse) error {
+	l := t.Logger.Session("task-completion-callback", lager.Data{"task-guid": response.TaskGuid, "callback-uri": callbackURI})
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		l.Error("failed-to-marshal-response", err)
+		return err
+	}
+
+	request, err := http.NewRequest("POST", callbackURI, bytes.NewBuffer(body))
+	if err != nil {
+		l.Error("failed-to-create-callback-request", err)
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.HTTPClient.Do(request)
+	if err != nil {
+		l.Error("cc-task-complete-failed", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		l.Error("cc-task-complete-failed-status-code", nil, lager.Data{"status-code": resp.StatusCode})
+		return fmt.Errorf("callback-response-unsuccessful, code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package bifrost_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/bifrost"
+	"code.cloudfoundry.org/eirini/bifrost/bifrostfakes"
+	"code.cloudfoundry.org/eirini/models/cf"
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/eirini/opi/opifakes"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Task", func() {
+
+	var (
+		err         error
+		taskBifrost eirini.TaskBifrost
+		converter   *bifrostfakes.FakeTaskConverter
+		taskDesirer *opifakes.FakeTaskDesirer
+		server      *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		converter = new(bifrostfakes.FakeTaskConverter)
+		taskDesirer = new(opifakes.FakeTaskDesirer)
+		server = ghttp.NewServer()
+
+		taskBifrost = &bifrost.Task{
+			Converter:   converter,
+			TaskDesirer: taskDesirer,
+			HTTPClient:  &http.Client{},
+			Logger:      lagertest.NewTestLogger("task-bifrost"),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("TransferTask", func() {
+		var (
+			request cf.TaskRequest
+			task    opi.Task
+		)
+
+		BeforeEach(func() {
+			request = cf.TaskRequest{AppGUID: "app-guid", Command: "rake db:migrate"}
+			task = opi.Task{GUID: "task-guid", Image: "docker.png"}
+			converter.ConvertTaskReturns(task, nil)
+		})
+
+		JustBeforeEach(func() {
+			err = taskBifrost.TransferTask(context.Background(), "task-guid", request)
+		})
+
+		It("should not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should convert the task request", func() {
+			Expect(converter.ConvertTaskCallCount()).To(Equal(1))
+			taskGUID, convertedRequest := converter.ConvertTaskArgsForCall(0)
+			Expect(taskGUID).To(Equal("task-guid"))
+			Expect(convertedRequest).To(Equal(request))
+		})
+
+		It("should desire the converted task", func() {
+			Expect(taskDesirer.DesireCallCount()).To(Equal(1))
+			Expect(taskDesirer.DesireArgsForCall(0)).To(Equal(&task))
+		})
+
+		Context("when converting the task fails", func() {
+			BeforeEach(func() {
+				converter.ConvertTaskReturns(opi.Task{}, errors.New("failed-to-convert"))
+			})
+
+			It("should return an invalid error", func() {
+				Expect(err).To(MatchError("failed to convert task request: failed-to-convert"))
+				Expect(opi.IsInvalid(err)).To(BeTrue())
+			})
+
+			It("should not desire the task", func() {
+				Expect(taskDesirer.DesireCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when desiring the task fails", func() {
+			BeforeEach(func() {
+				taskDesirer.DesireReturns(errors.New("failed-to-desire"))
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(MatchError("failed-to-desire"))
+			})
+		})
+	})
+
+	Context("GetTask", func() {
+		var response *cf.TaskResponse
+
+		BeforeEach(func() {
+			taskDesirer.GetReturns(&opi.TaskStatus{
+				GUID:          "task-guid",
+				State:         opi.TaskFailedState,
+				FailureReason: "it broke",
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			response, err = taskBifrost.GetTask(context.Background(), "task-guid")
+		})
+
+		It("should get the task from the desirer", func() {
+			Expect(taskDesirer.GetCallCount()).To(Equal(1))
+			Expect(taskDesirer.GetArgsForCall(0)).To(Equal("task-guid"))
+		})
+
+		It("should return the task state", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response).To(Equal(&cf.TaskResponse{
+				TaskGUID:      "task-guid",
+				State:         opi.TaskFailedState,
+				FailureReason: "it broke",
+			}))
+		})
+
+		Context("when the task cannot be retrieved", func() {
+			BeforeEach(func() {
+				taskDesirer.GetReturns(nil, errors.New("boom"))
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("CompleteTask", func() {
+		BeforeEach(func() {
+			taskDesirer.GetReturns(&opi.TaskStatus{
+				GUID:               "task-guid",
+				State:              opi.TaskSucceededState,
+				CompletionCallback: server.URL() + "/call/me/maybe",
+			}, nil)
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/call/me/maybe"),
+					ghttp.VerifyJSON(`{
+						"task_guid": "task-guid",
+						"failed": false,
+						"failure_reason": "",
+						"result": "",
+						"created_at": 0
+					}`),
+				),
+			)
+		})
+
+		JustBeforeEach(func() {
+			err = taskBifrost.CompleteTask(context.Background(), "task-guid")
+		})
+
+		It("should not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should send the completion callback", func() {
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("should delete the task", func() {
+			Expect(taskDesirer.DeleteCallCount()).To(Equal(1))
+			Expect(taskDesirer.DeleteArgsForCall(0)).To(Equal("task-guid"))
+		})
+
+		Context("when the task failed", func() {
+			BeforeEach(func() {
+				taskDesirer.GetReturns(&opi.TaskStatus{
+					GUID:               "task-guid",
+					State:              opi.TaskFailedState,
+					FailureReason:      "Job has reached the specified backoff limit",
+					CompletionCallback: server.URL() + "/call/me/maybe",
+				}, nil)
+
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/call/me/maybe"),
+					ghttp.VerifyJSON(`{
+						"task_guid": "task-guid",
+						"failed": true,
+						"failure_reason": "Job has reached the specified backoff limit",
+						"result": "",
+						"created_at": 0
+					}`),
+				))
+			})
+
+			It("should report the failure", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("when the task is still running", func() {
+			BeforeEach(func() {
+				taskDesirer.GetReturns(&opi.TaskStatus{
+					GUID:  "task-guid",
+					State: opi.TaskRunningState,
+				}, nil)
+			})
+
+			It("should return a conflict error", func() {
+				Expect(opi.IsConflict(err)).To(BeTrue())
+			})
+
+			It("should not send the completion callback", func() {
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("should not delete the task", func() {
+				Expect(taskDesirer.DeleteCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the callback fails", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.RespondWith(http.StatusInternalServerError, ""))
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should not delete the task", func() {
+				Expect(taskDesirer.DeleteCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("CancelTask", func() {
+		BeforeEach(func() {
+			taskDesirer.GetReturns(&opi.TaskStatus{
+				GUID:               "task-guid",
+				State:              opi.TaskRunningState,
+				CompletionCallback: server.URL() + "/call/me/maybe",
+			}, nil)
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/call/me/maybe"),
+					ghttp.VerifyJSON(`{
+						"task_guid": "task-guid",
+						"failed": true,
+						"failure_reason": "task was cancelled",
+						"result": "",
+						"created_at": 0
+					}`),
+				),
+			)
+		})
+
+		JustBeforeEach(func() {
+			err = taskBifrost.CancelTask(context.Background(), "task-guid")
+		})
+
+		It("should not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should delete the task", func() {
+			Expect(taskDesirer.DeleteCallCount()).To(Equal(1))
+			Expect(taskDesirer.DeleteArgsForCall(0)).To(Equal("task-guid"))
+		})
+
+		It("should report the cancellation to the completion callback", func() {
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		Context("when deleting the task fails", func() {
+			BeforeEach(func() {
+				taskDesirer.DeleteReturns(errors.New("boom"))
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should not send the completion callback", func() {
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
+})
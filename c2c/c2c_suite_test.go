@@ -0,0 +1,13 @@
+package c2c_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestC2C(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "C2C Suite")
+}
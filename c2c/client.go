@@ -0,0 +1,132 @@
+package c2c
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxIDsPerRequest keeps the query of a policy request within the URL
+// length limits of the policy server
+const maxIDsPerRequest = 50
+
+type Source struct {
+	ID string `json:"id"`
+}
+
+type Ports struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type Destination struct {
+	ID       string `json:"id"`
+	Protocol string `json:"protocol"`
+	Ports    Ports  `json:"ports"`
+}
+
+// Policy allows the instances of the source app to connect to the ports of
+// the destination app
+type Policy struct {
+	Source      Source      `json:"source"`
+	Destination Destination `json:"destination"`
+}
+
+type policyList struct {
+	Policies []Policy `json:"policies"`
+}
+
+//go:generate counterfeiter . PolicyClient
+type PolicyClient interface {
+	// Policies returns the policies which any of the given apps is the
+	// source or the destination of
+	Policies(appGUIDs ...string) ([]Policy, error)
+}
+
+type policyServerClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPolicyServerClient returns a client of the internal API of the CF
+// network policy server at baseURL
+func NewPolicyServerClient(baseURL string, httpClient *http.Client) PolicyClient {
+	return &policyServerClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+func (c *policyServerClient) Policies(appGUIDs ...string) ([]Policy, error) {
+	policies := []Policy{}
+	for start := 0; start < len(appGUIDs); start += maxIDsPerRequest {
+		end := start + maxIDsPerRequest
+		if end > len(appGUIDs) {
+			end = len(appGUIDs)
+		}
+
+		list, err := c.getPolicies(appGUIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, list.Policies...)
+	}
+	return policies, nil
+}
+
+func (c *policyServerClient) getPolicies(appGUIDs []string) (*policyList, error) {
+	query := url.Values{"id": {strings.Join(appGUIDs, ",")}}
+	resp, err := c.httpClient.Get(fmt.Sprintf("%s/networking/v1/internal/policies?%s", c.baseURL, query.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get policies")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get policies: policy server returned status code %d", resp.StatusCode)
+	}
+
+	var list policyList
+	if err = json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.Wrap(err, "failed to decode policies")
+	}
+	return &list, nil
+}
+
+// FileClient stands in for the policy server where there is none. It reads
+// the policies from a file in the format of the policy server API on every
+// request, so they can be changed without restarting.
+type FileClient struct {
+	Path string
+}
+
+func (c *FileClient) Policies(appGUIDs ...string) ([]Policy, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(c.Path))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read policies")
+	}
+
+	var list policyList
+	if err = json.Unmarshal(data, &list); err != nil {
+		return nil, errors.Wrap(err, "failed to decode policies")
+	}
+
+	ids := map[string]bool{}
+	for _, guid := range appGUIDs {
+		ids[guid] = true
+	}
+
+	policies := []Policy{}
+	for _, policy := range list.Policies {
+		if ids[policy.Source.ID] || ids[policy.Destination.ID] {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
@@ -0,0 +1,122 @@
+package c2c_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	. "code.cloudfoundry.org/eirini/c2c"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+const policiesJSON = `{
+	"total_policies": 2,
+	"policies": [
+		{"source": {"id": "frontend", "tag": "0001"}, "destination": {"id": "backend", "tag": "0002", "protocol": "tcp", "ports": {"start": 8080, "end": 8081}}},
+		{"source": {"id": "other", "tag": "0003"}, "destination": {"id": "db", "tag": "0004", "protocol": "udp", "ports": {"start": 53, "end": 53}}}
+	]
+}`
+
+var _ = Describe("PolicyClient", func() {
+
+	var (
+		client   PolicyClient
+		policies []Policy
+		getErr   error
+	)
+
+	backendPolicy := Policy{
+		Source:      Source{ID: "frontend"},
+		Destination: Destination{ID: "backend", Protocol: "tcp", Ports: Ports{Start: 8080, End: 8081}},
+	}
+
+	Context("When the policies are on the policy server", func() {
+
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			client = NewPolicyServerClient(server.URL(), &http.Client{})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should get the policies of the apps", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/networking/v1/internal/policies", "id=frontend%2Cbackend"),
+				ghttp.RespondWith(http.StatusOK, policiesJSON),
+			))
+
+			policies, getErr = client.Policies("frontend", "backend")
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(policies).To(HaveLen(2))
+			Expect(policies[0]).To(Equal(backendPolicy))
+		})
+
+		It("should request the policies of many apps in batches", func() {
+			guids := []string{}
+			for i := 0; i < 60; i++ {
+				guids = append(guids, fmt.Sprintf("app-%d", i))
+			}
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/networking/v1/internal/policies", "id="+strings.Join(guids[:50], "%2C")),
+					ghttp.RespondWith(http.StatusOK, policiesJSON),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/networking/v1/internal/policies", "id="+strings.Join(guids[50:], "%2C")),
+					ghttp.RespondWith(http.StatusOK, `{"policies": []}`),
+				),
+			)
+
+			policies, getErr = client.Policies(guids...)
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(policies).To(HaveLen(2))
+		})
+
+		It("should fail when the policy server fails", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+			_, getErr = client.Policies("frontend")
+			Expect(getErr).To(MatchError(ContainSubstring("status code 500")))
+		})
+	})
+
+	Context("When the policies are in a file", func() {
+
+		var path string
+
+		BeforeEach(func() {
+			file, err := ioutil.TempFile("", "policies")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = file.WriteString(policiesJSON)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(file.Close()).To(Succeed())
+
+			path = file.Name()
+			client = &FileClient{Path: path}
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(path)).To(Succeed())
+		})
+
+		It("should return the policies of the apps", func() {
+			policies, getErr = client.Policies("backend")
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(policies).To(ConsistOf(backendPolicy))
+		})
+
+		It("should fail when the file does not exist", func() {
+			client = &FileClient{Path: "/does/not/exist"}
+			_, getErr = client.Policies("backend")
+			Expect(getErr).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,126 @@
+package c2c
+
+import (
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/eirini/k8s"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolicyLabel marks the network policies of apps with the guid of the app
+// they apply to
+const PolicyLabel = "c2c_policy"
+
+func policyName(appGUID string) string {
+	return fmt.Sprintf("c2c-%s", appGUID)
+}
+
+// toNetworkPolicy only lets other apps connect to the instances of an app
+// as its policies allow, like the overlay network of Diego does. Pods which
+// are not apps, and the ingressCIDRs outside of the cluster, such as the
+// gorouters, can always connect.
+func toNetworkPolicy(appGUID string, policies []Policy, ingressCIDRs []string) (*networkingv1.NetworkPolicy, []error) {
+	ingress := []networkingv1.NetworkPolicyIngressRule{nonAppIngressRule(ingressCIDRs)}
+	errs := []error{}
+	for _, policy := range policies {
+		if policy.Destination.ID != appGUID {
+			continue
+		}
+		rule, err := toIngressRule(policy)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ingress = append(ingress, rule)
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   policyName(appGUID),
+			Labels: map[string]string{PolicyLabel: appGUID},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: meta.LabelSelector{
+				MatchLabels: map[string]string{
+					"guid":        appGUID,
+					"source_type": k8s.AppSourceType,
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     ingress,
+		},
+	}, errs
+}
+
+func nonAppIngressRule(ingressCIDRs []string) networkingv1.NetworkPolicyIngressRule {
+	rule := networkingv1.NetworkPolicyIngressRule{
+		From: []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &meta.LabelSelector{},
+				PodSelector: &meta.LabelSelector{
+					MatchExpressions: []meta.LabelSelectorRequirement{
+						{
+							Key:      "source_type",
+							Operator: meta.LabelSelectorOpNotIn,
+							Values:   []string{k8s.AppSourceType, k8s.TaskSourceType, k8s.StagingSourceType},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, cidr := range ingressCIDRs {
+		rule.From = append(rule.From, networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{CIDR: cidr},
+		})
+	}
+	return rule
+}
+
+// toIngressRule lets the instances and tasks of the source app of a policy
+// connect to its ports
+func toIngressRule(policy Policy) (networkingv1.NetworkPolicyIngressRule, error) {
+	var protocol corev1.Protocol
+	switch strings.ToLower(policy.Destination.Protocol) {
+	case "tcp":
+		protocol = corev1.ProtocolTCP
+	case "udp":
+		protocol = corev1.ProtocolUDP
+	default:
+		return networkingv1.NetworkPolicyIngressRule{}, fmt.Errorf("unsupported protocol %q", policy.Destination.Protocol)
+	}
+
+	first, last := policy.Destination.Ports.Start, policy.Destination.Ports.End
+	if last == 0 {
+		last = first
+	}
+	if first < 1 || last > 65535 || first > last {
+		return networkingv1.NetworkPolicyIngressRule{}, fmt.Errorf("invalid ports %d-%d", first, last)
+	}
+	ports, err := k8s.NetworkPolicyPorts(protocol, first, last)
+	if err != nil {
+		return networkingv1.NetworkPolicyIngressRule{}, err
+	}
+
+	return networkingv1.NetworkPolicyIngressRule{
+		From: []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &meta.LabelSelector{},
+				PodSelector: &meta.LabelSelector{
+					MatchLabels: map[string]string{"guid": policy.Source.ID},
+					MatchExpressions: []meta.LabelSelectorRequirement{
+						{
+							Key:      "source_type",
+							Operator: meta.LabelSelectorOpIn,
+							Values:   []string{k8s.AppSourceType, k8s.TaskSourceType},
+						},
+					},
+				},
+			},
+		},
+		Ports: ports,
+	}, nil
+}
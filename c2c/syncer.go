@@ -0,0 +1,178 @@
+package c2c
+
+import (
+	"fmt"
+	"reflect"
+
+	"code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/eirini/route"
+	"code.cloudfoundry.org/lager"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Syncer keeps a NetworkPolicy for every app, which is applied when the
+// app is desired and removed when it is stopped. Policies change without
+// the apps changing, so they are synced periodically as well.
+type Syncer struct {
+	client       kubernetes.Interface
+	policies     PolicyClient
+	namespace    string
+	ingressCIDRs []string
+	scheduler    route.TaskScheduler
+	logger       lager.Logger
+}
+
+type app struct {
+	namespace string
+	guid      string
+}
+
+func NewSyncer(client kubernetes.Interface, policies PolicyClient, namespace string, ingressCIDRs []string, scheduler route.TaskScheduler, logger lager.Logger) *Syncer {
+	return &Syncer{
+		client:       client,
+		policies:     policies,
+		namespace:    namespace,
+		ingressCIDRs: ingressCIDRs,
+		scheduler:    scheduler,
+		logger:       logger,
+	}
+}
+
+func (s *Syncer) Start() {
+	s.scheduler.Schedule(s.Sync)
+}
+
+func (s *Syncer) Sync() error {
+	apps, err := s.listApps(s.namespace, "")
+	if err != nil {
+		return err
+	}
+
+	guids := []string{}
+	seen := map[string]bool{}
+	for a := range apps {
+		if !seen[a.guid] {
+			seen[a.guid] = true
+			guids = append(guids, a.guid)
+		}
+	}
+
+	if len(guids) > 0 {
+		policies, err := s.policies.Policies(guids...)
+		if err != nil {
+			// without the policies it is unknown which ones are stale
+			return errors.Wrap(err, "failed to get policies")
+		}
+		for a := range apps {
+			if err = s.apply(a, policies); err != nil {
+				s.logger.Error("failed-to-apply-network-policy", err, lager.Data{"namespace": a.namespace, "app-guid": a.guid})
+			}
+		}
+	}
+
+	return s.deleteStaleNetworkPolicies(apps)
+}
+
+func (s *Syncer) AppDesired(namespace, appGUID string) error {
+	policies, err := s.policies.Policies(appGUID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get policies")
+	}
+	return s.apply(app{namespace: namespace, guid: appGUID}, policies)
+}
+
+func (s *Syncer) AppStopped(namespace, appGUID string) error {
+	apps, err := s.listApps(namespace, appGUID)
+	if err != nil {
+		return err
+	}
+	if len(apps) > 0 {
+		return nil
+	}
+	return s.deleteNetworkPolicy(namespace, policyName(appGUID))
+}
+
+func (s *Syncer) apply(a app, policies []Policy) error {
+	desired, errs := toNetworkPolicy(a.guid, policies, s.ingressCIDRs)
+	for _, err := range errs {
+		s.logger.Info("skipping-policy", lager.Data{"app-guid": a.guid, "reason": err.Error()})
+	}
+
+	networkPolicies := s.client.NetworkingV1().NetworkPolicies(a.namespace)
+	current, err := networkPolicies.Get(desired.Name, meta.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = networkPolicies.Create(desired)
+		return errors.Wrap(err, "failed to create network policy")
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to get network policy")
+	}
+
+	if reflect.DeepEqual(current.Labels, desired.Labels) && reflect.DeepEqual(current.Spec, desired.Spec) {
+		return nil
+	}
+	current.Labels = desired.Labels
+	current.Spec = desired.Spec
+	_, err = networkPolicies.Update(current)
+	return errors.Wrap(err, "failed to update network policy")
+}
+
+// listApps returns the apps which have a StatefulSet or a Deployment which
+// is not being deleted
+func (s *Syncer) listApps(namespace, appGUID string) (map[app]bool, error) {
+	selector := fmt.Sprintf("source_type=%s", k8s.AppSourceType)
+	if appGUID != "" {
+		selector = fmt.Sprintf("%s,guid=%s", selector, appGUID)
+	}
+	options := meta.ListOptions{LabelSelector: selector}
+
+	apps := map[app]bool{}
+	statefulSets, err := s.client.AppsV1().StatefulSets(namespace).List(options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list statefulsets")
+	}
+	for _, statefulSet := range statefulSets.Items {
+		if statefulSet.DeletionTimestamp == nil {
+			apps[app{namespace: statefulSet.Namespace, guid: statefulSet.Labels["guid"]}] = true
+		}
+	}
+
+	deployments, err := s.client.AppsV1().Deployments(namespace).List(options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list deployments")
+	}
+	for _, deployment := range deployments.Items {
+		if deployment.DeletionTimestamp == nil {
+			apps[app{namespace: deployment.Namespace, guid: deployment.Labels["guid"]}] = true
+		}
+	}
+	return apps, nil
+}
+
+func (s *Syncer) deleteStaleNetworkPolicies(apps map[app]bool) error {
+	list, err := s.client.NetworkingV1().NetworkPolicies(s.namespace).List(meta.ListOptions{LabelSelector: PolicyLabel})
+	if err != nil {
+		return errors.Wrap(err, "failed to list network policies")
+	}
+
+	for _, policy := range list.Items {
+		if apps[app{namespace: policy.Namespace, guid: policy.Labels[PolicyLabel]}] {
+			continue
+		}
+		if err = s.deleteNetworkPolicy(policy.Namespace, policy.Name); err != nil {
+			s.logger.Error("failed-to-delete-network-policy", err, lager.Data{"namespace": policy.Namespace, "name": policy.Name})
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) deleteNetworkPolicy(namespace, name string) error {
+	err := s.client.NetworkingV1().NetworkPolicies(namespace).Delete(name, nil)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete network policy")
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package c2c_test
+
+import (
+	"errors"
+
+	. "code.cloudfoundry.org/eirini/c2c"
+	"code.cloudfoundry.org/eirini/c2c/c2cfakes"
+	"code.cloudfoundry.org/eirini/route/routefakes"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Syncer", func() {
+
+	var (
+		client       *fake.Clientset
+		policyClient *c2cfakes.FakePolicyClient
+		scheduler    *routefakes.FakeTaskScheduler
+		syncer       *Syncer
+	)
+
+	createStatefulSet := func(namespace, name, guid string) {
+		_, err := client.AppsV1().StatefulSets(namespace).Create(&appsv1.StatefulSet{
+			ObjectMeta: meta.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"guid": guid, "source_type": "APP"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	getPolicy := func(namespace, name string) (*networkingv1.NetworkPolicy, error) {
+		return client.NetworkingV1().NetworkPolicies(namespace).Get(name, meta.GetOptions{})
+	}
+
+	port := func(protocol corev1.Protocol, number int) networkingv1.NetworkPolicyPort {
+		p := intstr.FromInt(number)
+		return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p}
+	}
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset()
+		policyClient = new(c2cfakes.FakePolicyClient)
+		scheduler = new(routefakes.FakeTaskScheduler)
+		syncer = NewSyncer(client, policyClient, "", []string{"10.0.0.0/24"}, scheduler, lagertest.NewTestLogger("network-policy-syncer"))
+
+		policyClient.PoliciesReturns([]Policy{
+			{
+				Source:      Source{ID: "frontend"},
+				Destination: Destination{ID: "backend", Protocol: "tcp", Ports: Ports{Start: 8080, End: 8081}},
+			},
+			{
+				Source:      Source{ID: "backend"},
+				Destination: Destination{ID: "db", Protocol: "icmp"},
+			},
+		}, nil)
+	})
+
+	It("should schedule the sync", func() {
+		syncer.Start()
+		Expect(scheduler.ScheduleCallCount()).To(Equal(1))
+	})
+
+	Context("When an app is desired", func() {
+
+		BeforeEach(func() {
+			Expect(syncer.AppDesired("space-ns", "backend")).To(Succeed())
+		})
+
+		It("should get the policies of the app", func() {
+			Expect(policyClient.PoliciesCallCount()).To(Equal(1))
+			Expect(policyClient.PoliciesArgsForCall(0)).To(ConsistOf("backend"))
+		})
+
+		It("should select the instances of the app", func() {
+			policy, err := getPolicy("space-ns", "c2c-backend")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Labels).To(Equal(map[string]string{"c2c_policy": "backend"}))
+			Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress))
+			Expect(policy.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{
+				"guid":        "backend",
+				"source_type": "APP",
+			}))
+		})
+
+		It("should let pods which are not apps and the ingress CIDRs connect", func() {
+			policy, err := getPolicy("space-ns", "c2c-backend")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Spec.Ingress[0].Ports).To(BeEmpty())
+			Expect(policy.Spec.Ingress[0].From).To(ConsistOf(
+				networkingv1.NetworkPolicyPeer{
+					NamespaceSelector: &meta.LabelSelector{},
+					PodSelector: &meta.LabelSelector{
+						MatchExpressions: []meta.LabelSelectorRequirement{
+							{Key: "source_type", Operator: meta.LabelSelectorOpNotIn, Values: []string{"APP", "TASK", "STG"}},
+						},
+					},
+				},
+				networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/24"}},
+			))
+		})
+
+		It("should let the sources of its policies connect to their ports", func() {
+			policy, err := getPolicy("space-ns", "c2c-backend")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Spec.Ingress).To(HaveLen(2))
+			Expect(policy.Spec.Ingress[1]).To(Equal(networkingv1.NetworkPolicyIngressRule{
+				From: []networkingv1.NetworkPolicyPeer{
+					{
+						NamespaceSelector: &meta.LabelSelector{},
+						PodSelector: &meta.LabelSelector{
+							MatchLabels: map[string]string{"guid": "frontend"},
+							MatchExpressions: []meta.LabelSelectorRequirement{
+								{Key: "source_type", Operator: meta.LabelSelectorOpIn, Values: []string{"APP", "TASK"}},
+							},
+						},
+					},
+				},
+				Ports: []networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 8080), port(corev1.ProtocolTCP, 8081)},
+			}))
+		})
+
+		Context("and a policy has more ports than can be listed", func() {
+
+			BeforeEach(func() {
+				policyClient.PoliciesReturns([]Policy{
+					{
+						Source:      Source{ID: "frontend"},
+						Destination: Destination{ID: "backend", Protocol: "tcp", Ports: Ports{Start: 1000, End: 3000}},
+					},
+				}, nil)
+				Expect(syncer.AppDesired("space-ns", "backend")).To(Succeed())
+			})
+
+			It("should skip the policy rather than allow every port", func() {
+				policy, err := getPolicy("space-ns", "c2c-backend")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policy.Spec.Ingress).To(HaveLen(1))
+			})
+		})
+
+		Context("and the policies change", func() {
+
+			BeforeEach(func() {
+				policyClient.PoliciesReturns([]Policy{}, nil)
+				Expect(syncer.AppDesired("space-ns", "backend")).To(Succeed())
+			})
+
+			It("should update the network policy", func() {
+				policy, err := getPolicy("space-ns", "c2c-backend")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policy.Spec.Ingress).To(HaveLen(1))
+			})
+		})
+
+		Context("and it is stopped", func() {
+
+			It("should delete the network policy", func() {
+				Expect(syncer.AppStopped("space-ns", "backend")).To(Succeed())
+
+				_, err := getPolicy("space-ns", "c2c-backend")
+				Expect(err).To(HaveOccurred())
+			})
+
+			Context("while another version of it is running", func() {
+
+				BeforeEach(func() {
+					createStatefulSet("space-ns", "backend-v2", "backend")
+				})
+
+				It("should keep the network policy", func() {
+					Expect(syncer.AppStopped("space-ns", "backend")).To(Succeed())
+
+					_, err := getPolicy("space-ns", "c2c-backend")
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+		})
+	})
+
+	Context("When the policy server fails", func() {
+
+		It("should fail to desire the app", func() {
+			policyClient.PoliciesReturns(nil, errors.New("boom"))
+			Expect(syncer.AppDesired("space-ns", "backend")).To(MatchError(ContainSubstring("boom")))
+		})
+	})
+
+	Context("When syncing", func() {
+
+		BeforeEach(func() {
+			createStatefulSet("space-ns", "backend-v1", "backend")
+			createStatefulSet("other-ns", "db-v1", "db")
+			Expect(syncer.AppDesired("space-ns", "stopped")).To(Succeed())
+		})
+
+		JustBeforeEach(func() {
+			Expect(syncer.Sync()).To(Succeed())
+		})
+
+		It("should get the policies of all apps at once", func() {
+			Expect(policyClient.PoliciesCallCount()).To(Equal(2))
+			Expect(policyClient.PoliciesArgsForCall(1)).To(ConsistOf("backend", "db"))
+		})
+
+		It("should apply the network policies of all apps", func() {
+			_, err := getPolicy("space-ns", "c2c-backend")
+			Expect(err).ToNot(HaveOccurred())
+
+			policy, err := getPolicy("other-ns", "c2c-db")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Spec.Ingress).To(HaveLen(1))
+		})
+
+		It("should delete the network policies of apps which no longer exist", func() {
+			_, err := getPolicy("space-ns", "c2c-stopped")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -9,14 +10,20 @@ import (
 
 	"code.cloudfoundry.org/eirini"
 	"code.cloudfoundry.org/eirini/bifrost"
+	"code.cloudfoundry.org/eirini/c2c"
 	cmdcommons "code.cloudfoundry.org/eirini/cmd"
 	"code.cloudfoundry.org/eirini/events"
 	"code.cloudfoundry.org/eirini/handler"
 	"code.cloudfoundry.org/eirini/k8s"
 	k8sevent "code.cloudfoundry.org/eirini/k8s/informers/event"
+	k8sinstance "code.cloudfoundry.org/eirini/k8s/informers/instance"
+	k8srollout "code.cloudfoundry.org/eirini/k8s/informers/rollout"
 	k8sroute "code.cloudfoundry.org/eirini/k8s/informers/route"
+	k8stask "code.cloudfoundry.org/eirini/k8s/informers/task"
 	"code.cloudfoundry.org/eirini/metrics"
+	"code.cloudfoundry.org/eirini/opi"
 	"code.cloudfoundry.org/eirini/route"
+	"code.cloudfoundry.org/eirini/securitygroup"
 	"code.cloudfoundry.org/eirini/stager"
 	"code.cloudfoundry.org/eirini/util"
 	loggregator "code.cloudfoundry.org/go-loggregator"
@@ -26,6 +33,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 
@@ -48,14 +56,22 @@ func connect(cmd *cobra.Command, args []string) {
 	}
 
 	cfg := setConfigFromFile(path)
-	stager := initStager(cfg)
-	bifrost := initBifrost(cfg)
 	clientset := cmdcommons.CreateKubeClient(cfg.Properties.KubeConfigPath)
+	namespacer := initNamespacer(cfg, clientset)
+	watchNamespace := namespacer.WatchNamespace()
+	lrpCache := launchLRPCache(clientset, cfg, watchNamespace)
+
+	securityGroups := launchSecurityGroupSyncer(clientset, cfg, watchNamespace)
+	taskDesirer := initTaskDesirer(cfg, namespacer, securityGroups)
+	stager := initStager(cfg, taskDesirer)
+	networkPolicies := launchNetworkPolicySyncer(clientset, cfg, watchNamespace)
+	bifrost := initBifrost(cfg, lrpCache, namespacer, networkPolicies, securityGroups)
+	taskBifrost := initTaskBifrost(cfg, taskDesirer)
 	metricsClient := cmdcommons.CreateMetricsClient(cfg.Properties.KubeConfigPath)
 
 	launchRouteEmitter(
 		clientset,
-		cfg.Properties.KubeNamespace,
+		watchNamespace,
 		cfg.Properties.NatsPassword,
 		cfg.Properties.NatsIP,
 	)
@@ -81,7 +97,7 @@ func connect(cmd *cobra.Command, args []string) {
 		clientset,
 		metricsClient,
 		loggregatorClient,
-		cfg.Properties.KubeNamespace,
+		watchNamespace,
 	)
 
 	launchEventReporter(
@@ -90,34 +106,125 @@ func connect(cmd *cobra.Command, args []string) {
 		cfg.Properties.CCCAPath,
 		cfg.Properties.CCCertPath,
 		cfg.Properties.CCKeyPath,
-		cfg.Properties.KubeNamespace,
+		watchNamespace,
+	)
+
+	if cfg.Properties.LRPBackend == k8s.LRPBackendDeployment {
+		launchIndexInformer(clientset, watchNamespace)
+	} else if cfg.Properties.UpdatePartition > 0 {
+		launchPartitionInformer(clientset, watchNamespace)
+	}
+
+	launchTaskCompletionInformer(
+		clientset,
+		watchNamespace,
+		taskBifrost,
+		stager,
 	)
 
 	handlerLogger := lager.NewLogger("handler")
 	handlerLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
-	handler := handler.New(bifrost, stager, handlerLogger)
+	handler := handler.WithReadinessGate(
+		handler.New(bifrost, stager, taskBifrost, handlerLogger),
+		lrpCacheSynced(lrpCache),
+		handlerLogger,
+	)
 
 	handlerLogger.Info("opi-connected")
 	handlerLogger.Fatal("opi-crashed", http.ListenAndServe("0.0.0.0:8085", handler))
 }
 
-func initStager(cfg *eirini.Config) eirini.Stager {
+func initNamespacer(cfg *eirini.Config, clientset kubernetes.Interface) *k8s.NamespaceMapper {
+	namespaceLogger := lager.NewLogger("namespace-mapper")
+	namespaceLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	namespacer, err := k8s.NewNamespaceMapper(clientset, cfg.Properties.NamespaceStrategy, cfg.Properties.KubeNamespace, namespaceLogger)
+	cmdcommons.ExitWithError(err)
+	return namespacer
+}
+
+func initStager(cfg *eirini.Config, taskDesirer *k8s.TaskDesirer) eirini.Stager {
+	stagerCfg := eirini.StagerConfig{
+		EiriniAddress:   cfg.Properties.EiriniAddress,
+		DownloaderImage: cfg.Properties.DownloaderImage,
+		UploaderImage:   cfg.Properties.UploaderImage,
+		ExecutorImage:   cfg.Properties.ExecutorImage,
+	}
+
+	httpClient, err := createCCHTTPClient(cfg)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to create stager http client"))
+	}
+
+	return stager.New(taskDesirer, httpClient, stagerCfg)
+}
+
+func initTaskBifrost(cfg *eirini.Config, taskDesirer *k8s.TaskDesirer) eirini.TaskBifrost {
+	taskLogger := lager.NewLogger("task-bifrost")
+	taskLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	convertLogger := lager.NewLogger("convert")
+	convertLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	converter := bifrost.NewConverter(convertLogger, cfg.Properties.RegistryAddress)
+
+	httpClient, err := createCCHTTPClient(cfg)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to create task bifrost http client"))
+	}
+
+	return &bifrost.Task{
+		Converter:   converter,
+		TaskDesirer: taskDesirer,
+		HTTPClient:  httpClient,
+		Logger:      taskLogger,
+	}
+}
+
+func initTaskDesirer(cfg *eirini.Config, namespacer k8s.Namespacer, securityGroups k8s.SecurityGroupSyncer) *k8s.TaskDesirer {
 	clientset := cmdcommons.CreateKubeClient(cfg.Properties.KubeConfigPath)
-	taskDesirer := &k8s.TaskDesirer{
+	return &k8s.TaskDesirer{
 		Namespace:       cfg.Properties.KubeNamespace,
 		CCUploaderIP:    cfg.Properties.CcUploaderIP,
 		CertsSecretName: cfg.Properties.CCCertsSecretName,
 		Client:          clientset,
+		Namespacer:      namespacer,
+		Security:        securityPolicy(cfg),
+		CPU:             cpuPolicy(cfg),
+		SecurityGroups:  securityGroups,
 	}
+}
 
-	stagerCfg := eirini.StagerConfig{
-		EiriniAddress:   cfg.Properties.EiriniAddress,
-		DownloaderImage: cfg.Properties.DownloaderImage,
-		UploaderImage:   cfg.Properties.UploaderImage,
-		ExecutorImage:   cfg.Properties.ExecutorImage,
+func securityPolicy(cfg *eirini.Config) k8s.SecurityPolicy {
+	securityContext := cfg.Properties.SecurityContext
+	return k8s.SecurityPolicy{
+		Unconfined:             securityContext.Unconfined,
+		RunAsUser:              securityContext.RunAsUser,
+		RunAsGroup:             securityContext.RunAsGroup,
+		ReadOnlyRootFilesystem: securityContext.ReadOnlyRootFilesystem,
 	}
+}
 
-	httpClient, err := util.CreateTLSHTTPClient(
+func cpuPolicy(cfg *eirini.Config) k8s.CPUPolicy {
+	cpu := cfg.Properties.CPU
+	policy, err := k8s.NewCPUPolicy(cpu.Policy, cpu.MillicoresPerGB, cpu.OvercommitRatio)
+	cmdcommons.ExitWithError(err)
+	return policy
+}
+
+func memoryPolicy(cfg *eirini.Config) k8s.MemoryPolicy {
+	memory := cfg.Properties.Memory
+	policy, err := k8s.NewMemoryPolicy(memory.RequestRatio, memory.MinRequestMB, memory.OrgRequestRatios)
+	cmdcommons.ExitWithError(err)
+	return policy
+}
+
+func shutdownPolicy(cfg *eirini.Config) k8s.ShutdownPolicy {
+	shutdown := cfg.Properties.GracefulShutdown
+	policy, err := k8s.NewShutdownPolicy(shutdown.GracePeriodSeconds, shutdown.DrainSeconds)
+	cmdcommons.ExitWithError(err)
+	return policy
+}
+
+func createCCHTTPClient(cfg *eirini.Config) (*http.Client, error) {
+	return util.CreateTLSHTTPClient(
 		[]util.CertPaths{
 			{
 				Crt: cfg.Properties.CCCertPath,
@@ -126,21 +233,15 @@ func initStager(cfg *eirini.Config) eirini.Stager {
 			},
 		},
 	)
-	if err != nil {
-		panic(errors.Wrap(err, "failed to create stager http client"))
-	}
-
-	return stager.New(taskDesirer, httpClient, stagerCfg)
 }
 
-func initBifrost(cfg *eirini.Config) eirini.Bifrost {
+func initBifrost(cfg *eirini.Config, lrpCache *k8s.LRPCache, namespacer k8s.Namespacer, networkPolicies k8s.NetworkPolicySyncer, securityGroups k8s.SecurityGroupSyncer) eirini.Bifrost {
 	syncLogger := lager.NewLogger("bifrost")
 	syncLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
-	kubeNamespace := cfg.Properties.KubeNamespace
 	clientset := cmdcommons.CreateKubeClient(cfg.Properties.KubeConfigPath)
 	desireLogger := lager.NewLogger("desirer")
 	desireLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
-	desirer := k8s.NewStatefulSetDesirer(clientset, kubeNamespace, cfg.Properties.RootfsVersion, desireLogger)
+	desirer := initLRPDesirer(cfg, clientset, lrpCache, namespacer, networkPolicies, securityGroups, desireLogger)
 	convertLogger := lager.NewLogger("convert")
 	convertLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
 	registryIP := cfg.Properties.RegistryAddress
@@ -153,6 +254,43 @@ func initBifrost(cfg *eirini.Config) eirini.Bifrost {
 	}
 }
 
+func initLRPDesirer(cfg *eirini.Config, clientset kubernetes.Interface, lrpCache *k8s.LRPCache, namespacer k8s.Namespacer, networkPolicies k8s.NetworkPolicySyncer, securityGroups k8s.SecurityGroupSyncer, logger lager.Logger) opi.Desirer {
+	placement, err := k8s.NewPlacementPolicy(cfg.Properties.PodAntiAffinity, cfg.Properties.SpreadZones, cfg.Properties.IsolationSegments)
+	cmdcommons.ExitWithError(err)
+
+	options := k8s.LRPDesirerOptions{
+		ConflictPolicy:  cfg.Properties.DesireConflictPolicy,
+		Placement:       placement,
+		Security:        securityPolicy(cfg),
+		CPU:             cpuPolicy(cfg),
+		Memory:          memoryPolicy(cfg),
+		Shutdown:        shutdownPolicy(cfg),
+		Namespacer:      namespacer,
+		UpdatePartition: cfg.Properties.UpdatePartition,
+		MaxUnavailable:  maxUnavailable(cfg),
+		Cache:           lrpCache,
+		NetworkPolicies: networkPolicies,
+		SecurityGroups:  securityGroups,
+	}
+
+	switch cfg.Properties.LRPBackend {
+	case k8s.LRPBackendDeployment:
+		return k8s.NewDeploymentDesirer(clientset, cfg.Properties.KubeNamespace, cfg.Properties.RootfsVersion, options, logger)
+	case "", k8s.LRPBackendStatefulSet:
+		return k8s.NewStatefulSetDesirer(clientset, cfg.Properties.KubeNamespace, cfg.Properties.RootfsVersion, options, logger)
+	default:
+		cmdcommons.ExitWithError(fmt.Errorf("unsupported lrp_backend %q", cfg.Properties.LRPBackend))
+		return nil
+	}
+}
+
+func maxUnavailable(cfg *eirini.Config) intstr.IntOrString {
+	if cfg.Properties.MaxUnavailable == "" {
+		return intstr.IntOrString{}
+	}
+	return intstr.Parse(cfg.Properties.MaxUnavailable)
+}
+
 func setConfigFromFile(path string) *eirini.Config {
 	fileBytes, err := ioutil.ReadFile(filepath.Clean(path))
 	cmdcommons.ExitWithError(err)
@@ -168,6 +306,24 @@ func initConnect() {
 	connectCmd.Flags().StringP("config", "c", "", "Path to the Eirini config file")
 }
 
+// launchLRPCache starts the cache which serves the reads of the StatefulSet
+// backend. The Deployment backend reads from the API and gets no cache.
+func launchLRPCache(clientset kubernetes.Interface, cfg *eirini.Config, namespace string) *k8s.LRPCache {
+	if cfg.Properties.LRPBackend != "" && cfg.Properties.LRPBackend != k8s.LRPBackendStatefulSet {
+		return nil
+	}
+	lrpCache := k8s.NewLRPCache(clientset, namespace, 10*time.Second)
+	lrpCache.Run(make(chan struct{}))
+	return lrpCache
+}
+
+func lrpCacheSynced(lrpCache *k8s.LRPCache) func() bool {
+	if lrpCache == nil {
+		return func() bool { return true }
+	}
+	return lrpCache.HasSynced
+}
+
 func launchRouteEmitter(clientset kubernetes.Interface, namespace, natsPassword, natsIP string) {
 	nc, err := nats.Connect(util.GenerateNatsURL(natsPassword, natsIP))
 	cmdcommons.ExitWithError(err)
@@ -187,12 +343,10 @@ func launchRouteEmitter(clientset kubernetes.Interface, namespace, natsPassword,
 
 func launchMetricsEmitter(clientset kubernetes.Interface, metricsClient metricsclientset.Interface, loggregatorClient *loggregator.IngressClient, namespace string) {
 	work := make(chan []metrics.Message, 20)
-	podClient := clientset.CoreV1().Pods(namespace)
-
 	podMetricsClient := metricsClient.MetricsV1beta1().PodMetricses(namespace)
 	metricsLogger := lager.NewLogger("metrics-collector")
 	metricsLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
-	collector := k8s.NewMetricsCollector(work, &route.SimpleLoopScheduler{}, podMetricsClient, podClient, metricsLogger)
+	collector := k8s.NewMetricsCollector(work, &route.SimpleLoopScheduler{}, podMetricsClient, clientset.CoreV1(), metricsLogger)
 
 	forwarder := metrics.NewLoggregatorForwarder(loggregatorClient)
 	emitter := metrics.NewEmitter(work, &route.SimpleLoopScheduler{}, forwarder)
@@ -217,3 +371,88 @@ func launchEventReporter(clientset kubernetes.Interface, uri, ca, cert, key, nam
 	go crashInformer.Start()
 	go reporter.Run()
 }
+
+// launchSecurityGroupSyncer returns nil when security groups are not
+// enforced
+func launchSecurityGroupSyncer(clientset kubernetes.Interface, cfg *eirini.Config, namespace string) k8s.SecurityGroupSyncer {
+	if !cfg.Properties.SecurityGroups.Enabled {
+		return nil
+	}
+
+	httpClient, err := createCCHTTPClient(cfg)
+	cmdcommons.ExitWithError(err)
+
+	ccAPI := cfg.Properties.SecurityGroups.CCAPI
+	if ccAPI == "" {
+		ccAPI = cfg.Properties.CcInternalAPI
+	}
+	interval := time.Duration(cfg.Properties.SecurityGroups.SyncIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	syncerLogger := lager.NewLogger("security-group-syncer")
+	syncerLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	scheduler := &route.TickerTaskScheduler{Ticker: time.NewTicker(interval)}
+	syncer := securitygroup.NewSyncer(clientset, securitygroup.NewCcClient(ccAPI, httpClient), namespace, scheduler, syncerLogger)
+
+	go syncer.Start()
+	return syncer
+}
+
+// launchNetworkPolicySyncer returns nil when network policies are disabled
+func launchNetworkPolicySyncer(clientset kubernetes.Interface, cfg *eirini.Config, namespace string) k8s.NetworkPolicySyncer {
+	policyCfg := cfg.Properties.NetworkPolicies
+	if !policyCfg.Enabled {
+		return nil
+	}
+
+	var policyClient c2c.PolicyClient = &c2c.FileClient{Path: policyCfg.PolicyFile}
+	if policyCfg.PolicyFile == "" {
+		httpClient, err := util.CreateTLSHTTPClient([]util.CertPaths{
+			{Crt: policyCfg.CertPath, Key: policyCfg.KeyPath, Ca: policyCfg.CAPath},
+		})
+		cmdcommons.ExitWithError(err)
+		policyClient = c2c.NewPolicyServerClient(policyCfg.PolicyServerAPI, httpClient)
+	}
+
+	interval := time.Duration(policyCfg.SyncIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	syncerLogger := lager.NewLogger("network-policy-syncer")
+	syncerLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	scheduler := &route.TickerTaskScheduler{Ticker: time.NewTicker(interval)}
+	syncer := c2c.NewSyncer(clientset, policyClient, namespace, policyCfg.IngressCIDRs, scheduler, syncerLogger)
+
+	go syncer.Start()
+	return syncer
+}
+
+func launchTaskCompletionInformer(clientset kubernetes.Interface, namespace string, taskBifrost eirini.TaskBifrost, stager eirini.Stager) {
+	completionLogger := lager.NewLogger("task-completion-informer")
+	completionLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	syncPeriod := 10 * time.Second
+	completionInformer := k8stask.NewCompletionInformer(clientset, syncPeriod, namespace, taskBifrost, stager, make(chan struct{}), completionLogger)
+
+	go completionInformer.Start()
+}
+
+func launchPartitionInformer(clientset kubernetes.Interface, namespace string) {
+	partitionLogger := lager.NewLogger("update-partition-informer")
+	partitionLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	syncPeriod := 10 * time.Second
+	partitionInformer := k8srollout.NewPartitionInformer(clientset, syncPeriod, namespace, make(chan struct{}), partitionLogger)
+
+	go partitionInformer.Start()
+}
+
+func launchIndexInformer(clientset kubernetes.Interface, namespace string) {
+	indexLogger := lager.NewLogger("instance-index-informer")
+	indexLogger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	syncPeriod := 10 * time.Second
+	indexInformer := k8sinstance.NewIndexInformer(clientset, syncPeriod, namespace, make(chan struct{}), indexLogger)
+
+	go indexInformer.Start()
+}
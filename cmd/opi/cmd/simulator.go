@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net/http"
@@ -35,7 +36,8 @@ func simulate(cmd *cobra.Command, args []string) {
 	}
 
 	stager := &StagerSimulator{}
-	handler := handler.New(bifrost, stager, handlerLogger)
+	taskBifrost := &TaskBifrostSimulator{}
+	handler := handler.New(bifrost, stager, taskBifrost, handlerLogger)
 
 	log.Fatal(http.ListenAndServe("127.0.0.1:8085", handler))
 }
@@ -87,12 +89,42 @@ func (c *ConverterSimulator) Convert(request cf.DesireLRPRequest) (opi.LRP, erro
 	return opi.LRP{}, nil
 }
 
+func (c *ConverterSimulator) ImageURI(dropletGUID, dropletHash string) string {
+	return ""
+}
+
 type StagerSimulator struct{}
 
 func (s *StagerSimulator) Stage(stagingGUID string, request cf.StagingRequest) error {
 	return nil
 }
 
+func (s *StagerSimulator) GetStagingStatus(stagingGUID string) (*cf.StagingStatus, error) {
+	return &cf.StagingStatus{StagingGUID: stagingGUID, State: opi.TaskRunningState}, nil
+}
+
+func (s *StagerSimulator) CancelStaging(stagingGUID string) error {
+	return nil
+}
+
 func (s *StagerSimulator) CompleteStaging(task *models.TaskCallbackResponse) error {
 	return nil
 }
+
+type TaskBifrostSimulator struct{}
+
+func (t *TaskBifrostSimulator) TransferTask(ctx context.Context, taskGUID string, request cf.TaskRequest) error {
+	return nil
+}
+
+func (t *TaskBifrostSimulator) GetTask(ctx context.Context, taskGUID string) (*cf.TaskResponse, error) {
+	return &cf.TaskResponse{TaskGUID: taskGUID, State: opi.TaskRunningState}, nil
+}
+
+func (t *TaskBifrostSimulator) CancelTask(ctx context.Context, taskGUID string) error {
+	return nil
+}
+
+func (t *TaskBifrostSimulator) CompleteTask(ctx context.Context, taskGUID string) error {
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"code.cloudfoundry.org/eirini/cmd"
+	"code.cloudfoundry.org/eirini/k8s"
 	"code.cloudfoundry.org/eirini/rootfspatcher"
 	"code.cloudfoundry.org/lager"
 )
@@ -14,6 +15,10 @@ func main() {
 	namespace := flag.String("namespace", "", "Namespace where eirini runs apps")
 	timeout := flag.Duration("timeout", -1, "Timeout for waiting for rootfs patching to be finished")
 	kubeConfigPath := flag.String("kubeconfig", "", "Config for kubernetes, leave empty to use in cluster config")
+	hardenSecurityContext := flag.Bool("harden-security-context", false, "Apply the hardened security context to existing apps")
+	readOnlyRootFilesystem := flag.Bool("read-only-root-filesystem", false, "Make the root filesystem of hardened apps read-only")
+	runAsUser := flag.Int64("run-as-user", 0, "User id of hardened apps, leave empty for vcap. Must match run_as_user of the opi config")
+	runAsGroup := flag.Int64("run-as-group", 0, "Group id of hardened apps, leave empty for vcap. Must match run_as_group of the opi config")
 
 	flag.Parse()
 
@@ -33,6 +38,13 @@ func main() {
 		StatefulSets: statefulSetClient,
 		Logger:       logger,
 	}
+	if *hardenSecurityContext {
+		patcher.PatchTemplate = k8s.SecurityPolicy{
+			RunAsUser:              *runAsUser,
+			RunAsGroup:             *runAsGroup,
+			ReadOnlyRootFilesystem: *readOnlyRootFilesystem,
+		}.Apply
+	}
 
 	logger = lager.NewLogger("Pod Waiter")
 	logger.RegisterSink(lager.NewWriterSink(os.Stderr, lager.DEBUG))
@@ -12,7 +12,7 @@ import (
 )
 
 type FakeBifrost struct {
-	GetAppStub        func(context.Context, opi.LRPIdentifier) *models.DesiredLRP
+	GetAppStub        func(context.Context, opi.LRPIdentifier) (*models.DesiredLRP, error)
 	getAppMutex       sync.RWMutex
 	getAppArgsForCall []struct {
 		arg1 context.Context
@@ -20,9 +20,11 @@ type FakeBifrost struct {
 	}
 	getAppReturns struct {
 		result1 *models.DesiredLRP
+		result2 error
 	}
 	getAppReturnsOnCall map[int]struct {
 		result1 *models.DesiredLRP
+		result2 error
 	}
 	GetInstancesStub        func(context.Context, opi.LRPIdentifier) ([]*cf.Instance, error)
 	getInstancesMutex       sync.RWMutex
@@ -104,23 +106,24 @@ type FakeBifrost struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeBifrost) GetApp(arg1 context.Context, arg2 opi.LRPIdentifier) *models.DesiredLRP {
+func (fake *FakeBifrost) GetApp(arg1 context.Context, arg2 opi.LRPIdentifier) (*models.DesiredLRP, error) {
 	fake.getAppMutex.Lock()
 	ret, specificReturn := fake.getAppReturnsOnCall[len(fake.getAppArgsForCall)]
 	fake.getAppArgsForCall = append(fake.getAppArgsForCall, struct {
 		arg1 context.Context
 		arg2 opi.LRPIdentifier
 	}{arg1, arg2})
+	stub := fake.GetAppStub
+	fakeReturns := fake.getAppReturns
 	fake.recordInvocation("GetApp", []interface{}{arg1, arg2})
 	fake.getAppMutex.Unlock()
-	if fake.GetAppStub != nil {
-		return fake.GetAppStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getAppReturns
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeBifrost) GetAppCallCount() int {
@@ -129,7 +132,7 @@ func (fake *FakeBifrost) GetAppCallCount() int {
 	return len(fake.getAppArgsForCall)
 }
 
-func (fake *FakeBifrost) GetAppCalls(stub func(context.Context, opi.LRPIdentifier) *models.DesiredLRP) {
+func (fake *FakeBifrost) GetAppCalls(stub func(context.Context, opi.LRPIdentifier) (*models.DesiredLRP, error)) {
 	fake.getAppMutex.Lock()
 	defer fake.getAppMutex.Unlock()
 	fake.GetAppStub = stub
@@ -142,27 +145,30 @@ func (fake *FakeBifrost) GetAppArgsForCall(i int) (context.Context, opi.LRPIdent
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeBifrost) GetAppReturns(result1 *models.DesiredLRP) {
+func (fake *FakeBifrost) GetAppReturns(result1 *models.DesiredLRP, result2 error) {
 	fake.getAppMutex.Lock()
 	defer fake.getAppMutex.Unlock()
 	fake.GetAppStub = nil
 	fake.getAppReturns = struct {
 		result1 *models.DesiredLRP
-	}{result1}
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeBifrost) GetAppReturnsOnCall(i int, result1 *models.DesiredLRP) {
+func (fake *FakeBifrost) GetAppReturnsOnCall(i int, result1 *models.DesiredLRP, result2 error) {
 	fake.getAppMutex.Lock()
 	defer fake.getAppMutex.Unlock()
 	fake.GetAppStub = nil
 	if fake.getAppReturnsOnCall == nil {
 		fake.getAppReturnsOnCall = make(map[int]struct {
 			result1 *models.DesiredLRP
+			result2 error
 		})
 	}
 	fake.getAppReturnsOnCall[i] = struct {
 		result1 *models.DesiredLRP
-	}{result1}
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *FakeBifrost) GetInstances(arg1 context.Context, arg2 opi.LRPIdentifier) ([]*cf.Instance, error) {
@@ -172,15 +178,16 @@ func (fake *FakeBifrost) GetInstances(arg1 context.Context, arg2 opi.LRPIdentifi
 		arg1 context.Context
 		arg2 opi.LRPIdentifier
 	}{arg1, arg2})
+	stub := fake.GetInstancesStub
+	fakeReturns := fake.getInstancesReturns
 	fake.recordInvocation("GetInstances", []interface{}{arg1, arg2})
 	fake.getInstancesMutex.Unlock()
-	if fake.GetInstancesStub != nil {
-		return fake.GetInstancesStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getInstancesReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -235,15 +242,16 @@ func (fake *FakeBifrost) List(arg1 context.Context) ([]*models.DesiredLRPSchedul
 	fake.listArgsForCall = append(fake.listArgsForCall, struct {
 		arg1 context.Context
 	}{arg1})
+	stub := fake.ListStub
+	fakeReturns := fake.listReturns
 	fake.recordInvocation("List", []interface{}{arg1})
 	fake.listMutex.Unlock()
-	if fake.ListStub != nil {
-		return fake.ListStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.listReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -299,15 +307,16 @@ func (fake *FakeBifrost) Stop(arg1 context.Context, arg2 opi.LRPIdentifier) erro
 		arg1 context.Context
 		arg2 opi.LRPIdentifier
 	}{arg1, arg2})
+	stub := fake.StopStub
+	fakeReturns := fake.stopReturns
 	fake.recordInvocation("Stop", []interface{}{arg1, arg2})
 	fake.stopMutex.Unlock()
-	if fake.StopStub != nil {
-		return fake.StopStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.stopReturns
 	return fakeReturns.result1
 }
 
@@ -361,15 +370,16 @@ func (fake *FakeBifrost) StopInstance(arg1 context.Context, arg2 opi.LRPIdentifi
 		arg2 opi.LRPIdentifier
 		arg3 uint
 	}{arg1, arg2, arg3})
+	stub := fake.StopInstanceStub
+	fakeReturns := fake.stopInstanceReturns
 	fake.recordInvocation("StopInstance", []interface{}{arg1, arg2, arg3})
 	fake.stopInstanceMutex.Unlock()
-	if fake.StopInstanceStub != nil {
-		return fake.StopInstanceStub(arg1, arg2, arg3)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.stopInstanceReturns
 	return fakeReturns.result1
 }
 
@@ -422,15 +432,16 @@ func (fake *FakeBifrost) Transfer(arg1 context.Context, arg2 cf.DesireLRPRequest
 		arg1 context.Context
 		arg2 cf.DesireLRPRequest
 	}{arg1, arg2})
+	stub := fake.TransferStub
+	fakeReturns := fake.transferReturns
 	fake.recordInvocation("Transfer", []interface{}{arg1, arg2})
 	fake.transferMutex.Unlock()
-	if fake.TransferStub != nil {
-		return fake.TransferStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.transferReturns
 	return fakeReturns.result1
 }
 
@@ -483,15 +494,16 @@ func (fake *FakeBifrost) Update(arg1 context.Context, arg2 cf.UpdateDesiredLRPRe
 		arg1 context.Context
 		arg2 cf.UpdateDesiredLRPRequest
 	}{arg1, arg2})
+	stub := fake.UpdateStub
+	fakeReturns := fake.updateReturns
 	fake.recordInvocation("Update", []interface{}{arg1, arg2})
 	fake.updateMutex.Unlock()
-	if fake.UpdateStub != nil {
-		return fake.UpdateStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.updateReturns
 	return fakeReturns.result1
 }
 
@@ -10,6 +10,17 @@ import (
 )
 
 type FakeStager struct {
+	CancelStagingStub        func(string) error
+	cancelStagingMutex       sync.RWMutex
+	cancelStagingArgsForCall []struct {
+		arg1 string
+	}
+	cancelStagingReturns struct {
+		result1 error
+	}
+	cancelStagingReturnsOnCall map[int]struct {
+		result1 error
+	}
 	CompleteStagingStub        func(*models.TaskCallbackResponse) error
 	completeStagingMutex       sync.RWMutex
 	completeStagingArgsForCall []struct {
@@ -21,6 +32,19 @@ type FakeStager struct {
 	completeStagingReturnsOnCall map[int]struct {
 		result1 error
 	}
+	GetStagingStatusStub        func(string) (*cf.StagingStatus, error)
+	getStagingStatusMutex       sync.RWMutex
+	getStagingStatusArgsForCall []struct {
+		arg1 string
+	}
+	getStagingStatusReturns struct {
+		result1 *cf.StagingStatus
+		result2 error
+	}
+	getStagingStatusReturnsOnCall map[int]struct {
+		result1 *cf.StagingStatus
+		result2 error
+	}
 	StageStub        func(string, cf.StagingRequest) error
 	stageMutex       sync.RWMutex
 	stageArgsForCall []struct {
@@ -37,21 +61,83 @@ type FakeStager struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeStager) CancelStaging(arg1 string) error {
+	fake.cancelStagingMutex.Lock()
+	ret, specificReturn := fake.cancelStagingReturnsOnCall[len(fake.cancelStagingArgsForCall)]
+	fake.cancelStagingArgsForCall = append(fake.cancelStagingArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CancelStagingStub
+	fakeReturns := fake.cancelStagingReturns
+	fake.recordInvocation("CancelStaging", []interface{}{arg1})
+	fake.cancelStagingMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStager) CancelStagingCallCount() int {
+	fake.cancelStagingMutex.RLock()
+	defer fake.cancelStagingMutex.RUnlock()
+	return len(fake.cancelStagingArgsForCall)
+}
+
+func (fake *FakeStager) CancelStagingCalls(stub func(string) error) {
+	fake.cancelStagingMutex.Lock()
+	defer fake.cancelStagingMutex.Unlock()
+	fake.CancelStagingStub = stub
+}
+
+func (fake *FakeStager) CancelStagingArgsForCall(i int) string {
+	fake.cancelStagingMutex.RLock()
+	defer fake.cancelStagingMutex.RUnlock()
+	argsForCall := fake.cancelStagingArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStager) CancelStagingReturns(result1 error) {
+	fake.cancelStagingMutex.Lock()
+	defer fake.cancelStagingMutex.Unlock()
+	fake.CancelStagingStub = nil
+	fake.cancelStagingReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStager) CancelStagingReturnsOnCall(i int, result1 error) {
+	fake.cancelStagingMutex.Lock()
+	defer fake.cancelStagingMutex.Unlock()
+	fake.CancelStagingStub = nil
+	if fake.cancelStagingReturnsOnCall == nil {
+		fake.cancelStagingReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.cancelStagingReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeStager) CompleteStaging(arg1 *models.TaskCallbackResponse) error {
 	fake.completeStagingMutex.Lock()
 	ret, specificReturn := fake.completeStagingReturnsOnCall[len(fake.completeStagingArgsForCall)]
 	fake.completeStagingArgsForCall = append(fake.completeStagingArgsForCall, struct {
 		arg1 *models.TaskCallbackResponse
 	}{arg1})
+	stub := fake.CompleteStagingStub
+	fakeReturns := fake.completeStagingReturns
 	fake.recordInvocation("CompleteStaging", []interface{}{arg1})
 	fake.completeStagingMutex.Unlock()
-	if fake.CompleteStagingStub != nil {
-		return fake.CompleteStagingStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.completeStagingReturns
 	return fakeReturns.result1
 }
 
@@ -97,6 +183,70 @@ func (fake *FakeStager) CompleteStagingReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeStager) GetStagingStatus(arg1 string) (*cf.StagingStatus, error) {
+	fake.getStagingStatusMutex.Lock()
+	ret, specificReturn := fake.getStagingStatusReturnsOnCall[len(fake.getStagingStatusArgsForCall)]
+	fake.getStagingStatusArgsForCall = append(fake.getStagingStatusArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetStagingStatusStub
+	fakeReturns := fake.getStagingStatusReturns
+	fake.recordInvocation("GetStagingStatus", []interface{}{arg1})
+	fake.getStagingStatusMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStager) GetStagingStatusCallCount() int {
+	fake.getStagingStatusMutex.RLock()
+	defer fake.getStagingStatusMutex.RUnlock()
+	return len(fake.getStagingStatusArgsForCall)
+}
+
+func (fake *FakeStager) GetStagingStatusCalls(stub func(string) (*cf.StagingStatus, error)) {
+	fake.getStagingStatusMutex.Lock()
+	defer fake.getStagingStatusMutex.Unlock()
+	fake.GetStagingStatusStub = stub
+}
+
+func (fake *FakeStager) GetStagingStatusArgsForCall(i int) string {
+	fake.getStagingStatusMutex.RLock()
+	defer fake.getStagingStatusMutex.RUnlock()
+	argsForCall := fake.getStagingStatusArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStager) GetStagingStatusReturns(result1 *cf.StagingStatus, result2 error) {
+	fake.getStagingStatusMutex.Lock()
+	defer fake.getStagingStatusMutex.Unlock()
+	fake.GetStagingStatusStub = nil
+	fake.getStagingStatusReturns = struct {
+		result1 *cf.StagingStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStager) GetStagingStatusReturnsOnCall(i int, result1 *cf.StagingStatus, result2 error) {
+	fake.getStagingStatusMutex.Lock()
+	defer fake.getStagingStatusMutex.Unlock()
+	fake.GetStagingStatusStub = nil
+	if fake.getStagingStatusReturnsOnCall == nil {
+		fake.getStagingStatusReturnsOnCall = make(map[int]struct {
+			result1 *cf.StagingStatus
+			result2 error
+		})
+	}
+	fake.getStagingStatusReturnsOnCall[i] = struct {
+		result1 *cf.StagingStatus
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeStager) Stage(arg1 string, arg2 cf.StagingRequest) error {
 	fake.stageMutex.Lock()
 	ret, specificReturn := fake.stageReturnsOnCall[len(fake.stageArgsForCall)]
@@ -104,15 +254,16 @@ func (fake *FakeStager) Stage(arg1 string, arg2 cf.StagingRequest) error {
 		arg1 string
 		arg2 cf.StagingRequest
 	}{arg1, arg2})
+	stub := fake.StageStub
+	fakeReturns := fake.stageReturns
 	fake.recordInvocation("Stage", []interface{}{arg1, arg2})
 	fake.stageMutex.Unlock()
-	if fake.StageStub != nil {
-		return fake.StageStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.stageReturns
 	return fakeReturns.result1
 }
 
@@ -161,8 +312,12 @@ func (fake *FakeStager) StageReturnsOnCall(i int, result1 error) {
 func (fake *FakeStager) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.cancelStagingMutex.RLock()
+	defer fake.cancelStagingMutex.RUnlock()
 	fake.completeStagingMutex.RLock()
 	defer fake.completeStagingMutex.RUnlock()
+	fake.getStagingStatusMutex.RLock()
+	defer fake.getStagingStatusMutex.RUnlock()
 	fake.stageMutex.RLock()
 	defer fake.stageMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
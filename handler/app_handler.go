@@ -29,13 +29,13 @@ func (a *App) Desire(w http.ResponseWriter, r *http.Request, _ httprouter.Params
 	buf := new(bytes.Buffer)
 	if _, err := buf.ReadFrom(r.Body); err != nil {
 		a.logError("request-body-cannot-be-read", err)
-		w.WriteHeader(http.StatusBadRequest)
+		a.writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(buf.Bytes(), &request); err != nil {
 		a.logError("request-body-decoding-failed", err)
-		w.WriteHeader(http.StatusBadRequest)
+		a.writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -43,7 +43,7 @@ func (a *App) Desire(w http.ResponseWriter, r *http.Request, _ httprouter.Params
 
 	if err := a.bifrost.Transfer(r.Context(), request); err != nil {
 		a.logError("desire-app-failed", err)
-		w.WriteHeader(http.StatusBadRequest)
+		a.writeErrorResponse(w, err, statusCodeForError(err))
 		return
 	}
 
@@ -54,7 +54,7 @@ func (a *App) List(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	desiredLRPSchedulingInfos, err := a.bifrost.List(r.Context())
 	if err != nil {
 		a.logError("list-apps-failed", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		a.writeErrorResponse(w, err, statusCodeForError(err))
 		return
 	}
 
@@ -81,9 +81,10 @@ func (a *App) GetApp(w http.ResponseWriter, r *http.Request, ps httprouter.Param
 		GUID:    ps.ByName("process_guid"),
 		Version: ps.ByName("version_guid"),
 	}
-	desiredLRP := a.bifrost.GetApp(r.Context(), identifier)
-	if desiredLRP == nil {
-		w.WriteHeader(http.StatusNotFound)
+	desiredLRP, err := a.bifrost.GetApp(r.Context(), identifier)
+	if err != nil {
+		a.logError("get-app-failed", err)
+		a.writeErrorResponse(w, err, statusCodeForError(err))
 		return
 	}
 	response := models.DesiredLRPResponse{
@@ -107,17 +108,13 @@ func (a *App) Update(w http.ResponseWriter, r *http.Request, ps httprouter.Param
 	var request cf.UpdateDesiredLRPRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		a.logError("json-decoding-failure", err)
-		err = writeUpdateErrorResponse(w, err, http.StatusBadRequest)
-
-		a.logError("Could not write response", err)
-
+		a.writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := a.bifrost.Update(r.Context(), request); err != nil {
 		a.logError("update-app-failed", err)
-		err = writeUpdateErrorResponse(w, err, http.StatusInternalServerError)
-		a.logError("Could not write response", err)
+		a.writeErrorResponse(w, err, statusCodeForError(err))
 	}
 }
 
@@ -129,7 +126,7 @@ func (a *App) Stop(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 	err := a.bifrost.Stop(r.Context(), identifier)
 	if err != nil {
 		a.logError("stop-app-failed", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		a.writeErrorResponse(w, err, statusCodeForError(err))
 	}
 }
 
@@ -142,13 +139,14 @@ func (a *App) StopInstance(w http.ResponseWriter, r *http.Request, ps httprouter
 	index, err := strconv.ParseUint(ps.ByName("instance"), 10, 32)
 	if err != nil {
 		a.logError("stop-app-instance-failed", err)
-		w.WriteHeader(http.StatusBadRequest)
+		a.writeErrorResponse(w, err, http.StatusBadRequest)
+		return
 	}
 
 	err = a.bifrost.StopInstance(r.Context(), identifier, uint(index))
 	if err != nil {
 		a.logError("stop-app-instance-failed", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		a.writeErrorResponse(w, err, statusCodeForError(err))
 	}
 }
 
@@ -161,6 +159,10 @@ func (a *App) GetInstances(w http.ResponseWriter, r *http.Request, ps httprouter
 	a.logError("get-instances-failed", err)
 	response := a.createGetInstancesResponse(identifier.ProcessGUID(), instances, err)
 
+	if err != nil {
+		w.WriteHeader(statusCodeForError(err))
+	}
+
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
 		a.logError("get-instances-failed", err)
@@ -188,22 +190,9 @@ func createErrorGetInstancesResponse(guid string, err error) cf.GetInstancesResp
 	}
 }
 
-func writeUpdateErrorResponse(w http.ResponseWriter, err error, statusCode int) error {
-	w.WriteHeader(statusCode)
-
-	response := models.DesiredLRPLifecycleResponse{
-		Error: &models.Error{
-			Message: err.Error(),
-		},
-	}
-
-	body, marshalError := json.Marshal(response)
-	if marshalError != nil {
-		panic(marshalError)
-	}
-
-	_, err = w.Write(body)
-	return err
+func (a *App) writeErrorResponse(w http.ResponseWriter, err error, statusCode int) {
+	err = writeErrorResponse(w, err, statusCode)
+	a.logError("Could not write response", err)
 }
 
 func (a *App) logError(msg string, err error) {
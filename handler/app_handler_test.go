@@ -13,6 +13,7 @@ import (
 	"code.cloudfoundry.org/eirini/eirinifakes"
 	. "code.cloudfoundry.org/eirini/handler"
 	"code.cloudfoundry.org/eirini/models/cf"
+	"code.cloudfoundry.org/eirini/opi"
 	"code.cloudfoundry.org/lager/lagertest"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/julienschmidt/httprouter"
@@ -22,14 +23,16 @@ import (
 
 var _ = Describe("AppHandler", func() {
 	var (
-		bifrost *eirinifakes.FakeBifrost
-		stager  *eirinifakes.FakeStager
-		lager   *lagertest.TestLogger
+		bifrost     *eirinifakes.FakeBifrost
+		stager      *eirinifakes.FakeStager
+		taskBifrost *eirinifakes.FakeTaskBifrost
+		lager       *lagertest.TestLogger
 	)
 
 	BeforeEach(func() {
 		bifrost = new(eirinifakes.FakeBifrost)
 		stager = new(eirinifakes.FakeStager)
+		taskBifrost = new(eirinifakes.FakeTaskBifrost)
 		lager = lagertest.NewTestLogger("app-handler-test")
 	})
 
@@ -64,7 +67,7 @@ var _ = Describe("AppHandler", func() {
 		})
 
 		JustBeforeEach(func() {
-			ts := httptest.NewServer(New(bifrost, stager, lager))
+			ts := httptest.NewServer(New(bifrost, stager, taskBifrost, lager))
 			req, err := http.NewRequest("PUT", ts.URL+path, bytes.NewReader([]byte(body)))
 			Expect(err).NotTo(HaveOccurred())
 
@@ -125,6 +128,51 @@ var _ = Describe("AppHandler", func() {
 			})
 		})
 
+		Context("when the app already exists", func() {
+			BeforeEach(func() {
+				bifrost.TransferReturns(opi.NewAlreadyExistsError("app already exists"))
+			})
+
+			It("should return a 409 Conflict HTTP status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusConflict))
+			})
+
+			It("should return the error in the response body", func() {
+				var lifecycleResponse models.DesiredLRPLifecycleResponse
+				Expect(json.NewDecoder(response.Body).Decode(&lifecycleResponse)).To(Succeed())
+				Expect(lifecycleResponse.Error).To(Equal(models.NewError(models.Error_ResourceExists, "app already exists")))
+			})
+		})
+
+		Context("when the request cannot be converted", func() {
+			BeforeEach(func() {
+				bifrost.TransferReturns(opi.NewInvalidError("invalid vcap application"))
+			})
+
+			It("should return a 422 Unprocessable Entity HTTP status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusUnprocessableEntity))
+			})
+		})
+
+		Context("when the backend is unavailable", func() {
+			BeforeEach(func() {
+				bifrost.TransferReturns(opi.NewBackendUnavailableError("connection refused"))
+			})
+
+			It("should return a 503 Service Unavailable HTTP status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+
+		Context("when desiring the app fails for an unknown reason", func() {
+			BeforeEach(func() {
+				bifrost.TransferReturns(errors.New("boom"))
+			})
+
+			It("should return a 500 Internal Server Error HTTP status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+		})
 	})
 
 	Context("List Apps", func() {
@@ -201,7 +249,7 @@ var _ = Describe("AppHandler", func() {
 		})
 
 		JustBeforeEach(func() {
-			ts := httptest.NewServer(New(bifrost, stager, lager))
+			ts := httptest.NewServer(New(bifrost, stager, taskBifrost, lager))
 			req, err := http.NewRequest("POST", ts.URL+path, bytes.NewReader([]byte(body)))
 			Expect(err).NotTo(HaveOccurred())
 
@@ -226,6 +274,20 @@ var _ = Describe("AppHandler", func() {
 				Expect(request.Version).To(Equal("version-id"))
 				Expect(*request.Update.Instances).To(Equal(int32(5)))
 			})
+
+			Context("and the app configuration is changed", func() {
+				BeforeEach(func() {
+					body = `{"guid": "app-id", "version": "version-id", "update": {"instances": 5}, "memory_mb": 1024, "health_check_type": "port", "droplet_guid": "new-droplet", "droplet_hash": "new-hash"}`
+				})
+
+				It("should pass the changes to the update", func() {
+					_, request := bifrost.UpdateArgsForCall(0)
+					Expect(*request.MemoryMB).To(Equal(int64(1024)))
+					Expect(*request.HealthCheckType).To(Equal("port"))
+					Expect(request.DropletGUID).To(Equal("new-droplet"))
+					Expect(request.DropletHash).To(Equal("new-hash"))
+				})
+			})
 		})
 
 		Context("when the json is invalid", func() {
@@ -274,7 +336,7 @@ var _ = Describe("AppHandler", func() {
 		})
 
 		JustBeforeEach(func() {
-			ts := httptest.NewServer(New(bifrost, stager, lager))
+			ts := httptest.NewServer(New(bifrost, stager, taskBifrost, lager))
 			req, err := http.NewRequest("GET", ts.URL+path, nil)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -297,7 +359,7 @@ var _ = Describe("AppHandler", func() {
 					ProcessGuid: "guid_1234-version_1234",
 					Instances:   5,
 				}
-				bifrost.GetAppReturns(desiredLRP)
+				bifrost.GetAppReturns(desiredLRP, nil)
 			})
 
 			It("should return a 200 HTTP status code", func() {
@@ -318,13 +380,29 @@ var _ = Describe("AppHandler", func() {
 
 		Context("when the app does not exist", func() {
 			BeforeEach(func() {
-				bifrost.GetAppReturns(nil)
+				bifrost.GetAppReturns(nil, opi.NewNotFoundError("app not found"))
 			})
 
 			It("should return a 404 HTTP status code", func() {
 				Expect(response.StatusCode).To(Equal(http.StatusNotFound))
 			})
 
+			It("should return the error in the response body", func() {
+				var getLRPResponse models.DesiredLRPResponse
+				err := json.NewDecoder(response.Body).Decode(&getLRPResponse)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(getLRPResponse.Error).To(Equal(models.NewError(models.Error_ResourceNotFound, "app not found")))
+			})
+		})
+
+		Context("when the backend is unavailable", func() {
+			BeforeEach(func() {
+				bifrost.GetAppReturns(nil, opi.NewBackendUnavailableError("connection refused"))
+			})
+
+			It("should return a 503 HTTP status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
 		})
 	})
 
@@ -339,7 +417,7 @@ var _ = Describe("AppHandler", func() {
 		})
 
 		JustBeforeEach(func() {
-			ts := httptest.NewServer(New(bifrost, stager, lager))
+			ts := httptest.NewServer(New(bifrost, stager, taskBifrost, lager))
 			req, err := http.NewRequest("PUT", ts.URL+path, nil)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -376,6 +454,21 @@ var _ = Describe("AppHandler", func() {
 				Expect(messages).To(ConsistOf("app-handler-test.stop-app-failed"))
 			})
 
+			It("should return the error in the response body", func() {
+				var lifecycleResponse models.DesiredLRPLifecycleResponse
+				Expect(json.NewDecoder(response.Body).Decode(&lifecycleResponse)).To(Succeed())
+				Expect(lifecycleResponse.Error.Message).To(Equal("someting-bad-happened"))
+			})
+		})
+
+		Context("when the app does not exist", func() {
+			BeforeEach(func() {
+				bifrost.StopReturns(opi.NewNotFoundError("app not found"))
+			})
+
+			It("should return a 404 HTTP status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+			})
 		})
 	})
 
@@ -390,7 +483,7 @@ var _ = Describe("AppHandler", func() {
 		})
 
 		JustBeforeEach(func() {
-			ts := httptest.NewServer(New(bifrost, stager, lager))
+			ts := httptest.NewServer(New(bifrost, stager, taskBifrost, lager))
 			req, err := http.NewRequest("PUT", ts.URL+path, nil)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -480,7 +573,7 @@ var _ = Describe("AppHandler", func() {
 		})
 
 		JustBeforeEach(func() {
-			ts := httptest.NewServer(New(bifrost, stager, lager))
+			ts := httptest.NewServer(New(bifrost, stager, taskBifrost, lager))
 			req, err := http.NewRequest("GET", ts.URL+path, nil)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -526,7 +619,11 @@ var _ = Describe("AppHandler", func() {
 
 		Context("when Bifrost returns an error", func() {
 			BeforeEach(func() {
-				bifrost.GetInstancesReturns([]*cf.Instance{}, errors.New("not found"))
+				bifrost.GetInstancesReturns([]*cf.Instance{}, opi.NewNotFoundError("not found"))
+			})
+
+			It("should return a 404 HTTP status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusNotFound))
 			})
 
 			It("returns the error in the response", func() {
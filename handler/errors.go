@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/eirini/opi"
+)
+
+func statusCodeForError(err error) int {
+	switch opi.ReasonForError(err) {
+	case opi.ReasonNotFound:
+		return http.StatusNotFound
+	case opi.ReasonAlreadyExists, opi.ReasonConflict:
+		return http.StatusConflict
+	case opi.ReasonInvalid:
+		return http.StatusUnprocessableEntity
+	case opi.ReasonBackendUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func toModelsError(err error) *models.Error {
+	errorType := models.Error_UnknownError
+	switch opi.ReasonForError(err) {
+	case opi.ReasonNotFound:
+		errorType = models.Error_ResourceNotFound
+	case opi.ReasonAlreadyExists:
+		errorType = models.Error_ResourceExists
+	case opi.ReasonConflict:
+		errorType = models.Error_ResourceConflict
+	case opi.ReasonInvalid:
+		errorType = models.Error_InvalidRequest
+	}
+
+	return models.NewError(errorType, err.Error())
+}
+
+// writeErrorResponse writes the body of the error responses of every
+// handler
+func writeErrorResponse(w http.ResponseWriter, err error, statusCode int) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.DesiredLRPLifecycleResponse{
+		Error: toModelsError(err),
+	}
+
+	body, marshalError := json.Marshal(response)
+	if marshalError != nil {
+		panic(marshalError)
+	}
+
+	_, err = w.Write(body)
+	return err
+}
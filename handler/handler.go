@@ -8,14 +8,16 @@ import (
 	"github.com/julienschmidt/httprouter"
 )
 
-func New(bifrost eirini.Bifrost, stager eirini.Stager, lager lager.Logger) http.Handler {
+func New(bifrost eirini.Bifrost, stager eirini.Stager, taskBifrost eirini.TaskBifrost, lager lager.Logger) http.Handler {
 	handler := httprouter.New()
 
 	appHandler := NewAppHandler(bifrost, lager)
 	stageHandler := NewStageHandler(stager, lager)
+	taskHandler := NewTaskHandler(taskBifrost, lager)
 
 	registerAppsEndpoints(handler, appHandler)
 	registerStageEndpoints(handler, stageHandler)
+	registerTaskEndpoints(handler, taskHandler)
 
 	return handler
 }
@@ -32,5 +34,13 @@ func registerAppsEndpoints(handler *httprouter.Router, appHandler *App) {
 
 func registerStageEndpoints(handler *httprouter.Router, stageHandler *Stage) {
 	handler.POST("/stage/:staging_guid", stageHandler.Stage)
+	handler.GET("/stage/:staging_guid", stageHandler.GetStatus)
+	handler.DELETE("/stage/:staging_guid", stageHandler.Cancel)
 	handler.PUT("/stage/:staging_guid/completed", stageHandler.StagingComplete)
 }
+
+func registerTaskEndpoints(handler *httprouter.Router, taskHandler *Task) {
+	handler.POST("/tasks/:task_guid", taskHandler.Run)
+	handler.GET("/tasks/:task_guid", taskHandler.Get)
+	handler.DELETE("/tasks/:task_guid", taskHandler.Cancel)
+}
@@ -11,6 +11,7 @@ import (
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/eirini/eirinifakes"
 	. "code.cloudfoundry.org/eirini/handler"
+	"code.cloudfoundry.org/eirini/models/cf"
 	"code.cloudfoundry.org/lager/lagertest"
 )
 
@@ -21,6 +22,7 @@ var _ = Describe("Handler", func() {
 		client        *http.Client
 		bifrost       *eirinifakes.FakeBifrost
 		stager        *eirinifakes.FakeStager
+		taskBifrost   *eirinifakes.FakeTaskBifrost
 		handlerClient http.Handler
 	)
 
@@ -28,8 +30,9 @@ var _ = Describe("Handler", func() {
 		client = &http.Client{}
 		bifrost = new(eirinifakes.FakeBifrost)
 		stager = new(eirinifakes.FakeStager)
+		taskBifrost = new(eirinifakes.FakeTaskBifrost)
 		lager := lagertest.NewTestLogger("handler-test")
-		handlerClient = New(bifrost, stager, lager)
+		handlerClient = New(bifrost, stager, taskBifrost, lager)
 	})
 
 	JustBeforeEach(func() {
@@ -101,7 +104,7 @@ var _ = Describe("Handler", func() {
 				path = "/apps/myguid/myversion"
 				expectedStatus = http.StatusOK
 
-				bifrost.GetAppReturns(&models.DesiredLRP{})
+				bifrost.GetAppReturns(&models.DesiredLRP{}, nil)
 			})
 
 			It("serves the endpoint", func() {
@@ -162,6 +165,34 @@ var _ = Describe("Handler", func() {
 			})
 		})
 
+		Context("GET /stage/:staging_guid", func() {
+
+			BeforeEach(func() {
+				method = "GET"
+				path = "/stage/stage_123"
+				expectedStatus = http.StatusOK
+
+				stager.GetStagingStatusReturns(&cf.StagingStatus{}, nil)
+			})
+
+			It("serves the endpoint", func() {
+				assertEndpoint()
+			})
+		})
+
+		Context("DELETE /stage/:staging_guid", func() {
+
+			BeforeEach(func() {
+				method = "DELETE"
+				path = "/stage/stage_123"
+				expectedStatus = http.StatusOK
+			})
+
+			It("serves the endpoint", func() {
+				assertEndpoint()
+			})
+		})
+
 		Context("PUT /stage/:staging_guid/completed", func() {
 
 			BeforeEach(func() {
@@ -175,6 +206,48 @@ var _ = Describe("Handler", func() {
 				assertEndpoint()
 			})
 		})
+
+		Context("POST /tasks/:task_guid", func() {
+
+			BeforeEach(func() {
+				method = "POST"
+				path = "/tasks/task_123"
+				body = `{}`
+				expectedStatus = http.StatusAccepted
+			})
+
+			It("serves the endpoint", func() {
+				assertEndpoint()
+			})
+		})
+
+		Context("GET /tasks/:task_guid", func() {
+
+			BeforeEach(func() {
+				method = "GET"
+				path = "/tasks/task_123"
+				expectedStatus = http.StatusOK
+
+				taskBifrost.GetTaskReturns(&cf.TaskResponse{}, nil)
+			})
+
+			It("serves the endpoint", func() {
+				assertEndpoint()
+			})
+		})
+
+		Context("DELETE /tasks/:task_guid", func() {
+
+			BeforeEach(func() {
+				method = "DELETE"
+				path = "/tasks/task_123"
+				expectedStatus = http.StatusOK
+			})
+
+			It("serves the endpoint", func() {
+				assertEndpoint()
+			})
+		})
 	})
 
 })
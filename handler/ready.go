@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/lager"
+)
+
+// WithReadinessGate serves GET /ready and rejects app requests with 503
+// Service Unavailable until ready returns true, so that CC never sees
+// answers from a cache that has not been filled yet
+func WithReadinessGate(next http.Handler, ready func() bool, logger lager.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" {
+			if !ready() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/apps") && !ready() {
+			logger.Info("app-request-before-cache-synced", lager.Data{"path": r.URL.Path})
+			err := opi.NewBackendUnavailableError("app cache has not synced yet")
+			if writeErr := writeErrorResponse(w, err, http.StatusServiceUnavailable); writeErr != nil {
+				logger.Error("failed-to-write-response", writeErr)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,80 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/eirini/eirinifakes"
+	. "code.cloudfoundry.org/eirini/handler"
+	"code.cloudfoundry.org/lager/lagertest"
+)
+
+var _ = Describe("ReadinessGate", func() {
+
+	var (
+		ts      *httptest.Server
+		bifrost *eirinifakes.FakeBifrost
+		ready   bool
+	)
+
+	get := func(path string) *http.Response {
+		response, err := http.Get(ts.URL + path)
+		Expect(err).ToNot(HaveOccurred())
+		return response
+	}
+
+	BeforeEach(func() {
+		bifrost = new(eirinifakes.FakeBifrost)
+		ready = false
+
+		logger := lagertest.NewTestLogger("readiness-test")
+		handler := New(bifrost, new(eirinifakes.FakeStager), new(eirinifakes.FakeTaskBifrost), logger)
+		ts = httptest.NewServer(WithReadinessGate(handler, func() bool { return ready }, logger))
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	Context("when the cache has not synced", func() {
+
+		It("should report that it is not ready", func() {
+			Expect(get("/ready").StatusCode).To(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("should reject app requests", func() {
+			response := get("/apps")
+			Expect(response.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(bifrost.ListCallCount()).To(Equal(0))
+
+			var body models.DesiredLRPLifecycleResponse
+			Expect(json.NewDecoder(response.Body).Decode(&body)).To(Succeed())
+			Expect(body.Error.Message).To(Equal("app cache has not synced yet"))
+		})
+
+		It("should still serve task requests", func() {
+			Expect(get("/tasks/some-guid").StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+
+	Context("when the cache has synced", func() {
+
+		BeforeEach(func() {
+			ready = true
+		})
+
+		It("should report that it is ready", func() {
+			Expect(get("/ready").StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("should serve app requests", func() {
+			Expect(get("/apps").StatusCode).To(Equal(http.StatusOK))
+			Expect(bifrost.ListCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -32,19 +32,49 @@ func (s *Stage) Stage(resp http.ResponseWriter, req *http.Request, ps httprouter
 	var stagingRequest cf.StagingRequest
 	if err := json.NewDecoder(req.Body).Decode(&stagingRequest); err != nil {
 		logger.Error("staging-request-body-decoding-failed", err)
-		writeErrorResponse(resp, http.StatusBadRequest, err)
+		s.writeErrorResponse(resp, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := s.stager.Stage(stagingGUID, stagingRequest); err != nil {
 		logger.Error("stage-app-failed", err)
-		writeErrorResponse(resp, http.StatusInternalServerError, err)
+		s.writeErrorResponse(resp, err, statusCodeForError(err))
 		return
 	}
 
 	resp.WriteHeader(http.StatusAccepted)
 }
 
+func (s *Stage) GetStatus(resp http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	stagingGUID := ps.ByName("staging_guid")
+	logger := s.logger.Session("staging-status", lager.Data{"staging-guid": stagingGUID})
+
+	status, err := s.stager.GetStagingStatus(stagingGUID)
+	if err != nil {
+		logger.Error("get-staging-status-failed", err)
+		s.writeErrorResponse(resp, err, statusCodeForError(err))
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(resp).Encode(status); err != nil {
+		logger.Error("encode-json-failed", err)
+	}
+}
+
+func (s *Stage) Cancel(resp http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	stagingGUID := ps.ByName("staging_guid")
+	logger := s.logger.Session("cancel-staging", lager.Data{"staging-guid": stagingGUID})
+
+	if err := s.stager.CancelStaging(stagingGUID); err != nil {
+		logger.Error("cancel-staging-failed", err)
+		s.writeErrorResponse(resp, err, statusCodeForError(err))
+		return
+	}
+
+	logger.Info("staging-cancelled")
+}
+
 func (s *Stage) StagingComplete(res http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	stagingGUID := ps.ByName("staging_guid")
 	logger := s.logger.Session("staging-complete", lager.Data{"staging-guid": stagingGUID})
@@ -58,7 +88,7 @@ func (s *Stage) StagingComplete(res http.ResponseWriter, req *http.Request, ps h
 	}
 
 	if err = s.stager.CompleteStaging(task); err != nil {
-		res.WriteHeader(http.StatusInternalServerError)
+		res.WriteHeader(statusCodeForError(err))
 		logger.Error("staging-completion-failed", err)
 		return
 	}
@@ -66,10 +96,8 @@ func (s *Stage) StagingComplete(res http.ResponseWriter, req *http.Request, ps h
 	logger.Info("posted-staging-complete")
 }
 
-func writeErrorResponse(resp http.ResponseWriter, status int, err error) {
-	resp.WriteHeader(status)
-	encodingErr := json.NewEncoder(resp).Encode(&cf.StagingError{Message: err.Error()})
-	if encodingErr != nil {
-		panic(encodingErr)
+func (s *Stage) writeErrorResponse(resp http.ResponseWriter, err error, statusCode int) {
+	if err = writeErrorResponse(resp, err, statusCode); err != nil {
+		s.logger.Error("failed-to-write-response", err)
 	}
 }
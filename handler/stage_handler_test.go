@@ -15,6 +15,7 @@ import (
 	"code.cloudfoundry.org/eirini/eirinifakes"
 	. "code.cloudfoundry.org/eirini/handler"
 	"code.cloudfoundry.org/eirini/models/cf"
+	"code.cloudfoundry.org/eirini/opi"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 )
@@ -27,6 +28,7 @@ var _ = Describe("StageHandler", func() {
 
 		stagingClient *eirinifakes.FakeStager
 		bifrost       *eirinifakes.FakeBifrost
+		taskBifrost   *eirinifakes.FakeTaskBifrost
 		response      *http.Response
 		body          string
 		path          string
@@ -37,10 +39,11 @@ var _ = Describe("StageHandler", func() {
 		logger = lagertest.NewTestLogger("test")
 		stagingClient = new(eirinifakes.FakeStager)
 		bifrost = new(eirinifakes.FakeBifrost)
+		taskBifrost = new(eirinifakes.FakeTaskBifrost)
 	})
 
 	JustBeforeEach(func() {
-		handler := New(bifrost, stagingClient, logger)
+		handler := New(bifrost, stagingClient, taskBifrost, logger)
 		ts = httptest.NewServer(handler)
 		req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader([]byte(body)))
 		Expect(err).NotTo(HaveOccurred())
@@ -101,10 +104,10 @@ var _ = Describe("StageHandler", func() {
 
 			It("should return the error in the response body", func() {
 				bytes, _ := ioutil.ReadAll(response.Body)
-				stagingError := cf.StagingError{}
-				err := json.Unmarshal(bytes, &stagingError)
+				var errorResponse models.DesiredLRPLifecycleResponse
+				err := json.Unmarshal(bytes, &errorResponse)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(stagingError.Message).ToNot(BeEmpty())
+				Expect(errorResponse.Error.Message).ToNot(BeEmpty())
 			})
 
 			It("should not desire a task", func() {
@@ -123,10 +126,10 @@ var _ = Describe("StageHandler", func() {
 
 			It("should return the error in the response body", func() {
 				bytes, _ := ioutil.ReadAll(response.Body)
-				stagingError := cf.StagingError{}
-				err := json.Unmarshal(bytes, &stagingError)
+				var errorResponse models.DesiredLRPLifecycleResponse
+				err := json.Unmarshal(bytes, &errorResponse)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(stagingError.Message).To(Equal("pow"))
+				Expect(errorResponse.Error).To(Equal(models.NewError(models.Error_UnknownError, "pow")))
 			})
 		})
 	})
@@ -174,4 +177,80 @@ var _ = Describe("StageHandler", func() {
 		})
 	})
 
+	Context("When the staging status is requested", func() {
+		BeforeEach(func() {
+			method = "GET"
+			path = "/stage/staging_123523"
+			body = ""
+
+			stagingClient.GetStagingStatusReturns(&cf.StagingStatus{
+				StagingGUID: "staging_123523",
+				State:       "RUNNING",
+				Phases: []cf.StagingPhase{
+					{Name: "downloader", State: "SUCCEEDED"},
+					{Name: "executor", State: "RUNNING"},
+				},
+			}, nil)
+		})
+
+		It("should return a 200 OK status code", func() {
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("should get the status of the right staging", func() {
+			Expect(stagingClient.GetStagingStatusCallCount()).To(Equal(1))
+			Expect(stagingClient.GetStagingStatusArgsForCall(0)).To(Equal("staging_123523"))
+		})
+
+		It("should return the staging status", func() {
+			var status cf.StagingStatus
+			Expect(json.NewDecoder(response.Body).Decode(&status)).To(Succeed())
+			Expect(status).To(Equal(cf.StagingStatus{
+				StagingGUID: "staging_123523",
+				State:       "RUNNING",
+				Phases: []cf.StagingPhase{
+					{Name: "downloader", State: "SUCCEEDED"},
+					{Name: "executor", State: "RUNNING"},
+				},
+			}))
+		})
+
+		Context("and the staging does not exist", func() {
+			BeforeEach(func() {
+				stagingClient.GetStagingStatusReturns(nil, opi.NewNotFoundError("nope"))
+			})
+
+			It("should return a 404 Not Found status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("When staging is cancelled", func() {
+		BeforeEach(func() {
+			method = "DELETE"
+			path = "/stage/staging_123523"
+			body = ""
+		})
+
+		It("should return a 200 OK status code", func() {
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("should cancel the right staging", func() {
+			Expect(stagingClient.CancelStagingCallCount()).To(Equal(1))
+			Expect(stagingClient.CancelStagingArgsForCall(0)).To(Equal("staging_123523"))
+		})
+
+		Context("and cancelling fails", func() {
+			BeforeEach(func() {
+				stagingClient.CancelStagingReturns(errors.New("boo"))
+			})
+
+			It("should return a 500 Internal Server Error response code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+		})
+	})
+
 })
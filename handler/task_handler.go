@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/models/cf"
+	"code.cloudfoundry.org/lager"
+	"github.com/julienschmidt/httprouter"
+)
+
+type Task struct {
+	taskBifrost eirini.TaskBifrost
+	logger      lager.Logger
+}
+
+func NewTaskHandler(taskBifrost eirini.TaskBifrost, logger lager.Logger) *Task {
+	logger = logger.Session("task-handler")
+
+	return &Task{
+		taskBifrost: taskBifrost,
+		logger:      logger,
+	}
+}
+
+func (t *Task) Run(resp http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	taskGUID := ps.ByName("task_guid")
+	logger := t.logger.Session("run-task", lager.Data{"task-guid": taskGUID})
+
+	var taskRequest cf.TaskRequest
+	if err := json.NewDecoder(req.Body).Decode(&taskRequest); err != nil {
+		logger.Error("task-request-body-decoding-failed", err)
+		t.writeErrorResponse(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := t.taskBifrost.TransferTask(req.Context(), taskGUID, taskRequest); err != nil {
+		logger.Error("task-request-failed", err)
+		t.writeErrorResponse(resp, err, statusCodeForError(err))
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+func (t *Task) Get(resp http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	taskGUID := ps.ByName("task_guid")
+	logger := t.logger.Session("get-task", lager.Data{"task-guid": taskGUID})
+
+	task, err := t.taskBifrost.GetTask(req.Context(), taskGUID)
+	if err != nil {
+		logger.Error("get-task-failed", err)
+		t.writeErrorResponse(resp, err, statusCodeForError(err))
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(resp).Encode(task); err != nil {
+		logger.Error("encode-json-failed", err)
+	}
+}
+
+func (t *Task) Cancel(resp http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	taskGUID := ps.ByName("task_guid")
+	logger := t.logger.Session("cancel-task", lager.Data{"task-guid": taskGUID})
+
+	if err := t.taskBifrost.CancelTask(req.Context(), taskGUID); err != nil {
+		logger.Error("cancel-task-failed", err)
+		t.writeErrorResponse(resp, err, statusCodeForError(err))
+		return
+	}
+
+	logger.Info("task-cancelled")
+}
+
+func (t *Task) writeErrorResponse(resp http.ResponseWriter, err error, statusCode int) {
+	if err = writeErrorResponse(resp, err, statusCode); err != nil {
+		t.logger.Error("failed-to-write-response", err)
+	}
+}
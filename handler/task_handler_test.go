@@ -0,0 +1,212 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/eirini/eirinifakes"
+	. "code.cloudfoundry.org/eirini/handler"
+	"code.cloudfoundry.org/eirini/models/cf"
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+)
+
+var _ = Describe("TaskHandler", func() {
+
+	var (
+		ts     *httptest.Server
+		logger lager.Logger
+
+		taskBifrost *eirinifakes.FakeTaskBifrost
+		response    *http.Response
+		body        string
+		path        string
+		method      string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		taskBifrost = new(eirinifakes.FakeTaskBifrost)
+	})
+
+	JustBeforeEach(func() {
+		handler := New(new(eirinifakes.FakeBifrost), new(eirinifakes.FakeStager), taskBifrost, logger)
+		ts = httptest.NewServer(handler)
+		req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader([]byte(body)))
+		Expect(err).NotTo(HaveOccurred())
+
+		client := &http.Client{}
+		response, err = client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	Context("When a task is submitted", func() {
+
+		BeforeEach(func() {
+			method = "POST"
+			path = "/tasks/guid_1234"
+			body = `{
+				"app_guid": "our-app-id",
+				"name": "migrate",
+				"command": "rake db:migrate",
+				"environment": [{"name": "HOWARD", "value": "the alien"}],
+				"completion_callback": "example.com/call/me/maybe",
+				"droplet_guid": "the-droplet-guid",
+				"droplet_hash": "the-droplet-hash",
+				"memory_mb": 256,
+				"disk_mb": 1024
+			}`
+		})
+
+		It("should return 202 Accepted code", func() {
+			Expect(response.StatusCode).To(Equal(http.StatusAccepted))
+		})
+
+		It("should transfer the task", func() {
+			Expect(taskBifrost.TransferTaskCallCount()).To(Equal(1))
+			_, taskGUID, taskRequest := taskBifrost.TransferTaskArgsForCall(0)
+
+			Expect(taskGUID).To(Equal("guid_1234"))
+			Expect(taskRequest).To(Equal(cf.TaskRequest{
+				AppGUID: "our-app-id",
+				Name:    "migrate",
+				Command: "rake db:migrate",
+				Environment: []cf.EnvironmentVariable{
+					{Name: "HOWARD", Value: "the alien"},
+				},
+				CompletionCallback: "example.com/call/me/maybe",
+				DropletGUID:        "the-droplet-guid",
+				DropletHash:        "the-droplet-hash",
+				MemoryMB:           256,
+				DiskMB:             1024,
+			}))
+		})
+
+		Context("and the body is invalid", func() {
+			BeforeEach(func() {
+				body = "{ this json is invalid"
+			})
+
+			It("should return a 400 Bad Request status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+
+			It("should not transfer the task", func() {
+				Expect(taskBifrost.TransferTaskCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("and transferring the task fails", func() {
+			BeforeEach(func() {
+				taskBifrost.TransferTaskReturns(errors.New("pow"))
+			})
+
+			It("should return a 500 Internal Server Error", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+
+			It("should return the error in the response body", func() {
+				bytes, _ := ioutil.ReadAll(response.Body)
+				var errorResponse models.DesiredLRPLifecycleResponse
+				err := json.Unmarshal(bytes, &errorResponse)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(errorResponse.Error).To(Equal(models.NewError(models.Error_UnknownError, "pow")))
+			})
+		})
+	})
+
+	Context("When a task is requested", func() {
+
+		BeforeEach(func() {
+			method = "GET"
+			path = "/tasks/guid_1234"
+			body = ""
+
+			taskBifrost.GetTaskReturns(&cf.TaskResponse{
+				TaskGUID: "guid_1234",
+				State:    "RUNNING",
+			}, nil)
+		})
+
+		It("should return 200 OK code", func() {
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("should get the task by its guid", func() {
+			Expect(taskBifrost.GetTaskCallCount()).To(Equal(1))
+			_, taskGUID := taskBifrost.GetTaskArgsForCall(0)
+			Expect(taskGUID).To(Equal("guid_1234"))
+		})
+
+		It("should return the task in the response body", func() {
+			var task cf.TaskResponse
+			Expect(json.NewDecoder(response.Body).Decode(&task)).To(Succeed())
+			Expect(task).To(Equal(cf.TaskResponse{
+				TaskGUID: "guid_1234",
+				State:    "RUNNING",
+			}))
+		})
+
+		Context("and the task cannot be found", func() {
+			BeforeEach(func() {
+				taskBifrost.GetTaskReturns(nil, opi.NewNotFoundError("not here"))
+			})
+
+			It("should return a 404 Not Found status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("When a task is cancelled", func() {
+
+		BeforeEach(func() {
+			method = "DELETE"
+			path = "/tasks/guid_1234"
+			body = ""
+		})
+
+		It("should return 200 OK code", func() {
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("should cancel the task", func() {
+			Expect(taskBifrost.CancelTaskCallCount()).To(Equal(1))
+			_, taskGUID := taskBifrost.CancelTaskArgsForCall(0)
+			Expect(taskGUID).To(Equal("guid_1234"))
+		})
+
+		Context("and cancelling the task fails", func() {
+			BeforeEach(func() {
+				taskBifrost.CancelTaskReturns(errors.New("boo"))
+			})
+
+			It("should return a 500 Internal Server Error", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+			})
+		})
+
+		Context("and the task does not exist", func() {
+			BeforeEach(func() {
+				taskBifrost.CancelTaskReturns(opi.NewNotFoundError("no such task"))
+			})
+
+			It("should return a 404 Not Found status code", func() {
+				Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
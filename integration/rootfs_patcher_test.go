@@ -27,6 +27,7 @@ var _ = Describe("RootfsPatcher", func() {
 			clientset,
 			namespace,
 			"old_rootfsversion",
+			k8s.LRPDesirerOptions{ConflictPolicy: k8s.ConflictPolicyReject},
 			lagertest.NewTestLogger("test-logger"),
 		)
 		odinLRP = createLRP("ödin")
@@ -40,6 +40,7 @@ var _ = Describe("StatefulSet Manager", func() {
 			clientset,
 			namespace,
 			"rootfsversion",
+			k8s.LRPDesirerOptions{ConflictPolicy: k8s.ConflictPolicyReject},
 			lagertest.NewTestLogger("test-logger"),
 		)
 	})
@@ -0,0 +1,97 @@
+package k8s
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// CPUPolicyShares requests 10 millicores per point of the CPU weight
+	// of an app and never limits it
+	CPUPolicyShares = "shares"
+	// CPUPolicyMemory requests CPU in proportion to the memory of an app
+	// and never limits it, like the CPU shares of Diego
+	CPUPolicyMemory = "memory"
+	// CPUPolicyLimits limits an app to CPU in proportion to its memory and
+	// requests that entitlement divided by the overcommit ratio
+	CPUPolicyLimits = "limits"
+
+	// DefaultMillicoresPerGB matches the shares policy for the CPU weight
+	// the Cloud Controller gives to apps, which reaches 100 at 8GB
+	DefaultMillicoresPerGB = 125
+
+	millicoresPerWeight = 10
+)
+
+// CPUPolicy decides the CPU requests and limits of app, task and staging
+// containers. The zero value is the shares policy.
+type CPUPolicy struct {
+	Type            string
+	MillicoresPerGB int64
+	OvercommitRatio float64
+}
+
+func NewCPUPolicy(policyType string, millicoresPerGB int64, overcommitRatio float64) (CPUPolicy, error) {
+	switch policyType {
+	case "":
+		policyType = CPUPolicyShares
+	case CPUPolicyShares, CPUPolicyMemory, CPUPolicyLimits:
+	default:
+		return CPUPolicy{}, fmt.Errorf("unsupported cpu policy %q", policyType)
+	}
+	if millicoresPerGB < 0 {
+		return CPUPolicy{}, fmt.Errorf("invalid cpu millicores per GB %d", millicoresPerGB)
+	}
+	if overcommitRatio != 0 && overcommitRatio < 1 {
+		return CPUPolicy{}, fmt.Errorf("invalid cpu overcommit ratio %g", overcommitRatio)
+	}
+	return CPUPolicy{
+		Type:            policyType,
+		MillicoresPerGB: millicoresPerGB,
+		OvercommitRatio: overcommitRatio,
+	}, nil
+}
+
+// Entitlement is the CPU in millicores that a container with the memory and
+// CPU weight is entitled to
+func (p CPUPolicy) Entitlement(memoryMB int64, cpuWeight uint8) int64 {
+	switch p.Type {
+	case CPUPolicyMemory, CPUPolicyLimits:
+		millicoresPerGB := p.MillicoresPerGB
+		if millicoresPerGB == 0 {
+			millicoresPerGB = DefaultMillicoresPerGB
+		}
+		return memoryMB * millicoresPerGB / 1024
+	default:
+		return int64(cpuWeight) * millicoresPerWeight
+	}
+}
+
+func (p CPUPolicy) apply(resources *corev1.ResourceRequirements, memoryMB int64, cpuWeight uint8) {
+	entitlement := p.Entitlement(memoryMB, cpuWeight)
+	if entitlement <= 0 {
+		return
+	}
+
+	request := entitlement
+	if p.Type == CPUPolicyLimits {
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		resources.Limits[corev1.ResourceCPU] = *resource.NewMilliQuantity(entitlement, resource.DecimalSI)
+
+		if p.OvercommitRatio > 1 {
+			request = int64(float64(entitlement) / p.OvercommitRatio)
+		}
+	}
+
+	if request <= 0 {
+		return
+	}
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	resources.Requests[corev1.ResourceCPU] = *resource.NewMilliQuantity(request, resource.DecimalSI)
+}
@@ -0,0 +1,46 @@
+package k8s_test
+
+import (
+	. "code.cloudfoundry.org/eirini/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CPUPolicy", func() {
+
+	Context("When creating a policy", func() {
+
+		It("should default to the shares policy", func() {
+			policy, err := NewCPUPolicy("", 0, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Type).To(Equal(CPUPolicyShares))
+		})
+
+		It("should reject unknown policies", func() {
+			_, err := NewCPUPolicy("fair", 0, 0)
+			Expect(err).To(MatchError(ContainSubstring(`unsupported cpu policy "fair"`)))
+		})
+
+		It("should reject overcommit ratios below 1", func() {
+			_, err := NewCPUPolicy(CPUPolicyLimits, 0, 0.5)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When computing the entitlement", func() {
+
+		It("should entitle 10 millicores per point of weight by shares", func() {
+			Expect(CPUPolicy{}.Entitlement(1024, 50)).To(Equal(int64(500)))
+		})
+
+		It("should entitle CPU in proportion to memory like Diego", func() {
+			policy := CPUPolicy{Type: CPUPolicyMemory}
+			Expect(policy.Entitlement(8192, 50)).To(Equal(int64(1000)))
+		})
+
+		It("should use the configured millicores per GB", func() {
+			policy := CPUPolicy{Type: CPUPolicyLimits, MillicoresPerGB: 500}
+			Expect(policy.Entitlement(2048, 50)).To(Equal(int64(1000)))
+		})
+	})
+})
@@ -0,0 +1,372 @@
+package k8s
+
+import (
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/eirini/util"
+	"code.cloudfoundry.org/lager"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	types "k8s.io/client-go/kubernetes/typed/apps/v1"
+)
+
+const (
+	// LRPBackendStatefulSet runs every LRP as a StatefulSet
+	LRPBackendStatefulSet = "statefulset"
+	// LRPBackendDeployment runs every LRP as a Deployment. Instance indexes
+	// are assigned to the pods by the instance index informer.
+	LRPBackendDeployment = "deployment"
+)
+
+type DeploymentDesirer struct {
+	Client                kubernetes.Interface
+	Namespace             string
+	RootfsVersion         string
+	ConflictPolicy        string
+	Placement             PlacementPolicy
+	Security              SecurityPolicy
+	CPU                   CPUPolicy
+	Memory                MemoryPolicy
+	Shutdown              ShutdownPolicy
+	LivenessProbeCreator  ProbeCreator
+	ReadinessProbeCreator ProbeCreator
+	Hasher                util.Hasher
+	Logger                lager.Logger
+	// Namespacer picks the namespace of new apps when set. Otherwise all
+	// apps are in Namespace.
+	Namespacer Namespacer
+	// NetworkPolicies applies the container networking policies of apps
+	// when set
+	NetworkPolicies NetworkPolicySyncer
+	// SecurityGroups restricts the egress of new apps when set
+	SecurityGroups SecurityGroupSyncer
+	// MaxUnavailable is the number or percentage of instances which may be
+	// down during a rollout, 25% when unset
+	MaxUnavailable intstr.IntOrString
+
+	desireLocks util.KeyedMutex
+}
+
+func NewDeploymentDesirer(client kubernetes.Interface, namespace string, rootfsVersion string, options LRPDesirerOptions, logger lager.Logger) opi.Desirer {
+	return &DeploymentDesirer{
+		Client:                client,
+		Namespace:             namespace,
+		RootfsVersion:         rootfsVersion,
+		ConflictPolicy:        options.ConflictPolicy,
+		Placement:             options.Placement,
+		Security:              options.Security,
+		CPU:                   options.CPU,
+		Memory:                options.Memory,
+		Shutdown:              options.Shutdown,
+		LivenessProbeCreator:  CreateLivenessProbe,
+		ReadinessProbeCreator: CreateReadinessProbe,
+		Hasher:                util.TruncatedSHA256Hasher{},
+		Logger:                logger,
+		Namespacer:            options.Namespacer,
+		NetworkPolicies:       options.NetworkPolicies,
+		SecurityGroups:        options.SecurityGroups,
+		MaxUnavailable:        options.MaxUnavailable,
+	}
+}
+
+func (m *DeploymentDesirer) Desire(lrp *opi.LRP) error {
+	unlock := m.desireLocks.Lock(lrp.ProcessGUID())
+	defer unlock()
+
+	deployment, err := m.getDeployment(lrp.LRPIdentifier)
+	if opi.IsNotFound(err) {
+		return m.create(lrp)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger := m.Logger.Session("desire-existing-app", lager.Data{"process-guid": lrp.ProcessGUID()})
+	storedRequest, err := loadOriginalRequest(m.Client, deployment.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	// the stored request does not tell the credentials of the app apart
+	sameEnv, err := envSecretMatches(m.Client, deployment.Namespace, deployment.Name, lrp.Env)
+	if err != nil {
+		return err
+	}
+	if storedRequest == lrp.LRP && sameEnv {
+		logger.Debug("app-already-desired")
+		// the dependents may have failed to apply when the app was created
+		return applyWorkloadDependents(m.Client, deployment.Namespace, deployment.Name, deployment.Spec.Selector.MatchLabels, lrp, ownerReference("Deployment", deployment.ObjectMeta))
+	}
+
+	if m.ConflictPolicy != ConflictPolicyUpdate {
+		logger.Info("rejecting-changed-app")
+		return opi.NewConflictError("app %s already exists with a different configuration", lrp.ProcessGUID())
+	}
+
+	logger.Info("updating-changed-app")
+	m.applyLRP(deployment, lrp)
+	for key, value := range lrp.Metadata {
+		deployment.Annotations[key] = value
+	}
+
+	if err = storeLRPDependents(m.Client, deployment.Namespace, &deployment.ObjectMeta, lrp, ownerReference("Deployment", deployment.ObjectMeta)); err != nil {
+		return err
+	}
+
+	return m.update(deployment, lrp)
+}
+
+func (m *DeploymentDesirer) create(lrp *opi.LRP) error {
+	deployment := m.toDeployment(lrp)
+	if err := m.Placement.isolate(&deployment.Spec.Template.Spec, lrp.PlacementTags); err != nil {
+		return err
+	}
+
+	namespace, err := ensureNamespace(m.Namespacer, m.Namespace, lrp.OrgGUID, lrp.SpaceGUID)
+	if err != nil {
+		return err
+	}
+	if err = restrictEgress(m.SecurityGroups, namespace, lrp.SpaceGUID, AppSourceType); err != nil {
+		return err
+	}
+
+	if err = storeLRPDependents(m.Client, namespace, &deployment.ObjectMeta, lrp, nil); err != nil {
+		return err
+	}
+
+	created, err := m.deployments(namespace).Create(deployment)
+	if err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			m.cleanUpDependents(namespace, deployment.Name)
+		}
+		return ToOpiError(err, "failed to create deployment")
+	}
+
+	owner := ownerReference("Deployment", created.ObjectMeta)
+	if err = storeLRPDependents(m.Client, namespace, &created.ObjectMeta, lrp, owner); err != nil {
+		return err
+	}
+	if err = applyWorkloadDependents(m.Client, namespace, created.Name, created.Spec.Selector.MatchLabels, lrp, owner); err != nil {
+		return err
+	}
+
+	// the policies are synced periodically as well, so a failure does not
+	// fail the desire
+	if m.NetworkPolicies != nil {
+		if err = m.NetworkPolicies.AppDesired(namespace, lrp.GUID); err != nil {
+			m.Logger.Error("failed-to-apply-network-policies", err, lager.Data{"process-guid": lrp.ProcessGUID()})
+		}
+	}
+	return nil
+}
+
+// cleanUpDependents deletes the dependents of a workload which could not
+// be created, as nothing would garbage collect them
+func (m *DeploymentDesirer) cleanUpDependents(namespace, name string) {
+	if err := deleteLRPDependents(m.Client, namespace, name); err != nil {
+		m.Logger.Error("failed-to-delete-dependents", err, lager.Data{"name": name})
+	}
+}
+
+func (m *DeploymentDesirer) List() ([]*opi.LRP, error) {
+	deployments, err := m.deployments(m.watchNamespace()).List(meta.ListOptions{})
+	if err != nil {
+		m.Logger.Error("failed-to-list-deployments", err)
+		return nil, ToOpiError(err, "failed to list deployments")
+	}
+
+	lrps := []*opi.LRP{}
+	for _, d := range deployments.Items {
+		lrps = append(lrps, deploymentToLRP(d))
+	}
+	return lrps, nil
+}
+
+func (m *DeploymentDesirer) Get(identifier opi.LRPIdentifier) (*opi.LRP, error) {
+	deployment, err := m.getDeployment(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	lrp := deploymentToLRP(*deployment)
+	if lrp.LRP, err = loadOriginalRequest(m.Client, deployment.ObjectMeta); err != nil {
+		return nil, err
+	}
+	return lrp, nil
+}
+
+func (m *DeploymentDesirer) Update(lrp *opi.LRP) error {
+	unlock := m.desireLocks.Lock(lrp.ProcessGUID())
+	defer unlock()
+
+	deployment, err := m.getDeployment(lrp.LRPIdentifier)
+	if err != nil {
+		return err
+	}
+
+	m.applyLRP(deployment, lrp)
+
+	if err = applyEnvSecret(m.Client, deployment.Namespace, deployment.Name, lrp, ownerReference("Deployment", deployment.ObjectMeta)); err != nil {
+		return err
+	}
+
+	return m.update(deployment, lrp)
+}
+
+func (m *DeploymentDesirer) update(deployment *appsv1.Deployment, lrp *opi.LRP) error {
+	updated, err := m.deployments(deployment.Namespace).Update(deployment)
+	if err != nil {
+		return ToOpiError(err, "failed to update deployment")
+	}
+	return applyWorkloadDependents(m.Client, updated.Namespace, updated.Name, updated.Spec.Selector.MatchLabels, lrp, ownerReference("Deployment", updated.ObjectMeta))
+}
+
+func (m *DeploymentDesirer) Stop(identifier opi.LRPIdentifier) error {
+	deployment, err := m.getDeployment(identifier)
+	if err != nil {
+		return err
+	}
+
+	if err = deleteWorkloadDependents(m.Client, deployment.Namespace, deployment.Name, deployment.Spec.Selector.MatchLabels); err != nil {
+		return err
+	}
+
+	backgroundPropagation := meta.DeletePropagationBackground
+	err = m.deployments(deployment.Namespace).Delete(deployment.Name, &meta.DeleteOptions{PropagationPolicy: &backgroundPropagation})
+	if err != nil {
+		return ToOpiError(err, "failed to delete deployment")
+	}
+
+	if m.NetworkPolicies != nil {
+		if err = m.NetworkPolicies.AppStopped(deployment.Namespace, identifier.GUID); err != nil {
+			m.Logger.Error("failed-to-remove-network-policies", err, lager.Data{"process-guid": identifier.ProcessGUID()})
+		}
+	}
+	return nil
+}
+
+func (m *DeploymentDesirer) StopInstance(identifier opi.LRPIdentifier, index uint) error {
+	if _, err := m.getDeployment(identifier); err != nil {
+		return err
+	}
+
+	pods, err := m.reader().getPods(identifier)
+	if err != nil {
+		m.Logger.Error("failed-to-list-pods", err, lager.Data{"process-guid": identifier.GUID})
+		return ToOpiError(err, "failed to list pods")
+	}
+
+	for _, pod := range pods {
+		podIndex, err := PodIndex(&pod)
+		if err != nil || podIndex != int(index) {
+			continue
+		}
+		err = m.Client.CoreV1().Pods(pod.Namespace).Delete(pod.Name, nil)
+		return ToOpiError(err, "failed to delete pod")
+	}
+
+	return opi.NewNotFoundError("app %s has no instance with index %d", identifier.ProcessGUID(), index)
+}
+
+func (m *DeploymentDesirer) GetInstances(identifier opi.LRPIdentifier) ([]*opi.Instance, error) {
+	reader := m.reader()
+	pods, err := reader.getPods(identifier)
+	if err != nil {
+		m.Logger.Error("failed-to-list-pods", err, lager.Data{"process-guid": identifier.GUID})
+		return []*opi.Instance{}, ToOpiError(err, "failed to list pods")
+	}
+
+	return toInstances(reader, pods, m.Logger)
+}
+
+func (m *DeploymentDesirer) getDeployment(identifier opi.LRPIdentifier) (*appsv1.Deployment, error) {
+	deployments, err := m.deployments(m.watchNamespace()).List(lrpListOptions(identifier))
+	if err != nil {
+		m.Logger.Error("failed-to-get-deployment", err, lager.Data{"process-guid": identifier.GUID})
+		return nil, ToOpiError(err, "failed to get deployment")
+	}
+
+	switch len(deployments.Items) {
+	case 0:
+		return nil, opi.NewNotFoundError("app %s not found", identifier.ProcessGUID())
+	case 1:
+		return &deployments.Items[0], nil
+	default:
+		m.Logger.Error("multiple-deployments-found", nil, lager.Data{"process-guid": identifier.GUID, "count": len(deployments.Items)})
+		return nil, opi.NewConflictError("more than one deployment was identified as %s", identifier.ProcessGUID())
+	}
+}
+
+func (m *DeploymentDesirer) applyLRP(deployment *appsv1.Deployment, lrp *opi.LRP) {
+	count := int32(lrp.TargetInstances)
+	deployment.Spec.Replicas = &count
+	deployment.Spec.Strategy = m.strategy()
+	applyLRPToPodTemplate(&deployment.ObjectMeta, &deployment.Spec.Template, lrp, m.LivenessProbeCreator, m.ReadinessProbeCreator)
+	applyResourcePolicies(&deployment.Spec.Template, lrp, m.CPU, m.Memory)
+	m.Shutdown.Apply(&deployment.Spec.Template)
+}
+
+func (m *DeploymentDesirer) toDeployment(lrp *opi.LRP) *appsv1.Deployment {
+	name := lrpName(m.Hasher, lrp)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: meta.ObjectMeta{
+			Name: name,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32ptr(lrp.TargetInstances),
+			Strategy: m.strategy(),
+			Template: toPodTemplateSpec(lrp, name, m.LivenessProbeCreator(lrp), m.ReadinessProbeCreator(lrp)),
+		},
+	}
+
+	deployment.Spec.Selector = &meta.LabelSelector{
+		MatchLabels: selectorLabels(lrp),
+	}
+
+	deployment.Spec.Template.Spec.Affinity = m.Placement.affinity(lrp)
+	m.Security.Apply(&deployment.Spec.Template)
+	applyResourcePolicies(&deployment.Spec.Template, lrp, m.CPU, m.Memory)
+	m.Shutdown.Apply(&deployment.Spec.Template)
+
+	labels := lrpLabels(lrp, m.RootfsVersion)
+	deployment.Spec.Template.Labels = labels
+	deployment.Labels = labels
+	deployment.Annotations = lrpAnnotations(lrp)
+
+	return deployment
+}
+
+// strategy never surges, so that a rollout replaces pods one for one and
+// the instance indexes of an app stay below its instance count
+func (m *DeploymentDesirer) strategy() appsv1.DeploymentStrategy {
+	maxSurge := intstr.FromInt(0)
+	maxUnavailable := m.MaxUnavailable
+	// no instance could be replaced without surging
+	if maxUnavailable == intstr.FromInt(0) {
+		maxUnavailable = intstr.FromString("25%")
+	}
+	return appsv1.DeploymentStrategy{
+		Type: appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxSurge:       &maxSurge,
+			MaxUnavailable: &maxUnavailable,
+		},
+	}
+}
+
+func (m *DeploymentDesirer) deployments(namespace string) types.DeploymentInterface {
+	return m.Client.AppsV1().Deployments(namespace)
+}
+
+func (m *DeploymentDesirer) watchNamespace() string {
+	return watchNamespace(m.Namespacer, m.Namespace)
+}
+
+func (m *DeploymentDesirer) reader() lrpReader {
+	return &apiLRPReader{client: m.Client, namespace: m.watchNamespace()}
+}
+
+func deploymentToLRP(d appsv1.Deployment) *opi.LRP {
+	return toLRP(d.ObjectMeta, d.Spec.Template, d.Spec.Replicas, d.Status.ReadyReplicas)
+}
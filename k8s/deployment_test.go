@@ -0,0 +1,245 @@
+package k8s_test
+
+import (
+	"code.cloudfoundry.org/eirini"
+	. "code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/eirini/k8s/k8sfakes"
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/eirini/util/utilfakes"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Deployment", func() {
+
+	var (
+		err                   error
+		client                *fake.Clientset
+		deploymentDesirer     opi.Desirer
+		livenessProbeCreator  *k8sfakes.FakeProbeCreator
+		readinessProbeCreator *k8sfakes.FakeProbeCreator
+		hasher                *utilfakes.FakeHasher
+		lrp                   *opi.LRP
+	)
+
+	listDeployments := func() []appsv1.Deployment {
+		list, listErr := client.AppsV1().Deployments(namespace).List(meta.ListOptions{})
+		Expect(listErr).NotTo(HaveOccurred())
+		return list.Items
+	}
+
+	deploymentPod := func(name string, index string) *corev1.Pod {
+		pod := toPod(name, 0, nil)
+		pod.Name = name
+		pod.OwnerReferences = []meta.OwnerReference{{Kind: "ReplicaSet", Name: "baldur-space-foo-random-5d8f7c"}}
+		if index != "" {
+			pod.Annotations = map[string]string{eirini.InstanceIndex: index}
+		}
+		return pod
+	}
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset()
+		livenessProbeCreator = new(k8sfakes.FakeProbeCreator)
+		readinessProbeCreator = new(k8sfakes.FakeProbeCreator)
+		hasher = new(utilfakes.FakeHasher)
+		hasher.HashReturns("random", nil)
+		lrp = createLRP("Baldur", "my.example.route")
+
+		deploymentDesirer = &DeploymentDesirer{
+			Client:                client,
+			Namespace:             namespace,
+			RootfsVersion:         "version1",
+			ConflictPolicy:        ConflictPolicyReject,
+			LivenessProbeCreator:  livenessProbeCreator.Spy,
+			ReadinessProbeCreator: readinessProbeCreator.Spy,
+			Hasher:                hasher,
+			Logger:                lagertest.NewTestLogger("test-logger"),
+		}
+	})
+
+	Context("When desiring an LRP", func() {
+
+		JustBeforeEach(func() {
+			err = deploymentDesirer.Desire(lrp)
+		})
+
+		It("should create a deployment", func() {
+			Expect(err).ToNot(HaveOccurred())
+
+			deployments := listDeployments()
+			Expect(deployments).To(HaveLen(1))
+			Expect(deployments[0].Name).To(Equal("baldur-space-foo-random"))
+			Expect(*deployments[0].Spec.Replicas).To(Equal(int32(lrp.TargetInstances)))
+			Expect(deployments[0].Annotations[eirini.OriginalRequest]).To(Equal(lrp.LRP))
+			Expect(deployments[0].Spec.Template.Labels).To(HaveKeyWithValue("guid", "guid_1234"))
+		})
+
+		It("should never surge during a rollout", func() {
+			strategy := listDeployments()[0].Spec.Strategy
+			Expect(strategy.RollingUpdate.MaxSurge.IntValue()).To(Equal(0))
+		})
+
+		It("should let a quarter of the instances be unavailable during a rollout", func() {
+			strategy := listDeployments()[0].Spec.Strategy
+			Expect(strategy.RollingUpdate.MaxUnavailable.String()).To(Equal("25%"))
+		})
+
+		Context("and the unavailable instances are configured", func() {
+			BeforeEach(func() {
+				deploymentDesirer.(*DeploymentDesirer).MaxUnavailable = intstr.FromInt(1)
+			})
+
+			It("should use them during a rollout", func() {
+				strategy := listDeployments()[0].Spec.Strategy
+				Expect(strategy.RollingUpdate.MaxUnavailable.IntValue()).To(Equal(1))
+			})
+		})
+
+		Context("and the app has service credentials", func() {
+			BeforeEach(func() {
+				lrp.Env = map[string]string{"VCAP_SERVICES": `{"credentials": "secret"}`}
+			})
+
+			It("should store them in a secret owned by the deployment", func() {
+				secret, getErr := client.CoreV1().Secrets(namespace).Get("baldur-space-foo-random-env", meta.GetOptions{})
+				Expect(getErr).ToNot(HaveOccurred())
+				Expect(secret.Data).To(HaveKeyWithValue("VCAP_SERVICES", []byte(`{"credentials": "secret"}`)))
+				Expect(secret.OwnerReferences[0].Kind).To(Equal("Deployment"))
+
+				env := listDeployments()[0].Spec.Template.Spec.Containers[0].Env
+				Expect(envNames(env)).To(ContainElement("VCAP_SERVICES"))
+				Expect(env).ToNot(ContainElement(corev1.EnvVar{Name: "VCAP_SERVICES", Value: `{"credentials": "secret"}`}))
+			})
+
+			Context("and the service is unbound", func() {
+				JustBeforeEach(func() {
+					lrp.Env = map[string]string{"PORT": "8080"}
+					Expect(deploymentDesirer.Update(lrp)).To(Succeed())
+				})
+
+				It("should stop reading them from the secret", func() {
+					secret, getErr := client.CoreV1().Secrets(namespace).Get("baldur-space-foo-random-env", meta.GetOptions{})
+					Expect(getErr).ToNot(HaveOccurred())
+					Expect(secret.Data).ToNot(HaveKey("VCAP_SERVICES"))
+
+					env := listDeployments()[0].Spec.Template.Spec.Containers[0].Env
+					Expect(envNames(env)).ToNot(ContainElement("VCAP_SERVICES"))
+				})
+			})
+		})
+
+		Context("and the same LRP was already desired", func() {
+			BeforeEach(func() {
+				Expect(deploymentDesirer.Desire(lrp)).To(Succeed())
+			})
+
+			It("should succeed without creating another deployment", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(listDeployments()).To(HaveLen(1))
+			})
+		})
+	})
+
+	Context("When the network policies of apps are synced", func() {
+		var networkPolicies *k8sfakes.FakeNetworkPolicySyncer
+
+		BeforeEach(func() {
+			networkPolicies = new(k8sfakes.FakeNetworkPolicySyncer)
+			deploymentDesirer.(*DeploymentDesirer).NetworkPolicies = networkPolicies
+			Expect(deploymentDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		It("should apply the network policies of a desired app", func() {
+			Expect(networkPolicies.AppDesiredCallCount()).To(Equal(1))
+			ns, guid := networkPolicies.AppDesiredArgsForCall(0)
+			Expect(ns).To(Equal(namespace))
+			Expect(guid).To(Equal(lrp.GUID))
+		})
+
+		It("should remove the network policies of a stopped app", func() {
+			Expect(deploymentDesirer.Stop(lrp.LRPIdentifier)).To(Succeed())
+
+			Expect(networkPolicies.AppStoppedCallCount()).To(Equal(1))
+			ns, guid := networkPolicies.AppStoppedArgsForCall(0)
+			Expect(ns).To(Equal(namespace))
+			Expect(guid).To(Equal(lrp.GUID))
+		})
+	})
+
+	Context("When an LRP exists", func() {
+
+		BeforeEach(func() {
+			Expect(deploymentDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		It("should get it", func() {
+			actualLRP, getErr := deploymentDesirer.Get(lrp.LRPIdentifier)
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(actualLRP.AppName).To(Equal("Baldur"))
+			Expect(actualLRP.TargetInstances).To(Equal(lrp.TargetInstances))
+		})
+
+		It("should list it", func() {
+			lrps, listErr := deploymentDesirer.List()
+			Expect(listErr).ToNot(HaveOccurred())
+			Expect(lrps).To(HaveLen(1))
+		})
+
+		It("should update its instance count", func() {
+			lrp.TargetInstances = 5
+			Expect(deploymentDesirer.Update(lrp)).To(Succeed())
+			Expect(*listDeployments()[0].Spec.Replicas).To(Equal(int32(5)))
+		})
+
+		It("should delete it when stopped", func() {
+			Expect(deploymentDesirer.Stop(lrp.LRPIdentifier)).To(Succeed())
+			Expect(listDeployments()).To(BeEmpty())
+		})
+
+		Context("and it has pods", func() {
+
+			BeforeEach(func() {
+				for _, pod := range []*corev1.Pod{
+					deploymentPod("baldur-space-foo-random-5d8f7c-x2b4q", "1"),
+					deploymentPod("baldur-space-foo-random-5d8f7c-9fz2k", "0"),
+					deploymentPod("baldur-space-foo-random-5d8f7c-22467", ""),
+				} {
+					_, createErr := client.CoreV1().Pods(namespace).Create(pod)
+					Expect(createErr).ToNot(HaveOccurred())
+				}
+			})
+
+			It("should report the instances which have an index", func() {
+				instances, getErr := deploymentDesirer.GetInstances(lrp.LRPIdentifier)
+				Expect(getErr).ToNot(HaveOccurred())
+				Expect(instances).To(ConsistOf(toInstance(0, 0), toInstance(1, 0)))
+			})
+
+			It("should stop an instance by its index", func() {
+				Expect(deploymentDesirer.StopInstance(lrp.LRPIdentifier, 1)).To(Succeed())
+
+				_, getErr := client.CoreV1().Pods(namespace).Get("baldur-space-foo-random-5d8f7c-x2b4q", meta.GetOptions{})
+				Expect(getErr).To(HaveOccurred())
+			})
+
+			It("should fail to stop an instance which does not exist", func() {
+				err = deploymentDesirer.StopInstance(lrp.LRPIdentifier, 7)
+				Expect(opi.IsNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("When the LRP does not exist", func() {
+		It("should return a not found error", func() {
+			_, err = deploymentDesirer.Get(lrp.LRPIdentifier)
+			Expect(opi.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
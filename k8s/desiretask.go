@@ -1,20 +1,30 @@
 package k8s
 
 import (
+	"fmt"
+
 	"code.cloudfoundry.org/eirini"
 	"code.cloudfoundry.org/eirini/opi"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	batch "k8s.io/api/batch/v1"
 	"k8s.io/client-go/kubernetes"
+	types "k8s.io/client-go/kubernetes/typed/batch/v1"
 )
 
 const (
+	// ActiveDeadlineSeconds is the time staging may take before it is failed
 	ActiveDeadlineSeconds = 900
-	stagingSourceType     = "STG"
+	TaskSourceType        = "TASK"
+	StagingSourceType     = "STG"
 	parallelism           = 1
 	completions           = 1
+	backoffLimit          = 0
+
+	oomKilledReason = "OOMKilled"
+	jobFailedReason = "job failed"
 )
 
 type TaskDesirer struct {
@@ -22,41 +32,223 @@ type TaskDesirer struct {
 	CCUploaderIP    string
 	CertsSecretName string
 	Client          kubernetes.Interface
+	// Namespacer picks the namespace of tasks when set. Staging jobs mount
+	// the CC certs secret, so they always run in Namespace.
+	Namespacer Namespacer
+	Security   SecurityPolicy
+	CPU        CPUPolicy
+	// SecurityGroups restricts the egress of tasks and staging jobs when
+	// set
+	SecurityGroups SecurityGroupSyncer
 }
 
 func (d *TaskDesirer) Desire(task *opi.Task) error {
-	job := toJob(task)
+	// tasks may run for as long as they need, like on Diego
+	job := toJob(task.GUID, task.AppGUID, task.SpaceGUID, TaskSourceType)
+	job.Annotations[eirini.CompletionCallback] = task.CompletionCallback
 
 	containers := []v1.Container{
 		{
 			Name:            "opi-task",
 			Image:           task.Image,
 			ImagePullPolicy: v1.PullAlways,
+			Command:         task.Command,
 			Env:             MapToEnvVar(task.Env),
+			Resources:       d.taskResources(task),
 		},
 	}
 
 	job.Spec.Template.Spec.Containers = containers
+	d.Security.Apply(&job.Spec.Template)
+
+	namespace, err := ensureNamespace(d.Namespacer, d.Namespace, task.OrgGUID, task.SpaceGUID)
+	if err != nil {
+		return err
+	}
+	if err = restrictEgress(d.SecurityGroups, namespace, task.SpaceGUID, TaskSourceType); err != nil {
+		return err
+	}
 
-	_, err := d.Client.BatchV1().Jobs(d.Namespace).Create(job)
-	return err
+	_, err = d.jobs(namespace).Create(job)
+	return ToOpiError(err, "failed to create job")
 }
 
 func (d *TaskDesirer) DesireStaging(task *opi.StagingTask) error {
 	job := d.toStagingJob(task)
-	_, err := d.Client.BatchV1().Jobs(d.Namespace).Create(job)
-	return err
+	if err := restrictEgress(d.SecurityGroups, d.Namespace, task.SpaceGUID, StagingSourceType); err != nil {
+		return err
+	}
+	_, err := d.jobs(d.Namespace).Create(job)
+	return ToOpiError(err, "failed to create staging job")
+}
+
+func (d *TaskDesirer) Get(name string) (*opi.TaskStatus, error) {
+	job, err := d.getJob(name)
+	if err != nil {
+		return nil, err
+	}
+
+	status := toTaskStatus(job)
+	if status.State == opi.TaskFailedState {
+		status.FailureReason = GetJobFailureReason(d.Client, job)
+	}
+
+	pods, err := d.Client.CoreV1().Pods(job.Namespace).List(meta_v1.ListOptions{LabelSelector: fmt.Sprintf("job-name=%s", name)})
+	if err != nil {
+		return nil, ToOpiError(err, "failed to list job pods")
+	}
+
+	if pod := latestPod(pods.Items); pod != nil {
+		status.Containers = toContainerStatuses(pod)
+	}
+
+	return status, nil
 }
 
 func (d *TaskDesirer) Delete(name string) error {
+	job, err := d.getJob(name)
+	if err != nil {
+		return err
+	}
+
 	backgroundPropagation := meta_v1.DeletePropagationBackground
-	return d.Client.BatchV1().Jobs(d.Namespace).Delete(name, &meta_v1.DeleteOptions{
+	err = d.jobs(job.Namespace).Delete(name, &meta_v1.DeleteOptions{
 		PropagationPolicy: &backgroundPropagation,
 	})
+	return ToOpiError(err, "failed to delete job")
+}
+
+// getJob finds a job by name, searching all namespaces when tasks are
+// spread over several of them
+func (d *TaskDesirer) getJob(name string) (*batch.Job, error) {
+	namespace := watchNamespace(d.Namespacer, d.Namespace)
+	if namespace != meta_v1.NamespaceAll {
+		job, err := d.jobs(namespace).Get(name, meta_v1.GetOptions{})
+		if err != nil {
+			return nil, ToOpiError(err, "failed to get job")
+		}
+		return job, nil
+	}
+
+	jobs, err := d.jobs(namespace).List(meta_v1.ListOptions{FieldSelector: fmt.Sprintf("metadata.name=%s", name)})
+	if err != nil {
+		return nil, ToOpiError(err, "failed to list jobs")
+	}
+	for i := range jobs.Items {
+		if jobs.Items[i].Name == name {
+			return &jobs.Items[i], nil
+		}
+	}
+	return nil, opi.NewNotFoundError("job %s not found", name)
+}
+
+func (d *TaskDesirer) jobs(namespace string) types.JobInterface {
+	return d.Client.BatchV1().Jobs(namespace)
+}
+
+func toTaskStatus(job *batch.Job) *opi.TaskStatus {
+	status := &opi.TaskStatus{
+		GUID:               job.Name,
+		State:              opi.TaskPendingState,
+		CompletionCallback: job.Annotations[eirini.CompletionCallback],
+	}
+
+	if job.Status.Active > 0 {
+		status.State = opi.TaskRunningState
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type {
+		case batch.JobComplete:
+			status.State = opi.TaskSucceededState
+		case batch.JobFailed:
+			status.State = opi.TaskFailedState
+		}
+	}
+
+	return status
+}
+
+func latestPod(pods []v1.Pod) *v1.Pod {
+	var latest *v1.Pod
+	for i := range pods {
+		if latest == nil || latest.CreationTimestamp.Before(&pods[i].CreationTimestamp) {
+			latest = &pods[i]
+		}
+	}
+	return latest
+}
+
+func toContainerStatuses(pod *v1.Pod) []opi.TaskContainerStatus {
+	containers := []v1.Container{}
+	containers = append(containers, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+
+	statuses := map[string]v1.ContainerStatus{}
+	for _, status := range pod.Status.InitContainerStatuses {
+		statuses[status.Name] = status
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	result := []opi.TaskContainerStatus{}
+	for _, container := range containers {
+		result = append(result, toContainerStatus(container.Name, statuses[container.Name].State))
+	}
+	return result
+}
+
+func toContainerStatus(name string, state v1.ContainerState) opi.TaskContainerStatus {
+	status := opi.TaskContainerStatus{Name: name, State: opi.TaskPendingState}
+
+	switch {
+	case state.Running != nil:
+		status.State = opi.TaskRunningState
+	case state.Terminated != nil:
+		status.State = opi.TaskSucceededState
+		if state.Terminated.ExitCode != 0 {
+			status.State = opi.TaskFailedState
+		}
+		status.Reason = state.Terminated.Reason
+		status.ExitCode = state.Terminated.ExitCode
+	case state.Waiting != nil:
+		status.Reason = state.Waiting.Reason
+	}
+
+	return status
+}
+
+func (d *TaskDesirer) taskResources(task *opi.Task) v1.ResourceRequirements {
+	resources := v1.ResourceRequirements{
+		Limits:   v1.ResourceList{},
+		Requests: v1.ResourceList{},
+	}
+
+	if task.MemoryMB > 0 {
+		memory := resource.MustParse(fmt.Sprintf("%dM", task.MemoryMB))
+		resources.Limits[v1.ResourceMemory] = memory
+		resources.Requests[v1.ResourceMemory] = memory
+	}
+
+	if task.DiskMB > 0 {
+		disk := resource.MustParse(fmt.Sprintf("%dM", task.DiskMB))
+		resources.Limits[v1.ResourceEphemeralStorage] = disk
+	}
+
+	d.CPU.apply(&resources, task.MemoryMB, 0)
+	return resources
 }
 
 func (d *TaskDesirer) toStagingJob(task *opi.StagingTask) *batch.Job {
-	job := toJob(task.Task)
+	job := toJob(task.Env[eirini.EnvStagingGUID], task.Env[eirini.EnvAppID], task.SpaceGUID, StagingSourceType)
+	job.Annotations[eirini.CompletionCallback] = task.Env[eirini.EnvCompletionCallback]
+	// staging is time-limited, unlike tasks
+	job.Spec.ActiveDeadlineSeconds = int64ptr(ActiveDeadlineSeconds)
 
 	job.Spec.Template.Spec.HostAliases = []v1.HostAlias{
 		{
@@ -97,6 +289,10 @@ func (d *TaskDesirer) toStagingJob(task *opi.StagingTask) *batch.Job {
 	executorVolumeMounts = append(executorVolumeMounts, buildpacksVolumeMount, workspaceVolumeMount, outputVolumeMount)
 	uploaderVolumeMounts = append(uploaderVolumeMounts, secretsVolumeMount, outputVolumeMount)
 
+	// every container gets the whole staging disk limit, as the limit of the
+	// pod is the largest of its init containers and its uploader
+	resources := d.taskResources(task.Task)
+
 	initContainers := []v1.Container{
 		{
 			Name:            "opi-task-downloader",
@@ -104,6 +300,7 @@ func (d *TaskDesirer) toStagingJob(task *opi.StagingTask) *batch.Job {
 			ImagePullPolicy: v1.PullAlways,
 			Env:             MapToEnvVar(task.Env),
 			VolumeMounts:    downloaderVolumeMounts,
+			Resources:       resources,
 		},
 		{
 			Name:            "opi-task-executor",
@@ -111,6 +308,7 @@ func (d *TaskDesirer) toStagingJob(task *opi.StagingTask) *batch.Job {
 			ImagePullPolicy: v1.PullAlways,
 			Env:             MapToEnvVar(task.Env),
 			VolumeMounts:    executorVolumeMounts,
+			Resources:       resources,
 		},
 	}
 
@@ -121,6 +319,7 @@ func (d *TaskDesirer) toStagingJob(task *opi.StagingTask) *batch.Job {
 			ImagePullPolicy: v1.PullAlways,
 			Env:             MapToEnvVar(task.Env),
 			VolumeMounts:    uploaderVolumeMounts,
+			Resources:       resources,
 		},
 	}
 
@@ -129,6 +328,7 @@ func (d *TaskDesirer) toStagingJob(task *opi.StagingTask) *batch.Job {
 
 	volumes := []v1.Volume{secretsVolume, outputVolume, buildpacksVolume, workspaceVolume}
 	job.Spec.Template.Spec.Volumes = volumes
+	d.Security.applyToStaging(&job.Spec.Template)
 
 	return job
 }
@@ -146,13 +346,13 @@ func getVolume(name, path string) (v1.Volume, v1.VolumeMount) {
 	return vol, mount
 }
 
-func toJob(task *opi.Task) *batch.Job {
+func toJob(name, appGUID, spaceGUID, sourceType string) *batch.Job {
 	automountServiceAccountToken := false
 	job := &batch.Job{
 		Spec: batch.JobSpec{
-			ActiveDeadlineSeconds: int64ptr(ActiveDeadlineSeconds),
-			Parallelism:           int32ptr(parallelism),
-			Completions:           int32ptr(completions),
+			Parallelism:  int32ptr(parallelism),
+			Completions:  int32ptr(completions),
+			BackoffLimit: int32ptr(backoffLimit),
 			Template: v1.PodTemplateSpec{
 				Spec: v1.PodSpec{
 					AutomountServiceAccountToken: &automountServiceAccountToken,
@@ -162,12 +362,14 @@ func toJob(task *opi.Task) *batch.Job {
 		},
 	}
 
-	job.Name = task.Env[eirini.EnvStagingGUID]
+	job.Name = name
+	job.Annotations = map[string]string{}
 
 	labels := map[string]string{
-		"guid":        task.Env[eirini.EnvAppID],
-		"source_type": stagingSourceType,
+		"guid":        appGUID,
+		"source_type": sourceType,
 	}
+	setSpaceLabel(labels, spaceGUID)
 
 	job.Spec.Template.Labels = labels
 	job.Labels = labels
@@ -1,8 +1,11 @@
 package k8s_test
 
 import (
+	"errors"
+
 	"code.cloudfoundry.org/eirini"
 	. "code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/eirini/k8s/k8sfakes"
 	"code.cloudfoundry.org/eirini/opi"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -38,6 +41,7 @@ var _ = Describe("Desiretask", func() {
 		automountServiceAccountToken := false
 		Expect(job.Name).To(Equal("the-stage-is-yours"))
 		Expect(job.Spec.ActiveDeadlineSeconds).To(Equal(int64ptr(900)))
+		Expect(*job.Spec.BackoffLimit).To(Equal(int32(0)))
 		Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(v1.RestartPolicyNever))
 		Expect(job.Spec.Template.Labels).To(Equal(labels))
 		Expect(job.Labels).To(Equal(labels))
@@ -82,6 +86,15 @@ var _ = Describe("Desiretask", func() {
 
 	Context("When desiring a task", func() {
 
+		BeforeEach(func() {
+			task.GUID = "the-task-guid"
+			task.AppGUID = "task-app-id"
+			task.Command = []string{"dumb-init", "--", "/lifecycle/launch"}
+			task.MemoryMB = 256
+			task.DiskMB = 1024
+			task.CompletionCallback = "example.com/call/me/maybe"
+		})
+
 		JustBeforeEach(func() {
 			err = desirer.Desire(task)
 		})
@@ -90,15 +103,56 @@ var _ = Describe("Desiretask", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("should not limit how long the task may run", func() {
+			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-task-guid", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(job.Spec.ActiveDeadlineSeconds).To(BeNil())
+		})
+
 		It("should desire the task", func() {
-			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-stage-is-yours", meta_v1.GetOptions{})
+			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-task-guid", meta_v1.GetOptions{})
 			Expect(getErr).ToNot(HaveOccurred())
 
-			assertGeneralSpec(job)
+			labels := map[string]string{
+				"guid":        "task-app-id",
+				"source_type": "TASK",
+			}
+			Expect(job.Labels).To(Equal(labels))
+			Expect(job.Spec.Template.Labels).To(Equal(labels))
+			Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(v1.RestartPolicyNever))
 
 			containers := job.Spec.Template.Spec.Containers
 			Expect(containers).To(HaveLen(1))
 			assertContainer(containers[0], "opi-task")
+			Expect(containers[0].Command).To(Equal([]string{"dumb-init", "--", "/lifecycle/launch"}))
+		})
+
+		It("should not retry the task", func() {
+			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-task-guid", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(*job.Spec.BackoffLimit).To(Equal(int32(0)))
+		})
+
+		It("should store the completion callback", func() {
+			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-task-guid", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(job.Annotations).To(HaveKeyWithValue("completion_callback", "example.com/call/me/maybe"))
+		})
+
+		It("should run the task as vcap", func() {
+			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-task-guid", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(*job.Spec.Template.Spec.SecurityContext.RunAsUser).To(Equal(int64(VcapUID)))
+		})
+
+		It("should set the memory and disk limits", func() {
+			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-task-guid", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+
+			resources := job.Spec.Template.Spec.Containers[0].Resources
+			Expect(resources.Limits.Memory().String()).To(Equal("256M"))
+			Expect(resources.Requests.Memory().String()).To(Equal("256M"))
+			Expect(resources.Limits.StorageEphemeral().String()).To(Equal("1024M"))
 		})
 
 		Context("and the job already exists", func() {
@@ -113,6 +167,123 @@ var _ = Describe("Desiretask", func() {
 		})
 	})
 
+	Context("When getting a task", func() {
+
+		var (
+			job    *batch.Job
+			status *opi.TaskStatus
+		)
+
+		BeforeEach(func() {
+			job = &batch.Job{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name: "the-task-guid",
+					Annotations: map[string]string{
+						"completion_callback": "example.com/call/me/maybe",
+					},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			_, createErr := fakeClient.BatchV1().Jobs(Namespace).Create(job)
+			Expect(createErr).ToNot(HaveOccurred())
+
+			status, err = desirer.Get("the-task-guid")
+		})
+
+		It("should return a pending task", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(&opi.TaskStatus{
+				GUID:               "the-task-guid",
+				State:              opi.TaskPendingState,
+				CompletionCallback: "example.com/call/me/maybe",
+			}))
+		})
+
+		Context("and the job has active pods", func() {
+			BeforeEach(func() {
+				job.Status.Active = 1
+
+				pod := &v1.Pod{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:   "the-task-guid-abcde",
+						Labels: map[string]string{"job-name": "the-task-guid"},
+					},
+					Spec: v1.PodSpec{
+						InitContainers: []v1.Container{{Name: "opi-task-downloader"}, {Name: "opi-task-executor"}},
+						Containers:     []v1.Container{{Name: "opi-task-uploader"}},
+					},
+					Status: v1.PodStatus{
+						InitContainerStatuses: []v1.ContainerStatus{
+							{
+								Name:  "opi-task-downloader",
+								State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"}},
+							},
+							{
+								Name:  "opi-task-executor",
+								State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+							},
+						},
+						ContainerStatuses: []v1.ContainerStatus{
+							{
+								Name:  "opi-task-uploader",
+								State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "PodInitializing"}},
+							},
+						},
+					},
+				}
+				_, createErr := fakeClient.CoreV1().Pods(Namespace).Create(pod)
+				Expect(createErr).ToNot(HaveOccurred())
+			})
+
+			It("should return a running task", func() {
+				Expect(status.State).To(Equal(opi.TaskRunningState))
+			})
+
+			It("should return the state of each container", func() {
+				Expect(status.Containers).To(Equal([]opi.TaskContainerStatus{
+					{Name: "opi-task-downloader", State: opi.TaskSucceededState, Reason: "Completed"},
+					{Name: "opi-task-executor", State: opi.TaskRunningState},
+					{Name: "opi-task-uploader", State: opi.TaskPendingState, Reason: "PodInitializing"},
+				}))
+			})
+		})
+
+		Context("and the job has completed", func() {
+			BeforeEach(func() {
+				job.Status.Conditions = []batch.JobCondition{
+					{Type: batch.JobComplete, Status: v1.ConditionTrue},
+				}
+			})
+
+			It("should return a succeeded task", func() {
+				Expect(status.State).To(Equal(opi.TaskSucceededState))
+			})
+		})
+
+		Context("and the job has failed", func() {
+			BeforeEach(func() {
+				job.Status.Failed = 1
+				job.Status.Conditions = []batch.JobCondition{
+					{Type: batch.JobFailed, Status: v1.ConditionTrue, Reason: "DeadlineExceeded", Message: "Job was active longer than specified deadline"},
+				}
+			})
+
+			It("should return a failed task with the failure reason", func() {
+				Expect(status.State).To(Equal(opi.TaskFailedState))
+				Expect(status.FailureReason).To(Equal("DeadlineExceeded: Job was active longer than specified deadline"))
+			})
+		})
+	})
+
+	Context("When getting a task that does not exist", func() {
+		It("should return an error", func() {
+			_, err = desirer.Get("not-there")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Context("When desiring a staging task", func() {
 
 		var stagingTask *opi.StagingTask
@@ -259,6 +430,57 @@ var _ = Describe("Desiretask", func() {
 			assertStagingSpec(job)
 		})
 
+		It("should store the completion callback", func() {
+			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-stage-is-yours", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(job.Annotations).To(HaveKeyWithValue(eirini.CompletionCallback, "example.com/call/me/maybe"))
+		})
+
+		It("should run every staging container as vcap without privileges", func() {
+			job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-stage-is-yours", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+
+			spec := job.Spec.Template.Spec
+			Expect(*spec.SecurityContext.RunAsUser).To(Equal(int64(VcapUID)))
+			for _, container := range append(spec.InitContainers, spec.Containers...) {
+				Expect(*container.SecurityContext.AllowPrivilegeEscalation).To(BeFalse())
+			}
+		})
+
+		Context("When the staging task has a disk limit", func() {
+			BeforeEach(func() {
+				stagingTask.DiskMB = 4096
+			})
+
+			It("should limit the ephemeral storage of every staging container", func() {
+				job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-stage-is-yours", meta_v1.GetOptions{})
+				Expect(getErr).ToNot(HaveOccurred())
+
+				spec := job.Spec.Template.Spec
+				for _, container := range append(spec.InitContainers, spec.Containers...) {
+					Expect(container.Resources.Limits.StorageEphemeral().String()).To(Equal("4096M"))
+				}
+			})
+		})
+
+		Context("When CPU is entitled in proportion to memory", func() {
+			BeforeEach(func() {
+				desirer.(*TaskDesirer).CPU = CPUPolicy{Type: CPUPolicyMemory, MillicoresPerGB: 1000}
+				stagingTask.MemoryMB = 512
+			})
+
+			It("should request CPU for every staging container", func() {
+				job, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-stage-is-yours", meta_v1.GetOptions{})
+				Expect(getErr).ToNot(HaveOccurred())
+
+				spec := job.Spec.Template.Spec
+				for _, container := range append(spec.InitContainers, spec.Containers...) {
+					Expect(container.Resources.Requests.Cpu().String()).To(Equal("500m"))
+					Expect(container.Resources.Limits).ToNot(HaveKey(v1.ResourceCPU))
+				}
+			})
+		})
+
 		Context("When the staging task already exists", func() {
 			BeforeEach(func() {
 				err = desirer.DesireStaging(stagingTask)
@@ -279,6 +501,7 @@ var _ = Describe("Desiretask", func() {
 
 		Context("that already exists", func() {
 			BeforeEach(func() {
+				task.GUID = "the-stage-is-yours"
 				err = desirer.Desire(task)
 				Expect(err).ToNot(HaveOccurred())
 			})
@@ -301,6 +524,130 @@ var _ = Describe("Desiretask", func() {
 		})
 
 	})
+
+	Context("When tasks are spread over namespaces", func() {
+
+		const spaceNamespace = "tests-space-the-space-guid"
+
+		var namespacer *k8sfakes.FakeNamespacer
+
+		BeforeEach(func() {
+			namespacer = new(k8sfakes.FakeNamespacer)
+			namespacer.EnsureNamespaceReturns(spaceNamespace, nil)
+			namespacer.WatchNamespaceReturns(meta_v1.NamespaceAll)
+			desirer.(*TaskDesirer).Namespacer = namespacer
+
+			task.GUID = "the-task-guid"
+			task.OrgGUID = "the-org-guid"
+			task.SpaceGUID = "the-space-guid"
+			Expect(desirer.Desire(task)).To(Succeed())
+		})
+
+		It("should create the job in the namespace of the space", func() {
+			orgGUID, spaceGUID := namespacer.EnsureNamespaceArgsForCall(0)
+			Expect(orgGUID).To(Equal("the-org-guid"))
+			Expect(spaceGUID).To(Equal("the-space-guid"))
+
+			_, getErr := fakeClient.BatchV1().Jobs(spaceNamespace).Get("the-task-guid", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+		})
+
+		It("should label the pods of the job with the space", func() {
+			job, getErr := fakeClient.BatchV1().Jobs(spaceNamespace).Get("the-task-guid", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(job.Spec.Template.Labels).To(HaveKeyWithValue(SpaceGUIDLabel, "the-space-guid"))
+		})
+
+		It("should find the task by its name", func() {
+			status, getErr := desirer.Get("the-task-guid")
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(status.GUID).To(Equal("the-task-guid"))
+		})
+
+		It("should delete the task in its namespace", func() {
+			Expect(desirer.Delete("the-task-guid")).To(Succeed())
+
+			_, getErr := fakeClient.BatchV1().Jobs(spaceNamespace).Get("the-task-guid", meta_v1.GetOptions{})
+			Expect(getErr).To(HaveOccurred())
+		})
+
+		It("should not find tasks which do not exist", func() {
+			_, getErr := desirer.Get("some-other-task")
+			Expect(opi.IsNotFound(getErr)).To(BeTrue())
+		})
+
+		It("should keep staging jobs in the default namespace", func() {
+			Expect(desirer.DesireStaging(&opi.StagingTask{Task: task})).To(Succeed())
+
+			_, getErr := fakeClient.BatchV1().Jobs(Namespace).Get("the-stage-is-yours", meta_v1.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("When security groups are enforced", func() {
+
+		var (
+			securityGroups *k8sfakes.FakeSecurityGroupSyncer
+			jobsBefore     []int
+		)
+
+		BeforeEach(func() {
+			jobsBefore = nil
+			securityGroups = new(k8sfakes.FakeSecurityGroupSyncer)
+			securityGroups.SpaceDesiredStub = func(namespace, _, _ string) error {
+				jobs, listErr := fakeClient.BatchV1().Jobs(namespace).List(meta_v1.ListOptions{})
+				Expect(listErr).ToNot(HaveOccurred())
+				jobsBefore = append(jobsBefore, len(jobs.Items))
+				return nil
+			}
+			desirer.(*TaskDesirer).SecurityGroups = securityGroups
+
+			task.GUID = "the-task-guid"
+			task.SpaceGUID = "the-space-guid"
+		})
+
+		It("should restrict the egress of a task before its job exists", func() {
+			Expect(desirer.Desire(task)).To(Succeed())
+
+			Expect(securityGroups.SpaceDesiredCallCount()).To(Equal(1))
+			namespace, spaceGUID, sourceType := securityGroups.SpaceDesiredArgsForCall(0)
+			Expect(namespace).To(Equal(Namespace))
+			Expect(spaceGUID).To(Equal("the-space-guid"))
+			Expect(sourceType).To(Equal(TaskSourceType))
+			Expect(jobsBefore).To(Equal([]int{0}))
+		})
+
+		It("should restrict the egress of staging before its job exists", func() {
+			Expect(desirer.DesireStaging(&opi.StagingTask{Task: task})).To(Succeed())
+
+			Expect(securityGroups.SpaceDesiredCallCount()).To(Equal(1))
+			_, spaceGUID, sourceType := securityGroups.SpaceDesiredArgsForCall(0)
+			Expect(spaceGUID).To(Equal("the-space-guid"))
+			Expect(sourceType).To(Equal(StagingSourceType))
+			Expect(jobsBefore).To(Equal([]int{0}))
+		})
+
+		It("should not restrict the egress of a task without a space", func() {
+			task.SpaceGUID = ""
+			Expect(desirer.Desire(task)).To(Succeed())
+			Expect(securityGroups.SpaceDesiredCallCount()).To(Equal(0))
+		})
+
+		Context("and the egress cannot be restricted", func() {
+			BeforeEach(func() {
+				securityGroups.SpaceDesiredStub = nil
+				securityGroups.SpaceDesiredReturns(errors.New("boom"))
+			})
+
+			It("should not create the job", func() {
+				Expect(desirer.Desire(task)).ToNot(Succeed())
+
+				jobs, listErr := fakeClient.BatchV1().Jobs(Namespace).List(meta_v1.ListOptions{})
+				Expect(listErr).ToNot(HaveOccurred())
+				Expect(jobs.Items).To(BeEmpty())
+			})
+		})
+	})
 })
 
 func int64ptr(i int) *int64 {
@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"sort"
+
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/opi"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The sensitive environment of an app is kept in a Secret next to its
+// workload, which is owned by the workload and referenced by the container
+// env, so that reading the workload does not reveal any credentials
+
+func envSecretName(workloadName string) string {
+	return workloadName + "-env"
+}
+
+func splitSensitiveEnv(env map[string]string) (plain, sensitive map[string]string) {
+	plain, sensitive = map[string]string{}, map[string]string{}
+	for name, value := range env {
+		if eirini.IsSensitiveEnvVar(name) {
+			sensitive[name] = value
+			continue
+		}
+		plain[name] = value
+	}
+	return plain, sensitive
+}
+
+// toAppEnvVars returns the env of an app container. Sensitive variables are
+// read from the env secret, including the ones the kept env already reads
+// from it, as updates without an environment do not carry them.
+func toAppEnvVars(env map[string]string, secretName string, kept []corev1.EnvVar) []corev1.EnvVar {
+	plain, sensitive := splitSensitiveEnv(env)
+	for _, envVar := range kept {
+		if isEnvSecretRef(envVar, secretName) {
+			sensitive[envVar.Name] = ""
+		}
+	}
+
+	names := make([]string, 0, len(sensitive))
+	for name := range sensitive {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	envVars := toEnvVars(plain)
+	for _, name := range names {
+		envVars = append(envVars, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  name,
+				},
+			},
+		})
+	}
+	return envVars
+}
+
+func isEnvSecretRef(envVar corev1.EnvVar, secretName string) bool {
+	return envVar.ValueFrom != nil &&
+		envVar.ValueFrom.SecretKeyRef != nil &&
+		envVar.ValueFrom.SecretKeyRef.Name == secretName
+}
+
+// applyEnvSecret writes the sensitive variables of an LRP to the env secret
+// of its workload, and removes the ones it no longer has, unless the LRP
+// keeps them.
+func applyEnvSecret(client kubernetes.Interface, namespace, workloadName string, lrp *opi.LRP, owner *meta.OwnerReference) error {
+	_, sensitive := splitSensitiveEnv(lrp.Env)
+	if len(sensitive) == 0 && owner == nil {
+		return nil
+	}
+
+	secrets := client.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get(envSecretName(workloadName), meta.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if len(sensitive) == 0 {
+			return nil
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: envSecretName(workloadName)},
+			Type:       corev1.SecretTypeOpaque,
+		}
+		setEnvSecretData(secret, sensitive, false, owner)
+		_, err = secrets.Create(secret)
+		return ToOpiError(err, "failed to create env secret")
+	}
+	if err != nil {
+		return ToOpiError(err, "failed to get env secret")
+	}
+
+	setEnvSecretData(secret, sensitive, lrp.KeepSensitiveEnv, owner)
+	_, err = secrets.Update(secret)
+	return ToOpiError(err, "failed to update env secret")
+}
+
+func setEnvSecretData(secret *corev1.Secret, sensitive map[string]string, keep bool, owner *meta.OwnerReference) {
+	if secret.Data == nil || !keep {
+		secret.Data = map[string][]byte{}
+	}
+	for name, value := range sensitive {
+		secret.Data[name] = []byte(value)
+	}
+	if owner != nil {
+		secret.OwnerReferences = []meta.OwnerReference{*owner}
+	}
+}
+
+// envSecretMatches tells whether the env secret of a workload holds exactly
+// the sensitive variables of env
+func envSecretMatches(client kubernetes.Interface, namespace, workloadName string, env map[string]string) (bool, error) {
+	_, sensitive := splitSensitiveEnv(env)
+	secret, err := client.CoreV1().Secrets(namespace).Get(envSecretName(workloadName), meta.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return len(sensitive) == 0, nil
+	}
+	if err != nil {
+		return false, ToOpiError(err, "failed to get env secret")
+	}
+
+	if len(secret.Data) != len(sensitive) {
+		return false, nil
+	}
+	for name, value := range sensitive {
+		stored, ok := secret.Data[name]
+		if !ok || string(stored) != value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func deleteEnvSecret(client kubernetes.Interface, namespace, workloadName string) error {
+	err := client.CoreV1().Secrets(namespace).Delete(envSecretName(workloadName), nil)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return ToOpiError(err, "failed to delete env secret")
+}
+
+// ownerReference lets the env secret be garbage collected with its workload
+func ownerReference(kind string, owner meta.ObjectMeta) *meta.OwnerReference {
+	return &meta.OwnerReference{
+		APIVersion: appsv1.SchemeGroupVersion.String(),
+		Kind:       kind,
+		Name:       owner.Name,
+		UID:        owner.UID,
+	}
+}
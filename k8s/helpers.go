@@ -2,8 +2,14 @@ package k8s
 
 import (
 	"fmt"
+	"net"
+	"strings"
 
+	"code.cloudfoundry.org/eirini/opi"
+	"github.com/pkg/errors"
+	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -41,3 +47,111 @@ func IsStopped(eventList *v1.EventList) bool {
 	event := events[len(events)-1]
 	return event.Reason == eventKilling
 }
+
+func GetJobFailureReason(client kubernetes.Interface, job *batch.Job) string {
+	pods, err := client.CoreV1().Pods(job.Namespace).List(meta.ListOptions{LabelSelector: fmt.Sprintf("job-name=%s", job.Name)})
+	if err == nil {
+		for _, pod := range pods.Items {
+			if reason := podFailureReason(pod); reason != "" {
+				return reason
+			}
+		}
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batch.JobFailed && condition.Status == v1.ConditionTrue {
+			return fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+		}
+	}
+
+	return jobFailedReason
+}
+
+const (
+	// DiskQuotaExceededReason is the crash reason of instances which were
+	// evicted for exceeding their disk quota
+	DiskQuotaExceededReason = "DiskQuotaExceeded"
+	evictedReason           = "Evicted"
+)
+
+// diskQuotaExceededMessages are the eviction messages of the kubelet for
+// pods and containers which exceed their ephemeral storage limits
+var diskQuotaExceededMessages = []string{
+	"ephemeral local storage usage exceeds the total limit",
+	"exceeded its local ephemeral storage limit",
+	"Usage of EmptyDir volume",
+}
+
+// IsDiskQuotaExceeded tells whether the kubelet evicted a pod because it
+// used more ephemeral storage than its limits, rather than because its node
+// ran low on disk
+func IsDiskQuotaExceeded(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodFailed || pod.Status.Reason != evictedReason {
+		return false
+	}
+	for _, message := range diskQuotaExceededMessages {
+		if strings.Contains(pod.Status.Message, message) {
+			return true
+		}
+	}
+	return false
+}
+
+func podFailureReason(pod v1.Pod) string {
+	statuses := []v1.ContainerStatus{}
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		terminated := status.State.Terminated
+		if terminated == nil || terminated.ExitCode == 0 {
+			continue
+		}
+
+		if terminated.Reason == oomKilledReason {
+			return fmt.Sprintf("%s was killed because it ran out of memory", status.Name)
+		}
+
+		return fmt.Sprintf("%s exited with code %d: %s", status.Name, terminated.ExitCode, terminated.Reason)
+	}
+
+	if IsDiskQuotaExceeded(&pod) {
+		return fmt.Sprintf("pod was evicted because it exceeded its disk quota: %s", pod.Status.Message)
+	}
+
+	if pod.Status.Phase == v1.PodFailed && pod.Status.Reason != "" {
+		return fmt.Sprintf("%s: %s", pod.Status.Reason, pod.Status.Message)
+	}
+
+	return ""
+}
+
+// ToOpiError translates an error returned by the Kubernetes API into a typed
+// opi error, so that callers can react to it without knowing about Kubernetes
+func ToOpiError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case apierrors.IsNotFound(err):
+		return opi.NewNotFoundError("%s: %s", message, err.Error())
+	case apierrors.IsAlreadyExists(err):
+		return opi.NewAlreadyExistsError("%s: %s", message, err.Error())
+	case apierrors.IsConflict(err):
+		return opi.NewConflictError("%s: %s", message, err.Error())
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return opi.NewInvalidError("%s: %s", message, err.Error())
+	case apierrors.IsServerTimeout(err), apierrors.IsTimeout(err), apierrors.IsServiceUnavailable(err),
+		apierrors.IsTooManyRequests(err), apierrors.IsInternalError(err), isConnectionError(err):
+		return opi.NewBackendUnavailableError("%s: %s", message, err.Error())
+	default:
+		return errors.Wrap(err, message)
+	}
+}
+
+// isConnectionError tells whether the API server could not be reached,
+// e.g. *url.Error and *net.OpError, from errors of eirini itself
+func isConnectionError(err error) bool {
+	_, isNetError := errors.Cause(err).(net.Error)
+	return isNetError
+}
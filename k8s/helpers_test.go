@@ -1,9 +1,20 @@
 package k8s_test
 
 import (
+	"errors"
+	"net"
+	"net/url"
+
+	"code.cloudfoundry.org/eirini/opi"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 
 	. "code.cloudfoundry.org/eirini/k8s"
 )
@@ -43,4 +54,178 @@ var _ = Describe("Helpers", func() {
 			})
 		})
 	})
+
+	Context("Get job failure reason", func() {
+
+		var (
+			client kubernetes.Interface
+			job    *batch.Job
+			pod    *v1.Pod
+			reason string
+		)
+
+		BeforeEach(func() {
+			client = fake.NewSimpleClientset()
+			job = &batch.Job{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      "the-job",
+					Namespace: "tests",
+				},
+				Status: batch.JobStatus{
+					Conditions: []batch.JobCondition{
+						{
+							Type:    batch.JobFailed,
+							Status:  v1.ConditionTrue,
+							Reason:  "DeadlineExceeded",
+							Message: "Job was active longer than specified deadline",
+						},
+					},
+				},
+			}
+			pod = &v1.Pod{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      "the-job-abcde",
+					Namespace: "tests",
+					Labels:    map[string]string{"job-name": "the-job"},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			_, err := client.CoreV1().Pods("tests").Create(pod)
+			Expect(err).ToNot(HaveOccurred())
+
+			reason = GetJobFailureReason(client, job)
+		})
+
+		It("should fall back to the job failure condition", func() {
+			Expect(reason).To(Equal("DeadlineExceeded: Job was active longer than specified deadline"))
+		})
+
+		Context("when a container was OOM killed", func() {
+			BeforeEach(func() {
+				pod.Status.InitContainerStatuses = []v1.ContainerStatus{
+					{
+						Name: "opi-task-downloader",
+						State: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{ExitCode: 0},
+						},
+					},
+					{
+						Name: "opi-task-executor",
+						State: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"},
+						},
+					},
+				}
+			})
+
+			It("should report the out of memory container", func() {
+				Expect(reason).To(Equal("opi-task-executor was killed because it ran out of memory"))
+			})
+		})
+
+		Context("when a container exited with an error", func() {
+			BeforeEach(func() {
+				pod.Status.ContainerStatuses = []v1.ContainerStatus{
+					{
+						Name: "opi-task",
+						State: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{ExitCode: 2, Reason: "Error"},
+						},
+					},
+				}
+			})
+
+			It("should report the exit code", func() {
+				Expect(reason).To(Equal("opi-task exited with code 2: Error"))
+			})
+		})
+
+		Context("when the pod itself failed", func() {
+			BeforeEach(func() {
+				pod.Status.Phase = v1.PodFailed
+				pod.Status.Reason = "Evicted"
+				pod.Status.Message = "The node was low on resource: ephemeral-storage."
+			})
+
+			It("should report the pod failure", func() {
+				Expect(reason).To(Equal("Evicted: The node was low on resource: ephemeral-storage."))
+			})
+		})
+
+		Context("when the pod exceeded its disk quota", func() {
+			BeforeEach(func() {
+				pod.Status.Phase = v1.PodFailed
+				pod.Status.Reason = "Evicted"
+				pod.Status.Message = "Pod ephemeral local storage usage exceeds the total limit of containers 1G. "
+			})
+
+			It("should report the exceeded disk quota", func() {
+				Expect(reason).To(Equal("pod was evicted because it exceeded its disk quota: Pod ephemeral local storage usage exceeds the total limit of containers 1G. "))
+			})
+		})
+
+		Context("when the job has no failure condition", func() {
+			BeforeEach(func() {
+				job.Status.Conditions = nil
+			})
+
+			It("should return a generic reason", func() {
+				Expect(reason).To(Equal("job failed"))
+			})
+		})
+	})
+	Context("Convert Kubernetes errors to opi errors", func() {
+
+		resource := schema.GroupResource{Group: "apps", Resource: "statefulsets"}
+
+		assertOpiError := func(k8sErr error, matcher func(error) bool) {
+			err := ToOpiError(k8sErr, "failed to do the thing")
+			Expect(matcher(err)).To(BeTrue())
+			Expect(err.Error()).To(HavePrefix("failed to do the thing: "))
+		}
+
+		It("should map not found errors", func() {
+			assertOpiError(apierrors.NewNotFound(resource, "app"), opi.IsNotFound)
+		})
+
+		It("should map already exists errors", func() {
+			assertOpiError(apierrors.NewAlreadyExists(resource, "app"), opi.IsAlreadyExists)
+		})
+
+		It("should map conflict errors", func() {
+			assertOpiError(apierrors.NewConflict(resource, "app", errors.New("modified")), opi.IsConflict)
+		})
+
+		It("should map bad request errors", func() {
+			assertOpiError(apierrors.NewBadRequest("bad"), opi.IsInvalid)
+		})
+
+		It("should map unavailable and timeout errors", func() {
+			assertOpiError(apierrors.NewServiceUnavailable("down"), opi.IsBackendUnavailable)
+			assertOpiError(apierrors.NewTimeoutError("slow", 1), opi.IsBackendUnavailable)
+		})
+
+		It("should map connection errors", func() {
+			refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+			assertOpiError(&url.Error{Op: "Get", URL: "https://kube-api", Err: refused}, opi.IsBackendUnavailable)
+			assertOpiError(refused, opi.IsBackendUnavailable)
+		})
+
+		It("should not map other errors to connection errors", func() {
+			err := ToOpiError(errors.New("failed to marshal"), "failed")
+			Expect(opi.ReasonForError(err)).To(Equal(opi.ReasonUnknown))
+		})
+
+		It("should not change a nil error", func() {
+			Expect(ToOpiError(nil, "nothing")).To(BeNil())
+		})
+
+		It("should wrap errors with other reasons", func() {
+			err := ToOpiError(apierrors.NewForbidden(resource, "app", errors.New("nope")), "failed")
+			Expect(err).To(HaveOccurred())
+			Expect(opi.ReasonForError(err)).To(Equal(opi.ReasonUnknown))
+		})
+	})
 })
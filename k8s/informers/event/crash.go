@@ -1,15 +1,16 @@
 package event
 
 import (
+	"fmt"
 	"time"
 
 	"code.cloudfoundry.org/eirini/events"
 	"code.cloudfoundry.org/eirini/k8s"
 	"code.cloudfoundry.org/eirini/models/cf"
-	"code.cloudfoundry.org/eirini/util"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/runtimeschema/cc_messages"
 	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -44,11 +45,16 @@ func NewCrashInformer(
 	}
 }
 
+// Start watches the app pods only, as the namespace may be shared with
+// tasks, staging and other workloads, or be every namespace
 func (c *CrashInformer) Start() {
 	factory := informers.NewSharedInformerFactoryWithOptions(
 		c.clientset,
 		c.syncPeriod,
 		informers.WithNamespace(c.namespace),
+		informers.WithTweakListOptions(func(options *meta.ListOptions) {
+			options.LabelSelector = fmt.Sprintf("source_type=%s", k8s.AppSourceType)
+		}),
 	)
 
 	informer := factory.Core().V1().Pods().Informer()
@@ -59,13 +65,20 @@ func (c *CrashInformer) Start() {
 	informer.Run(c.stopperChan)
 }
 
-func (c *CrashInformer) updateFunc(_ interface{}, newObj interface{}) {
+func (c *CrashInformer) updateFunc(oldObj interface{}, newObj interface{}) {
 	pod := newObj.(*v1.Pod)
 	statuses := pod.Status.ContainerStatuses
 	if len(statuses) == 0 {
 		return
 	}
 
+	if k8s.IsDiskQuotaExceeded(pod) {
+		if !k8s.IsDiskQuotaExceeded(oldObj.(*v1.Pod)) {
+			c.reportDiskQuotaExceeded(pod)
+		}
+		return
+	}
+
 	terminated := pod.Status.ContainerStatuses[0].State.Terminated
 	if terminated != nil && terminated.ExitCode != 0 {
 		c.reportState(pod)
@@ -93,6 +106,19 @@ func (c *CrashInformer) reportState(pod *v1.Pod) {
 	c.sendStateReport(pod, terminated.Reason, int(terminated.ExitCode), terminated.Reason, int64(terminated.StartedAt.Second()))
 }
 
+// reportDiskQuotaExceeded reports evicted instances with a reason of their
+// own, as their containers may not have terminated with an exit code. It is
+// only called on the eviction itself, as evicted pods are still updated.
+func (c *CrashInformer) reportDiskQuotaExceeded(pod *v1.Pod) {
+	exitStatus := 0
+	crashTimestamp := int64(0)
+	if terminated := pod.Status.ContainerStatuses[0].State.Terminated; terminated != nil {
+		exitStatus = int(terminated.ExitCode)
+		crashTimestamp = int64(terminated.StartedAt.Second())
+	}
+	c.sendStateReport(pod, k8s.DiskQuotaExceededReason, exitStatus, pod.Status.Message, crashTimestamp)
+}
+
 func (c *CrashInformer) sendStateReport(
 	pod *v1.Pod,
 	reason string,
@@ -115,7 +141,7 @@ func toReport(
 	crashTimestamp int64,
 ) (events.CrashReport, error) {
 	container := pod.Status.ContainerStatuses[0]
-	index, err := util.ParseAppIndex(pod.Name)
+	index, err := k8s.PodIndex(pod)
 	if err != nil {
 		return events.CrashReport{}, err
 	}
@@ -31,6 +31,7 @@ var _ = Describe("Event", func() {
 		informerStopper chan struct{}
 
 		watcher               *watch.FakeWatcher
+		watchSelectors        chan string
 		pinky, brain, bandito *v1.Pod
 
 		crashTime meta.Time
@@ -48,8 +49,16 @@ var _ = Describe("Event", func() {
 		crashInformer = NewCrashInformer(client, 0, namespace, reportChan, informerStopper, lagertest.NewTestLogger("test-logger"))
 
 		watcher = watch.NewFake()
+		watchSelectors = make(chan string, 10)
 		fakecs := client.(*fake.Clientset)
-		fakecs.PrependWatchReactor("pods", testing.DefaultWatchReactor(watcher, nil))
+		fakecs.PrependWatchReactor("pods", func(action testing.Action) (bool, watch.Interface, error) {
+			watchSelectors <- action.(testing.WatchAction).GetWatchRestrictions().Labels.String()
+			return true, watcher, nil
+		})
+	})
+
+	It("should only watch app pods", func() {
+		Eventually(watchSelectors).Should(Receive(Equal("source_type=APP")))
 	})
 
 	AfterEach(func() {
@@ -140,6 +149,52 @@ var _ = Describe("Event", func() {
 			})
 		})
 
+		Context("was evicted for exceeding its disk quota", func() {
+			BeforeEach(func() {
+				pinkyCopy = createPod("pinky-pod")
+				pinkyCopy.Status.Phase = v1.PodFailed
+				pinkyCopy.Status.Reason = "Evicted"
+				pinkyCopy.Status.Message = "Container opi exceeded its local ephemeral storage limit \"1G\". "
+				pinkyCopy.Status.ContainerStatuses = []v1.ContainerStatus{
+					{
+						RestartCount: 1,
+						State: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "Error"},
+						},
+					},
+				}
+
+				brainCopy = createPod("brain-pod")
+				banditoCopy = createStatelessPod("bandito")
+				banditoCopy.Name = "no-bandito"
+			})
+
+			It("should report the exceeded disk quota", func() {
+				Eventually(reportChan).Should(Receive(Equal(events.CrashReport{
+					ProcessGUID: "pinky-pod-anno",
+					AppCrashedRequest: cc_messages.AppCrashedRequest{
+						Reason:          "DiskQuotaExceeded",
+						Instance:        "pinky-pod-0",
+						Index:           0,
+						ExitStatus:      137,
+						ExitDescription: "Container opi exceeded its local ephemeral storage limit \"1G\". ",
+						CrashCount:      1,
+					},
+				})))
+			})
+
+			It("should report the eviction once when the pod is updated again", func() {
+				Eventually(reportChan).Should(Receive())
+
+				pinkyUpdate := pinkyCopy.DeepCopy()
+				pinkyUpdate.ResourceVersion = "2"
+				watcher.Modify(pinkyUpdate)
+				watcher.Modify(pinkyUpdate.DeepCopy())
+
+				Consistently(reportChan).ShouldNot(Receive())
+			})
+		})
+
 		Context("has terminated status", func() {
 
 			BeforeEach(func() {
@@ -0,0 +1,162 @@
+package instance
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/lager"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const lrpIndex = "lrp"
+
+// IndexInformer annotates the pods of Deployment backed apps with the lowest
+// instance index which is not in use by another running pod of the same app
+type IndexInformer struct {
+	clientset   kubernetes.Interface
+	syncPeriod  time.Duration
+	namespace   string
+	stopperChan chan struct{}
+	logger      lager.Logger
+
+	indexer cache.Indexer
+	// assigned remembers the indexes handed out until the pod updates
+	// show up in the informer cache, so they are not handed out twice
+	assigned map[types.UID]int
+}
+
+func NewIndexInformer(
+	client kubernetes.Interface,
+	syncPeriod time.Duration,
+	namespace string,
+	stopperChan chan struct{},
+	logger lager.Logger,
+) *IndexInformer {
+	return &IndexInformer{
+		clientset:   client,
+		syncPeriod:  syncPeriod,
+		namespace:   namespace,
+		stopperChan: stopperChan,
+		logger:      logger,
+		assigned:    map[types.UID]int{},
+	}
+}
+
+func (i *IndexInformer) Start() {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		i.clientset,
+		i.syncPeriod,
+		informers.WithNamespace(i.namespace),
+		informers.WithTweakListOptions(func(options *meta.ListOptions) {
+			options.LabelSelector = "source_type=APP"
+		}),
+	)
+
+	informer := factory.Core().V1().Pods().Informer()
+	if err := informer.AddIndexers(cache.Indexers{lrpIndex: lrpIndexFunc}); err != nil {
+		panic(err)
+	}
+	i.indexer = informer.GetIndexer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: i.assignIndex,
+		UpdateFunc: func(_, newObj interface{}) {
+			i.assignIndex(newObj)
+		},
+		DeleteFunc: i.forgetIndex,
+	})
+
+	informer.Run(i.stopperChan)
+}
+
+func (i *IndexInformer) assignIndex(obj interface{}) {
+	pod := obj.(*v1.Pod)
+	if !k8s.IsOwnedByReplicaSet(pod) || pod.DeletionTimestamp != nil {
+		return
+	}
+	if _, ok := pod.Annotations[eirini.InstanceIndex]; ok {
+		return
+	}
+	if _, ok := i.assigned[pod.UID]; ok {
+		return
+	}
+
+	index, err := i.lowestFreeIndex(pod)
+	if err != nil {
+		i.logger.Error("failed-to-find-free-index", err, lager.Data{"pod-name": pod.Name})
+		return
+	}
+
+	annotated := pod.DeepCopy()
+	if annotated.Annotations == nil {
+		annotated.Annotations = map[string]string{}
+	}
+	annotated.Annotations[eirini.InstanceIndex] = strconv.Itoa(index)
+
+	if _, err := i.clientset.CoreV1().Pods(pod.Namespace).Update(annotated); err != nil {
+		// the pod will be retried when its next update comes in
+		i.logger.Error("failed-to-annotate-pod", err, lager.Data{"pod-name": pod.Name, "index": index})
+		return
+	}
+
+	i.assigned[pod.UID] = index
+	i.logger.Debug("assigned-instance-index", lager.Data{"pod-name": pod.Name, "index": index})
+}
+
+func (i *IndexInformer) forgetIndex(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	if pod, ok := obj.(*v1.Pod); ok {
+		delete(i.assigned, pod.UID)
+	}
+}
+
+func (i *IndexInformer) lowestFreeIndex(pod *v1.Pod) (int, error) {
+	key, err := lrpIndexFunc(pod)
+	if err != nil {
+		return 0, err
+	}
+	siblings, err := i.indexer.ByIndex(lrpIndex, key[0])
+	if err != nil {
+		return 0, err
+	}
+
+	taken := map[int]bool{}
+	for _, obj := range siblings {
+		sibling := obj.(*v1.Pod)
+		if sibling.DeletionTimestamp != nil {
+			continue
+		}
+		if index, ok := i.assigned[sibling.UID]; ok {
+			taken[index] = true
+			continue
+		}
+		if index, err := strconv.Atoi(sibling.Annotations[eirini.InstanceIndex]); err == nil {
+			taken[index] = true
+		}
+	}
+
+	index := 0
+	for taken[index] {
+		index++
+	}
+	return index, nil
+}
+
+func lrpIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a pod, got %T", obj)
+	}
+	return []string{fmt.Sprintf("%s-%s", pod.Labels["guid"], pod.Labels["version"])}, nil
+}
@@ -0,0 +1,118 @@
+package instance_test
+
+import (
+	"code.cloudfoundry.org/eirini"
+	. "code.cloudfoundry.org/eirini/k8s/informers/instance"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+)
+
+var _ = Describe("IndexInformer", func() {
+
+	const namespace = "milkyway"
+
+	var (
+		client          *fake.Clientset
+		indexInformer   *IndexInformer
+		informerStopper chan struct{}
+		watcher         *watch.FakeWatcher
+	)
+
+	addPod := func(pod *v1.Pod) {
+		_, err := client.CoreV1().Pods(namespace).Create(pod)
+		Expect(err).ToNot(HaveOccurred())
+		watcher.Add(pod)
+	}
+
+	getIndex := func(name string) func() string {
+		return func() string {
+			pod, err := client.CoreV1().Pods(namespace).Get(name, meta.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			return pod.Annotations[eirini.InstanceIndex]
+		}
+	}
+
+	BeforeEach(func() {
+		informerStopper = make(chan struct{})
+		client = fake.NewSimpleClientset()
+		indexInformer = NewIndexInformer(client, 0, namespace, informerStopper, lagertest.NewTestLogger("test-logger"))
+
+		watcher = watch.NewFake()
+		client.PrependWatchReactor("pods", testing.DefaultWatchReactor(watcher, nil))
+
+		go indexInformer.Start()
+	})
+
+	AfterEach(func() {
+		close(informerStopper)
+	})
+
+	It("should give new pods of an app distinct indexes", func() {
+		addPod(createPod("app-abc12", "app-guid", "ReplicaSet"))
+		addPod(createPod("app-def34", "app-guid", "ReplicaSet"))
+
+		Eventually(getIndex("app-abc12")).Should(Equal("0"))
+		Eventually(getIndex("app-def34")).Should(Equal("1"))
+	})
+
+	It("should skip indexes which are already in use", func() {
+		running := createPod("app-abc12", "app-guid", "ReplicaSet")
+		running.Annotations = map[string]string{eirini.InstanceIndex: "0"}
+		addPod(running)
+		addPod(createPod("app-def34", "app-guid", "ReplicaSet"))
+
+		Eventually(getIndex("app-def34")).Should(Equal("1"))
+		Consistently(getIndex("app-abc12")).Should(Equal("0"))
+	})
+
+	It("should reuse the index of a terminating pod", func() {
+		terminating := createPod("app-abc12", "app-guid", "ReplicaSet")
+		terminating.Annotations = map[string]string{eirini.InstanceIndex: "0"}
+		now := meta.Now()
+		terminating.DeletionTimestamp = &now
+		addPod(terminating)
+		addPod(createPod("app-def34", "app-guid", "ReplicaSet"))
+
+		Eventually(getIndex("app-def34")).Should(Equal("0"))
+	})
+
+	It("should count indexes per app", func() {
+		other := createPod("other-abc12", "other-guid", "ReplicaSet")
+		other.Annotations = map[string]string{eirini.InstanceIndex: "0"}
+		addPod(other)
+		addPod(createPod("app-def34", "app-guid", "ReplicaSet"))
+
+		Eventually(getIndex("app-def34")).Should(Equal("0"))
+	})
+
+	It("should not annotate StatefulSet pods", func() {
+		addPod(createPod("app-0", "app-guid", "StatefulSet"))
+
+		Consistently(getIndex("app-0")).Should(BeEmpty())
+	})
+})
+
+func createPod(name, guid, ownerKind string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: "milkyway",
+			UID:       types.UID(name + "-uid"),
+			Labels: map[string]string{
+				"guid":        guid,
+				"version":     "version",
+				"source_type": "APP",
+			},
+			OwnerReferences: []meta.OwnerReference{
+				{Kind: ownerKind, Name: guid + "-owner"},
+			},
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package rollout
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/lager"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// PartitionInformer finishes the staged rollouts of apps. Once the
+// instances at or above the update partition of a StatefulSet run its new
+// version and are ready, it lowers the partition to 0, so that the
+// instances below it are updated as well.
+type PartitionInformer struct {
+	clientset   kubernetes.Interface
+	syncPeriod  time.Duration
+	namespace   string
+	stopperChan chan struct{}
+	logger      lager.Logger
+}
+
+func NewPartitionInformer(
+	client kubernetes.Interface,
+	syncPeriod time.Duration,
+	namespace string,
+	stopperChan chan struct{},
+	logger lager.Logger,
+) *PartitionInformer {
+	return &PartitionInformer{
+		clientset:   client,
+		syncPeriod:  syncPeriod,
+		namespace:   namespace,
+		stopperChan: stopperChan,
+		logger:      logger,
+	}
+}
+
+func (p *PartitionInformer) Start() {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		p.clientset,
+		p.syncPeriod,
+		informers.WithNamespace(p.namespace),
+		informers.WithTweakListOptions(func(options *meta.ListOptions) {
+			options.LabelSelector = fmt.Sprintf("source_type=%s", k8s.AppSourceType)
+		}),
+	)
+
+	informer := factory.Apps().V1().StatefulSets().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: p.updateFunc,
+	})
+
+	informer.Run(p.stopperChan)
+}
+
+func (p *PartitionInformer) updateFunc(_ interface{}, newObj interface{}) {
+	statefulSet := newObj.(*appsv1.StatefulSet)
+	partition := partitionOf(statefulSet)
+	if partition == 0 {
+		return
+	}
+
+	logger := p.logger.Session("finish-rollout", lager.Data{"statefulset": statefulSet.Name, "partition": partition})
+	ready, err := p.updatedInstancesReady(statefulSet, partition)
+	if err != nil {
+		logger.Error("failed-to-list-pods", err)
+		return
+	}
+	if !ready {
+		return
+	}
+
+	updated := statefulSet.DeepCopy()
+	zero := int32(0)
+	updated.Spec.UpdateStrategy.RollingUpdate.Partition = &zero
+	if _, err = p.clientset.AppsV1().StatefulSets(statefulSet.Namespace).Update(updated); err != nil {
+		logger.Error("failed-to-lower-partition", err)
+		return
+	}
+	logger.Info("lowered-partition")
+}
+
+// updatedInstancesReady tells whether every instance at or above the
+// partition runs the version the StatefulSet is updated to, and is ready
+func (p *PartitionInformer) updatedInstancesReady(statefulSet *appsv1.StatefulSet, partition int32) (bool, error) {
+	// the status does not describe the current spec yet
+	if statefulSet.Status.ObservedGeneration < statefulSet.Generation || statefulSet.Status.UpdateRevision == "" {
+		return false, nil
+	}
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if partition >= replicas {
+		return true, nil
+	}
+
+	selector := labels.Set{}
+	for key, value := range statefulSet.Spec.Selector.MatchLabels {
+		selector[key] = value
+	}
+	selector[appsv1.StatefulSetRevisionLabel] = statefulSet.Status.UpdateRevision
+	pods, err := p.clientset.CoreV1().Pods(statefulSet.Namespace).List(meta.ListOptions{
+		LabelSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	var ready int32
+	for _, pod := range pods.Items {
+		if isReady(pod) {
+			ready++
+		}
+	}
+	return ready >= replicas-partition, nil
+}
+
+func partitionOf(statefulSet *appsv1.StatefulSet) int32 {
+	rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate == nil || rollingUpdate.Partition == nil {
+		return 0
+	}
+	return *rollingUpdate.Partition
+}
+
+func isReady(pod v1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
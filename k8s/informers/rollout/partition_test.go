@@ -0,0 +1,127 @@
+package rollout_test
+
+import (
+	. "code.cloudfoundry.org/eirini/k8s/informers/rollout"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+)
+
+var _ = Describe("PartitionInformer", func() {
+
+	const namespace = "milkyway"
+
+	var (
+		client          kubernetes.Interface
+		informerStopper chan struct{}
+		watcher         *watch.FakeWatcher
+		statefulSet     *appsv1.StatefulSet
+		updatedPod      *v1.Pod
+	)
+
+	partition := func() int32 {
+		current, err := client.AppsV1().StatefulSets(namespace).Get("dora", meta.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return *current.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+
+	BeforeEach(func() {
+		replicas := int32(3)
+		staged := int32(2)
+		statefulSet = &appsv1.StatefulSet{
+			ObjectMeta: meta.ObjectMeta{
+				Name:       "dora",
+				Namespace:  namespace,
+				Generation: 2,
+				Labels:     map[string]string{"guid": "dora-guid", "source_type": "APP"},
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: &replicas,
+				Selector: &meta.LabelSelector{MatchLabels: map[string]string{"guid": "dora-guid"}},
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+					Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &staged},
+				},
+			},
+			Status: appsv1.StatefulSetStatus{
+				ObservedGeneration: 2,
+				CurrentRevision:    "dora-1",
+				UpdateRevision:     "dora-2",
+			},
+		}
+		updatedPod = &v1.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Name:   "dora-2",
+				Labels: map[string]string{"guid": "dora-guid", appsv1.StatefulSetRevisionLabel: "dora-2"},
+			},
+			Status: v1.PodStatus{
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+			},
+		}
+
+		client = fake.NewSimpleClientset()
+		informerStopper = make(chan struct{})
+		watcher = watch.NewFake()
+		client.(*fake.Clientset).PrependWatchReactor("statefulsets", testing.DefaultWatchReactor(watcher, nil))
+	})
+
+	AfterEach(func() {
+		close(informerStopper)
+	})
+
+	JustBeforeEach(func() {
+		_, err := client.AppsV1().StatefulSets(namespace).Create(statefulSet)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = client.CoreV1().Pods(namespace).Create(updatedPod)
+		Expect(err).ToNot(HaveOccurred())
+
+		informer := NewPartitionInformer(client, 0, namespace, informerStopper, lagertest.NewTestLogger("test"))
+		go informer.Start()
+
+		watcher.Add(statefulSet)
+		watcher.Modify(statefulSet)
+	})
+
+	Context("When the instances above the partition are updated and ready", func() {
+		It("should update the instances below the partition", func() {
+			Eventually(partition).Should(Equal(int32(0)))
+		})
+	})
+
+	Context("When an updated instance is not ready", func() {
+		BeforeEach(func() {
+			updatedPod.Status.Conditions[0].Status = v1.ConditionFalse
+		})
+
+		It("should keep the partition", func() {
+			Consistently(partition).Should(Equal(int32(2)))
+		})
+	})
+
+	Context("When the instances are not updated yet", func() {
+		BeforeEach(func() {
+			updatedPod.Labels[appsv1.StatefulSetRevisionLabel] = "dora-1"
+		})
+
+		It("should keep the partition", func() {
+			Consistently(partition).Should(Equal(int32(2)))
+		})
+	})
+
+	Context("When the status does not describe the update yet", func() {
+		BeforeEach(func() {
+			statefulSet.Status.ObservedGeneration = 1
+		})
+
+		It("should keep the partition", func() {
+			Consistently(partition).Should(Equal(int32(2)))
+		})
+	})
+})
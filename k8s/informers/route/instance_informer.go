@@ -9,7 +9,6 @@ import (
 	"code.cloudfoundry.org/eirini/models/cf"
 	"code.cloudfoundry.org/eirini/route"
 	"code.cloudfoundry.org/lager"
-	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
@@ -38,12 +37,13 @@ func NewInstanceChangeInformer(client kubernetes.Interface, syncPeriod time.Dura
 func (c *InstanceChangeInformer) Start(work chan<- *route.Message) {
 	factory := informers.NewSharedInformerFactoryWithOptions(c.Client,
 		c.SyncPeriod,
-		informers.WithNamespace(c.Namespace))
+		informers.WithNamespace(c.Namespace),
+		informers.WithTweakListOptions(appListOptions))
 
 	podInformer := factory.Core().V1().Pods().Informer()
 	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		UpdateFunc: func(_ interface{}, updatedObj interface{}) {
-			c.onPodUpdate(updatedObj, work)
+		UpdateFunc: func(oldObj interface{}, updatedObj interface{}) {
+			c.onPodUpdate(oldObj, updatedObj, work)
 		},
 		DeleteFunc: func(obj interface{}) {
 			c.onPodDelete(obj, work)
@@ -54,22 +54,25 @@ func (c *InstanceChangeInformer) Start(work chan<- *route.Message) {
 }
 
 func (c *InstanceChangeInformer) onPodDelete(deletedObj interface{}, work chan<- *route.Message) {
-	deletedPod := deletedObj.(*v1.Pod)
-	userDefinedRoutes, err := c.getUserDefinedRoutes(deletedPod)
+	c.unregisterRoutes(deletedObj.(*v1.Pod), work)
+}
+
+func (c *InstanceChangeInformer) unregisterRoutes(pod *v1.Pod, work chan<- *route.Message) {
+	userDefinedRoutes, err := c.getUserDefinedRoutes(pod)
 	if err != nil {
-		c.logError("failed-to-get-user-defined-routes", err, deletedPod)
+		c.logError("failed-to-get-user-defined-routes", err, pod)
 		return
 	}
 
 	for _, r := range userDefinedRoutes {
 		routes, err := route.NewMessage(
-			deletedPod.Name,
-			deletedPod.Name,
-			deletedPod.Status.PodIP,
+			pod.Name,
+			pod.Name,
+			pod.Status.PodIP,
 			uint32(r.Port),
 		)
 		if err != nil {
-			c.logError("failed-to-construct-a-route-message", err, deletedPod)
+			c.logError("failed-to-construct-a-route-message", err, pod)
 			continue
 		}
 		routes.UnregisteredRoutes = []string{r.Hostname}
@@ -77,8 +80,19 @@ func (c *InstanceChangeInformer) onPodDelete(deletedObj interface{}, work chan<-
 	}
 }
 
-func (c *InstanceChangeInformer) onPodUpdate(updatedObj interface{}, work chan<- *route.Message) {
+// onPodUpdate unregisters the routes of a pod as soon as it starts
+// terminating, so that gorouter stops sending it requests while it drains,
+// rather than when it is finally deleted
+func (c *InstanceChangeInformer) onPodUpdate(oldObj, updatedObj interface{}, work chan<- *route.Message) {
+	oldPod := oldObj.(*v1.Pod)
 	updatedPod := updatedObj.(*v1.Pod)
+	if updatedPod.DeletionTimestamp != nil {
+		if oldPod.DeletionTimestamp == nil {
+			c.unregisterRoutes(updatedPod, work)
+		}
+		return
+	}
+
 	if !isReady(updatedPod.Status.Conditions) {
 		c.logDebug("pod-not-ready", updatedPod)
 		return
@@ -107,13 +121,13 @@ func (c *InstanceChangeInformer) onPodUpdate(updatedObj interface{}, work chan<-
 }
 
 func (c *InstanceChangeInformer) getUserDefinedRoutes(pod *v1.Pod) ([]cf.Route, error) {
-	owner, err := c.getOwner(pod)
+	annotations, err := c.getOwnerAnnotations(pod)
 	if err != nil {
 		c.logError("unexpected-pod-owner", err, pod)
 		return []cf.Route{}, err
 	}
 
-	return decodeRoutes(owner.Annotations[eirini.RegisteredRoutes])
+	return decodeRoutes(annotations[eirini.RegisteredRoutes])
 }
 
 func (c *InstanceChangeInformer) logError(message string, err error, pod *v1.Pod) {
@@ -128,15 +142,38 @@ func (c *InstanceChangeInformer) logDebug(message string, pod *v1.Pod) {
 	}
 }
 
-func (c *InstanceChangeInformer) getOwner(pod *v1.Pod) (*apps.StatefulSet, error) {
+// getOwnerAnnotations returns the annotations of the StatefulSet or
+// Deployment that the pod belongs to. Deployment pods are owned by a
+// ReplicaSet, which in turn is owned by the Deployment.
+func (c *InstanceChangeInformer) getOwnerAnnotations(pod *v1.Pod) (map[string]string, error) {
 	ownerReferences := pod.OwnerReferences
 
 	if len(ownerReferences) != 1 {
 		return nil, fmt.Errorf("unexpected owner count - expected 1, but got %d", len(ownerReferences))
 	}
 
-	ownerName := ownerReferences[0].Name
-	return c.Client.AppsV1().StatefulSets(c.Namespace).Get(ownerName, meta.GetOptions{})
+	owner := ownerReferences[0]
+	if owner.Kind != "ReplicaSet" {
+		statefulSet, err := c.Client.AppsV1().StatefulSets(pod.Namespace).Get(owner.Name, meta.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return statefulSet.Annotations, nil
+	}
+
+	replicaSet, err := c.Client.AppsV1().ReplicaSets(pod.Namespace).Get(owner.Name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	deploymentRef := meta.GetControllerOf(replicaSet)
+	if deploymentRef == nil {
+		return nil, fmt.Errorf("replicaset %s is not owned by a deployment", replicaSet.Name)
+	}
+	deployment, err := c.Client.AppsV1().Deployments(pod.Namespace).Get(deploymentRef.Name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return deployment.Annotations, nil
 }
 
 func isReady(conditions []v1.PodCondition) bool {
@@ -154,3 +191,9 @@ func decodeRoutes(s string) ([]cf.Route, error) {
 
 	return routes, err
 }
+
+// appListOptions keeps the informers to app workloads, as they may watch
+// namespaces which are shared with other workloads
+func appListOptions(options *meta.ListOptions) {
+	options.LabelSelector = "source_type=APP"
+}
@@ -48,7 +48,8 @@ var _ = Describe("InstanceChangeInformer", func() {
 	createPod := func(name string) *v1.Pod {
 		return &v1.Pod{
 			ObjectMeta: meta.ObjectMeta{
-				Name: name,
+				Name:      name,
+				Namespace: namespace,
 				OwnerReferences: []meta.OwnerReference{
 					{
 						Kind: "StatefulSet",
@@ -313,6 +314,51 @@ var _ = Describe("InstanceChangeInformer", func() {
 		})
 	})
 
+	Context("When a pod of a deployment gets an ip", func() {
+
+		BeforeEach(func() {
+			isController := true
+			deployment := &apps_v1.Deployment{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        "mr-deployment",
+					Annotations: map[string]string{"routes": `[{"hostname": "mr-deployment.50.60.70.80.nip.io", "port": 8080}]`},
+				},
+			}
+			replicaSet := &apps_v1.ReplicaSet{
+				ObjectMeta: meta.ObjectMeta{
+					Name: "mr-deployment-5d8f7c",
+					OwnerReferences: []meta.OwnerReference{
+						{Kind: "Deployment", Name: "mr-deployment", Controller: &isController},
+					},
+				},
+			}
+			_, err := client.AppsV1().Deployments(namespace).Create(deployment)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = client.AppsV1().ReplicaSets(namespace).Create(replicaSet)
+			Expect(err).ToNot(HaveOccurred())
+
+			pod0 = createPod("mr-deployment-5d8f7c-x2b4q")
+			pod0.OwnerReferences = []meta.OwnerReference{{Kind: "ReplicaSet", Name: "mr-deployment-5d8f7c"}}
+			pod1 = createPod("mr-stateful-1")
+		})
+
+		JustBeforeEach(func() {
+			pod0.Status.PodIP = "10.20.30.40"
+			podWatcher.Modify(pod0)
+		})
+
+		It("should send the routes of the deployment", func() {
+			Eventually(workChan, routeMessageTimeout).Should(Receive(Equal(&route.Message{
+				Name:       "mr-deployment-5d8f7c-x2b4q",
+				Routes:     []string{"mr-deployment.50.60.70.80.nip.io"},
+				InstanceID: "mr-deployment-5d8f7c-x2b4q",
+				Address:    "10.20.30.40",
+				Port:       8080,
+				TLSPort:    0,
+			})))
+		})
+	})
+
 	Context("When a pod is deleted", func() {
 
 		BeforeEach(func() {
@@ -413,4 +459,69 @@ var _ = Describe("InstanceChangeInformer", func() {
 			})
 		})
 	})
+
+	Context("When a pod starts terminating", func() {
+
+		var deletionTimestamp meta.Time
+
+		BeforeEach(func() {
+			pod0 = createPod("mr-stateful-0")
+			pod0.Status.PodIP = "10.20.30.40"
+			pod1 = createPod("mr-stateful-1")
+			pod1.Status.PodIP = "50.60.70.80"
+			deletionTimestamp = meta.Now()
+		})
+
+		JustBeforeEach(func() {
+			terminatingPod := pod0.DeepCopy()
+			terminatingPod.DeletionTimestamp = &deletionTimestamp
+			podWatcher.Modify(terminatingPod)
+		})
+
+		It("should send the unregister routes before the pod is deleted", func() {
+			Eventually(workChan, routeMessageTimeout).Should(Receive(Equal(&route.Message{
+				Name:               "mr-stateful-0",
+				UnregisteredRoutes: []string{"mr-stateful.50.60.70.80.nip.io"},
+				InstanceID:         "mr-stateful-0",
+				Address:            "10.20.30.40",
+				Port:               8080,
+				TLSPort:            0,
+			})))
+		})
+
+		It("should send the unregister routes before the pod is deleted", func() {
+			Eventually(workChan, routeMessageTimeout).Should(Receive(Equal(&route.Message{
+				Name:               "mr-stateful-0",
+				UnregisteredRoutes: []string{"mr-bombastic.50.60.70.80.nip.io"},
+				InstanceID:         "mr-stateful-0",
+				Address:            "10.20.30.40",
+				Port:               6565,
+				TLSPort:            0,
+			})))
+		})
+
+		It("should not register the routes of the terminating pod", func() {
+			Consistently(workChan, routeMessageTimeout).ShouldNot(Receive(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Name":   Equal("mr-stateful-0"),
+				"Routes": Not(BeEmpty()),
+			}))))
+		})
+
+		Context("and it is updated again while it terminates", func() {
+
+			JustBeforeEach(func() {
+				Eventually(workChan, routeMessageTimeout).Should(Receive())
+				Eventually(workChan, routeMessageTimeout).Should(Receive())
+
+				terminatingPod := pod0.DeepCopy()
+				terminatingPod.DeletionTimestamp = &deletionTimestamp
+				terminatingPod.Status.Conditions[0].Status = v1.ConditionFalse
+				podWatcher.Modify(terminatingPod)
+			})
+
+			It("should not send the routes again", func() {
+				Consistently(workChan, routeMessageTimeout).ShouldNot(Receive())
+			})
+		})
+	})
 })
@@ -24,6 +24,15 @@ type routes struct {
 
 type portGroup map[int32]routes
 
+// routeOwner holds what the informer needs from the StatefulSet or
+// Deployment that runs an app
+type routeOwner struct {
+	name        string
+	namespace   string
+	annotations map[string]string
+	selector    *meta.LabelSelector
+}
+
 type URIChangeInformer struct {
 	Cancel     <-chan struct{}
 	Client     kubernetes.Interface
@@ -45,33 +54,39 @@ func NewURIChangeInformer(client kubernetes.Interface, syncPeriod time.Duration,
 func (c *URIChangeInformer) Start(work chan<- *route.Message) {
 	factory := informers.NewSharedInformerFactoryWithOptions(c.Client,
 		c.SyncPeriod,
-		informers.WithNamespace(c.Namespace))
+		informers.WithNamespace(c.Namespace),
+		informers.WithTweakListOptions(appListOptions))
 
-	informer := factory.Apps().V1().StatefulSets().Informer()
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	handlers := cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(oldObj, updatedObj interface{}) {
 			c.onUpdate(oldObj, updatedObj, work)
 		},
 		DeleteFunc: func(obj interface{}) {
 			c.onDelete(obj, work)
 		},
-	})
+	}
+	factory.Apps().V1().StatefulSets().Informer().AddEventHandler(handlers)
+	factory.Apps().V1().Deployments().Informer().AddEventHandler(handlers)
 
-	informer.Run(c.Cancel)
+	factory.Start(c.Cancel)
+	<-c.Cancel
 }
 
 func (c *URIChangeInformer) onUpdate(oldObj, updatedObj interface{}, work chan<- *route.Message) {
-	oldStatefulSet := oldObj.(*apps_v1.StatefulSet)
-	updatedStatefulSet := updatedObj.(*apps_v1.StatefulSet)
+	oldOwner := toRouteOwner(oldObj)
+	updatedOwner := toRouteOwner(updatedObj)
+	if oldOwner == nil || updatedOwner == nil {
+		return
+	}
 
-	updatedSet, err := decodeRoutesAsSet(updatedStatefulSet)
+	updatedSet, err := decodeRoutesAsSet(updatedOwner)
 	if err != nil {
-		c.logError("failed-to-decode-updated-user-defined-routes", err, updatedStatefulSet)
+		c.logError("failed-to-decode-updated-user-defined-routes", err, updatedOwner)
 	}
 
-	oldSet, err := decodeRoutesAsSet(oldStatefulSet)
+	oldSet, err := decodeRoutesAsSet(oldOwner)
 	if err != nil {
-		c.logError("failed-to-decode-old-user-defined-routes", err, oldStatefulSet)
+		c.logError("failed-to-decode-old-user-defined-routes", err, oldOwner)
 	}
 
 	removedRoutes := oldSet.Difference(updatedSet)
@@ -79,7 +94,7 @@ func (c *URIChangeInformer) onUpdate(oldObj, updatedObj interface{}, work chan<-
 
 	c.sendRoutesForAllPods(
 		work,
-		updatedStatefulSet,
+		updatedOwner,
 		grouped,
 	)
 }
@@ -103,31 +118,41 @@ func groupRoutesByPort(remove, add set.Set) portGroup {
 }
 
 func (c *URIChangeInformer) onDelete(obj interface{}, work chan<- *route.Message) {
-	deletedStatefulSet := obj.(*apps_v1.StatefulSet)
-	routeSet, err := decodeRoutesAsSet(deletedStatefulSet)
+	deletedOwner := toRouteOwner(obj)
+	if deletedOwner == nil {
+		return
+	}
+
+	routeSet, err := decodeRoutesAsSet(deletedOwner)
 	if err != nil {
-		c.logError("failed-to-decode-deleted-user-defined-routes", err, deletedStatefulSet)
+		c.logError("failed-to-decode-deleted-user-defined-routes", err, deletedOwner)
 	}
 
 	routeGroups := groupRoutesByPort(routeSet, set.NewSet())
 	c.sendRoutesForAllPods(
 		work,
-		deletedStatefulSet,
+		deletedOwner,
 		routeGroups,
 	)
 }
 
-func (c *URIChangeInformer) sendRoutesForAllPods(work chan<- *route.Message, statefulset *apps_v1.StatefulSet, grouped portGroup) {
-	pods, err := c.getChildrenPods(statefulset)
+func (c *URIChangeInformer) sendRoutesForAllPods(work chan<- *route.Message, owner *routeOwner, grouped portGroup) {
+	pods, err := c.getChildrenPods(owner)
 	if err != nil {
-		c.logError("failed-to-get-child-pods", err, statefulset)
+		c.logError("failed-to-get-child-pods", err, owner)
 		return
 	}
 	for _, pod := range pods {
 		if !isReady(pod.Status.Conditions) {
 			continue
 		}
+		// a terminating pod had its routes unregistered by the instance
+		// informer, but may still be ready while it drains
+		terminating := pod.DeletionTimestamp != nil
 		for port, routes := range grouped {
+			if terminating && len(routes.UnregisterRoutes) == 0 {
+				continue
+			}
 			podRoute, err := route.NewMessage(
 				pod.Name,
 				pod.Name,
@@ -135,42 +160,59 @@ func (c *URIChangeInformer) sendRoutesForAllPods(work chan<- *route.Message, sta
 				uint32(port),
 			)
 			if err != nil {
-				c.logPodError("failed-to-construct-a-route-message", err, statefulset, pod)
+				c.logPodError("failed-to-construct-a-route-message", err, owner, pod)
 				return
 			}
 
-			podRoute.Routes = routes.RegisterRoutes
+			if !terminating {
+				podRoute.Routes = routes.RegisterRoutes
+			}
 			podRoute.UnregisteredRoutes = routes.UnregisterRoutes
 			work <- podRoute
 		}
 	}
 }
 
-func (c *URIChangeInformer) logError(message string, err error, statefulset *apps_v1.StatefulSet) {
+func (c *URIChangeInformer) logError(message string, err error, owner *routeOwner) {
 	if c.Logger != nil {
-		c.Logger.Error(message, err, lager.Data{"statefulset-name": statefulset.Name})
+		c.Logger.Error(message, err, lager.Data{"owner-name": owner.name})
 	}
 }
 
-func (c *URIChangeInformer) logPodError(message string, err error, statefulset *apps_v1.StatefulSet, pod v1.Pod) {
+func (c *URIChangeInformer) logPodError(message string, err error, owner *routeOwner, pod v1.Pod) {
 	if c.Logger != nil {
-		c.Logger.Error(message, err, lager.Data{"statefulset-name": statefulset.Name, "pod-name": pod.Name})
+		c.Logger.Error(message, err, lager.Data{"owner-name": owner.name, "pod-name": pod.Name})
 	}
 }
 
-func (c *URIChangeInformer) getChildrenPods(st *apps_v1.StatefulSet) ([]v1.Pod, error) {
-	set := labels.Set(st.Spec.Selector.MatchLabels)
+func (c *URIChangeInformer) getChildrenPods(owner *routeOwner) ([]v1.Pod, error) {
+	set := labels.Set(owner.selector.MatchLabels)
 	opts := meta.ListOptions{LabelSelector: set.AsSelector().String()}
-	podlist, err := c.Client.CoreV1().Pods(c.Namespace).List(opts)
+	podlist, err := c.Client.CoreV1().Pods(owner.namespace).List(opts)
 	if err != nil {
 		return []v1.Pod{}, err
 	}
 	return podlist.Items, nil
 }
 
-func decodeRoutesAsSet(statefulset *apps_v1.StatefulSet) (set.Set, error) {
+func toRouteOwner(obj interface{}) *routeOwner {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	switch owner := obj.(type) {
+	case *apps_v1.StatefulSet:
+		return &routeOwner{name: owner.Name, namespace: owner.Namespace, annotations: owner.Annotations, selector: owner.Spec.Selector}
+	case *apps_v1.Deployment:
+		return &routeOwner{name: owner.Name, namespace: owner.Namespace, annotations: owner.Annotations, selector: owner.Spec.Selector}
+	default:
+		return nil
+	}
+}
+
+func decodeRoutesAsSet(owner *routeOwner) (set.Set, error) {
 	routes := set.NewSet()
-	updatedUserDefinedRoutes, err := decodeRoutes(statefulset.Annotations[eirini.RegisteredRoutes])
+	updatedUserDefinedRoutes, err := decodeRoutes(owner.annotations[eirini.RegisteredRoutes])
 	if err != nil {
 		return set.NewSet(), err
 	}
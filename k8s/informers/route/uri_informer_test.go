@@ -34,6 +34,7 @@ var _ = Describe("URIChangeInformer", func() {
 		informer    URIChangeInformer
 		client      kubernetes.Interface
 		watcher     *watch.FakeWatcher
+		depWatcher  *watch.FakeWatcher
 		workChan    chan *route.Message
 		stopChan    chan struct{}
 		logger      *lagertest.TestLogger
@@ -76,6 +77,8 @@ var _ = Describe("URIChangeInformer", func() {
 		fakecs := cs.(*fake.Clientset)
 		watcher = watch.NewFake()
 		fakecs.PrependWatchReactor("statefulsets", testcore.DefaultWatchReactor(watcher, nil))
+		depWatcher = watch.NewFake()
+		fakecs.PrependWatchReactor("deployments", testcore.DefaultWatchReactor(depWatcher, nil))
 	}
 
 	copyWithModifiedRoute := func(st *apps_v1.StatefulSet, routes string) *apps_v1.StatefulSet {
@@ -118,7 +121,8 @@ var _ = Describe("URIChangeInformer", func() {
 
 		statefulset = &apps_v1.StatefulSet{
 			ObjectMeta: meta.ObjectMeta{
-				Name: "mr-stateful",
+				Name:      "mr-stateful",
+				Namespace: namespace,
 				Annotations: map[string]string{
 					"routes": `[
 						{
@@ -454,6 +458,50 @@ var _ = Describe("URIChangeInformer", func() {
 
 	})
 
+	Context("When a pod is terminating", func() {
+
+		BeforeEach(func() {
+			pod0.DeletionTimestamp = &meta.Time{Time: time.Now()}
+		})
+
+		JustBeforeEach(func() {
+			watcher.Modify(copyWithModifiedRoute(statefulset, `[
+						{
+							"hostname": "mr-stateful.50.60.70.80.nip.io",
+							"port": 1111
+						},
+						{
+							"hostname": "mr-boombastic.50.60.70.80.nip.io",
+							"port": 6565
+						}
+					]`))
+		})
+
+		It("should not register routes for the pod", func() {
+			Consistently(workChan, routeMessageTimeout).ShouldNot(Receive(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Name":   Equal("mr-stateful-0"),
+				"Routes": Not(BeEmpty()),
+			}))))
+		})
+
+		It("should unregister the deleted route for the pod", func() {
+			Eventually(workChan, routeMessageTimeout).Should(Receive(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Name":               Equal("mr-stateful-0"),
+				"Routes":             BeEmpty(),
+				"UnregisteredRoutes": ConsistOf("mr-stateful.50.60.70.80.nip.io"),
+				"Port":               BeNumerically("==", 8080),
+			}))))
+		})
+
+		It("should register the new route for the other pod", func() {
+			Eventually(workChan, routeMessageTimeout).Should(Receive(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Name":   Equal("mr-stateful-1"),
+				"Routes": ConsistOf("mr-stateful.50.60.70.80.nip.io"),
+				"Port":   BeNumerically("==", 1111),
+			}))))
+		})
+	})
+
 	Context("When the app is deleted", func() {
 
 		JustBeforeEach(func() {
@@ -508,4 +556,40 @@ var _ = Describe("URIChangeInformer", func() {
 			}))))
 		})
 	})
+
+	Context("When a deployment is deleted", func() {
+
+		JustBeforeEach(func() {
+			deployment := &apps_v1.Deployment{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      "mr-deployment",
+					Namespace: namespace,
+					Annotations: map[string]string{
+						"routes": `[{"hostname": "mr-deployment.50.60.70.80.nip.io", "port": 8080}]`,
+					},
+				},
+				Spec: apps_v1.DeploymentSpec{
+					Selector: &meta.LabelSelector{
+						MatchLabels: map[string]string{
+							"name": "the-app-name",
+						},
+					},
+				},
+			}
+			depWatcher.Add(deployment)
+			depWatcher.Delete(deployment)
+		})
+
+		It("should unregister its routes for its pods", func() {
+			Eventually(workChan, routeMessageTimeout).Should(Receive(PointTo(MatchAllFields(Fields{
+				"Name":               Equal("mr-stateful-0"),
+				"Routes":             BeEmpty(),
+				"UnregisteredRoutes": ConsistOf("mr-deployment.50.60.70.80.nip.io"),
+				"InstanceID":         Equal("mr-stateful-0"),
+				"Address":            Equal("10.20.30.40"),
+				"Port":               BeNumerically("==", 8080),
+				"TLSPort":            BeNumerically("==", 0),
+			}))))
+		})
+	})
 })
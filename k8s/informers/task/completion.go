@@ -0,0 +1,182 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/runtimeschema/cc_messages"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+type CompletionInformer struct {
+	clientset   kubernetes.Interface
+	syncPeriod  time.Duration
+	namespace   string
+	taskBifrost eirini.TaskBifrost
+	stager      eirini.Stager
+	stopperChan chan struct{}
+	logger      lager.Logger
+}
+
+func NewCompletionInformer(
+	client kubernetes.Interface,
+	syncPeriod time.Duration,
+	namespace string,
+	taskBifrost eirini.TaskBifrost,
+	stager eirini.Stager,
+	stopperChan chan struct{},
+	logger lager.Logger,
+) *CompletionInformer {
+	return &CompletionInformer{
+		clientset:   client,
+		syncPeriod:  syncPeriod,
+		namespace:   namespace,
+		taskBifrost: taskBifrost,
+		stager:      stager,
+		stopperChan: stopperChan,
+		logger:      logger,
+	}
+}
+
+func (c *CompletionInformer) Start() {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		c.clientset,
+		c.syncPeriod,
+		informers.WithNamespace(c.namespace),
+		informers.WithTweakListOptions(func(options *meta.ListOptions) {
+			options.LabelSelector = fmt.Sprintf("source_type in (%s,%s)", k8s.TaskSourceType, k8s.StagingSourceType)
+		}),
+	)
+
+	informer := factory.Batch().V1().Jobs().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: c.updateFunc,
+	})
+
+	informer.Run(c.stopperChan)
+}
+
+func (c *CompletionInformer) updateFunc(_ interface{}, newObj interface{}) {
+	job := newObj.(*batch.Job)
+	if job.Annotations[eirini.CompletionHandled] == "true" {
+		return
+	}
+
+	var report func(*batch.Job) error
+	switch job.Labels["source_type"] {
+	case k8s.TaskSourceType:
+		if isFinished(job) {
+			report = c.completeTask
+		}
+	case k8s.StagingSourceType:
+		// successful staging is reported by the uploader
+		if isFailed(job) {
+			report = c.failStaging
+		}
+	}
+
+	if report == nil || !c.markHandled(job) {
+		return
+	}
+	if err := report(job); err != nil {
+		c.unmarkHandled(job)
+	}
+}
+
+// markHandled records that the completion of a job is reported, so that it
+// is not reported again on every resync until the job is deleted. A job
+// which cannot be marked, or whose report fails, is reported on a later
+// resync.
+func (c *CompletionInformer) markHandled(job *batch.Job) bool {
+	marked := job.DeepCopy()
+	if marked.Annotations == nil {
+		marked.Annotations = map[string]string{}
+	}
+	marked.Annotations[eirini.CompletionHandled] = "true"
+
+	if _, err := c.clientset.BatchV1().Jobs(job.Namespace).Update(marked); err != nil {
+		c.logger.Error("failed-to-mark-job-handled", err, lager.Data{"job-name": job.Name})
+		return false
+	}
+	return true
+}
+
+func (c *CompletionInformer) unmarkHandled(job *batch.Job) {
+	jobs := c.clientset.BatchV1().Jobs(job.Namespace)
+	marked, err := jobs.Get(job.Name, meta.GetOptions{})
+	if err != nil {
+		// a deleted job is not reported again anyway
+		if !apierrors.IsNotFound(err) {
+			c.logger.Error("failed-to-get-job", err, lager.Data{"job-name": job.Name})
+		}
+		return
+	}
+
+	delete(marked.Annotations, eirini.CompletionHandled)
+	if _, err = jobs.Update(marked); err != nil {
+		c.logger.Error("failed-to-unmark-job-handled", err, lager.Data{"job-name": job.Name})
+	}
+}
+
+func (c *CompletionInformer) completeTask(job *batch.Job) error {
+	err := c.taskBifrost.CompleteTask(context.Background(), job.Name)
+	if err != nil {
+		c.logger.Error("failed-to-complete-task", err, lager.Data{"task-guid": job.Name})
+	}
+	return err
+}
+
+func (c *CompletionInformer) failStaging(job *batch.Job) error {
+	logger := c.logger.Session("fail-staging", lager.Data{"staging-guid": job.Name})
+
+	annotation, err := json.Marshal(cc_messages.StagingTaskAnnotation{
+		CompletionCallback: job.Annotations[eirini.CompletionCallback],
+	})
+	if err != nil {
+		logger.Error("failed-to-marshal-annotation", err)
+		return err
+	}
+
+	reason := k8s.GetJobFailureReason(c.clientset, job)
+	logger.Info("staging-job-failed", lager.Data{"reason": reason})
+
+	err = c.stager.CompleteStaging(&models.TaskCallbackResponse{
+		TaskGuid:      job.Name,
+		Failed:        true,
+		FailureReason: reason,
+		Annotation:    string(annotation),
+	})
+	if err != nil {
+		logger.Error("failed-to-complete-staging", err)
+	}
+	return err
+}
+
+func isFinished(job *batch.Job) bool {
+	return hasCondition(job, batch.JobComplete) || hasCondition(job, batch.JobFailed)
+}
+
+func isFailed(job *batch.Job) bool {
+	return hasCondition(job, batch.JobFailed)
+}
+
+func hasCondition(job *batch.Job, conditionType batch.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
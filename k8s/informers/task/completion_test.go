@@ -0,0 +1,273 @@
+package task_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/eirini/eirinifakes"
+	. "code.cloudfoundry.org/eirini/k8s/informers/task"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+)
+
+var _ = Describe("CompletionInformer", func() {
+
+	const namespace = "milkyway"
+
+	var (
+		client             kubernetes.Interface
+		taskBifrost        *eirinifakes.FakeTaskBifrost
+		stager             *eirinifakes.FakeStager
+		completionInformer *CompletionInformer
+		informerStopper    chan struct{}
+		watcher            *watch.FakeWatcher
+		job                *batch.Job
+	)
+
+	BeforeEach(func() {
+		job = createJob("the-task-guid")
+
+		taskBifrost = new(eirinifakes.FakeTaskBifrost)
+		stager = new(eirinifakes.FakeStager)
+		informerStopper = make(chan struct{})
+
+		client = fake.NewSimpleClientset()
+		completionInformer = NewCompletionInformer(client, 0, namespace, taskBifrost, stager, informerStopper, lagertest.NewTestLogger("test-logger"))
+
+		watcher = watch.NewFake()
+		fakecs := client.(*fake.Clientset)
+		fakecs.PrependWatchReactor("jobs", testing.DefaultWatchReactor(watcher, nil))
+	})
+
+	AfterEach(func() {
+		close(informerStopper)
+	})
+
+	JustBeforeEach(func() {
+		_, err := client.BatchV1().Jobs(namespace).Create(job)
+		Expect(err).ToNot(HaveOccurred())
+
+		go completionInformer.Start()
+
+		watcher.Add(job)
+	})
+
+	getAnnotations := func(name string) map[string]string {
+		current, err := client.BatchV1().Jobs(namespace).Get(name, meta.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return current.Annotations
+	}
+
+	Context("When a task job is still running", func() {
+		JustBeforeEach(func() {
+			running := createJob("the-task-guid")
+			running.Status.Active = 1
+			watcher.Modify(running)
+		})
+
+		It("should not complete the task", func() {
+			Consistently(taskBifrost.CompleteTaskCallCount).Should(Equal(0))
+		})
+	})
+
+	Context("When a task job completes", func() {
+		JustBeforeEach(func() {
+			completed := createJob("the-task-guid")
+			completed.Status.Conditions = []batch.JobCondition{
+				{Type: batch.JobComplete, Status: v1.ConditionTrue},
+			}
+			watcher.Modify(completed)
+		})
+
+		It("should complete the task", func() {
+			Eventually(taskBifrost.CompleteTaskCallCount).Should(Equal(1))
+			_, taskGUID := taskBifrost.CompleteTaskArgsForCall(0)
+			Expect(taskGUID).To(Equal("the-task-guid"))
+		})
+
+		It("should record that the completion was handled", func() {
+			Eventually(func() map[string]string {
+				return getAnnotations("the-task-guid")
+			}).Should(HaveKeyWithValue("completion_handled", "true"))
+		})
+
+		Context("and the job cannot be updated", func() {
+			BeforeEach(func() {
+				client.(*fake.Clientset).PrependReactor("update", "jobs", func(action testing.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("boom")
+				})
+			})
+
+			It("should not complete the task until it can", func() {
+				Consistently(taskBifrost.CompleteTaskCallCount).Should(Equal(0))
+			})
+		})
+
+		Context("and the completion callback fails", func() {
+			BeforeEach(func() {
+				taskBifrost.CompleteTaskReturnsOnCall(0, errors.New("cc is down"))
+			})
+
+			It("should remove the record that the completion was handled", func() {
+				Eventually(taskBifrost.CompleteTaskCallCount).Should(Equal(1))
+				Eventually(func() map[string]string {
+					return getAnnotations("the-task-guid")
+				}).ShouldNot(HaveKey("completion_handled"))
+			})
+
+			It("should complete the task on a later resync", func() {
+				Eventually(taskBifrost.CompleteTaskCallCount).Should(Equal(1))
+				Eventually(func() map[string]string {
+					return getAnnotations("the-task-guid")
+				}).ShouldNot(HaveKey("completion_handled"))
+
+				resynced, err := client.BatchV1().Jobs(namespace).Get("the-task-guid", meta.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				watcher.Modify(resynced)
+				Eventually(taskBifrost.CompleteTaskCallCount).Should(Equal(2))
+			})
+		})
+	})
+
+	Context("When a task job is resynced after its completion was handled", func() {
+		JustBeforeEach(func() {
+			completed := createJob("the-task-guid")
+			completed.Annotations = map[string]string{"completion_handled": "true"}
+			completed.Status.Conditions = []batch.JobCondition{
+				{Type: batch.JobComplete, Status: v1.ConditionTrue},
+			}
+			watcher.Modify(completed)
+		})
+
+		It("should not complete the task again", func() {
+			Consistently(taskBifrost.CompleteTaskCallCount).Should(Equal(0))
+		})
+	})
+
+	Context("When a task job fails", func() {
+		JustBeforeEach(func() {
+			failed := createJob("the-task-guid")
+			failed.Status.Conditions = []batch.JobCondition{
+				{Type: batch.JobFailed, Status: v1.ConditionTrue},
+			}
+			watcher.Modify(failed)
+		})
+
+		It("should complete the task", func() {
+			Eventually(taskBifrost.CompleteTaskCallCount).Should(Equal(1))
+			_, taskGUID := taskBifrost.CompleteTaskArgsForCall(0)
+			Expect(taskGUID).To(Equal("the-task-guid"))
+		})
+	})
+
+	Context("When a staging job", func() {
+		BeforeEach(func() {
+			job = createStagingJob("the-staging-guid")
+		})
+
+		Context("completes", func() {
+			JustBeforeEach(func() {
+				completed := createStagingJob("the-staging-guid")
+				completed.Status.Conditions = []batch.JobCondition{
+					{Type: batch.JobComplete, Status: v1.ConditionTrue},
+				}
+				watcher.Modify(completed)
+			})
+
+			It("should leave reporting to the uploader", func() {
+				Consistently(stager.CompleteStagingCallCount).Should(Equal(0))
+			})
+
+			It("should not complete a task", func() {
+				Consistently(taskBifrost.CompleteTaskCallCount).Should(Equal(0))
+			})
+		})
+
+		Context("fails", func() {
+			BeforeEach(func() {
+				pod := &v1.Pod{
+					ObjectMeta: meta.ObjectMeta{
+						Name:   "the-staging-guid-xyz",
+						Labels: map[string]string{"job-name": "the-staging-guid"},
+					},
+					Status: v1.PodStatus{
+						InitContainerStatuses: []v1.ContainerStatus{
+							{
+								Name: "opi-task-executor",
+								State: v1.ContainerState{
+									Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"},
+								},
+							},
+						},
+					},
+				}
+				_, err := client.CoreV1().Pods(namespace).Create(pod)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			JustBeforeEach(func() {
+				failed := createStagingJob("the-staging-guid")
+				failed.Status.Conditions = []batch.JobCondition{
+					{Type: batch.JobFailed, Status: v1.ConditionTrue},
+				}
+				watcher.Modify(failed)
+			})
+
+			It("should report the failure to the stager", func() {
+				Eventually(stager.CompleteStagingCallCount).Should(Equal(1))
+				Expect(stager.CompleteStagingArgsForCall(0)).To(Equal(&models.TaskCallbackResponse{
+					TaskGuid:      "the-staging-guid",
+					Failed:        true,
+					FailureReason: "opi-task-executor was killed because it ran out of memory",
+					Annotation:    `{"lifecycle":"","completion_callback":"example.com/call/me/maybe"}`,
+				}))
+			})
+
+			It("should record that the failure was handled", func() {
+				Eventually(func() map[string]string {
+					return getAnnotations("the-staging-guid")
+				}).Should(HaveKeyWithValue("completion_handled", "true"))
+			})
+
+			It("should not complete a task", func() {
+				Consistently(taskBifrost.CompleteTaskCallCount).Should(Equal(0))
+			})
+		})
+	})
+})
+
+func createStagingJob(name string) *batch.Job {
+	return &batch.Job{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: "milkyway",
+			Labels: map[string]string{
+				"source_type": "STG",
+			},
+			Annotations: map[string]string{
+				"completion_callback": "example.com/call/me/maybe",
+			},
+		},
+	}
+}
+
+func createJob(name string) *batch.Job {
+	return &batch.Job{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: "milkyway",
+			Labels: map[string]string{
+				"source_type": "TASK",
+			},
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package k8s
+
+import (
+	"strconv"
+
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrIndexNotAssigned is returned for Deployment pods which have not been
+// annotated with their instance index yet
+var ErrIndexNotAssigned = errors.New("pod has not been assigned an instance index yet")
+
+// PodIndex returns the CF instance index of an app pod. Pods of Deployments
+// get their index from an annotation, as their names are random, whereas
+// StatefulSet pods carry it at the end of their name.
+func PodIndex(pod *corev1.Pod) (int, error) {
+	if index, ok := pod.Annotations[eirini.InstanceIndex]; ok {
+		parsed, err := strconv.Atoi(index)
+		return parsed, errors.Wrapf(err, "pod %s has an invalid instance index", pod.Name)
+	}
+
+	if IsOwnedByReplicaSet(pod) {
+		return 0, errors.Wrapf(ErrIndexNotAssigned, "pod %s", pod.Name)
+	}
+
+	return util.ParseAppIndex(pod.Name)
+}
+
+func IsOwnedByReplicaSet(pod *corev1.Pod) bool {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "ReplicaSet" {
+			return true
+		}
+	}
+	return false
+}
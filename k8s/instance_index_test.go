@@ -0,0 +1,52 @@
+package k8s_test
+
+import (
+	"code.cloudfoundry.org/eirini"
+	. "code.cloudfoundry.org/eirini/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("PodIndex", func() {
+
+	var pod *corev1.Pod
+
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Name:            "app-space-random-3",
+				OwnerReferences: []meta.OwnerReference{{Kind: "StatefulSet", Name: "app-space-random"}},
+			},
+		}
+	})
+
+	It("should take the index of StatefulSet pods from their name", func() {
+		Expect(PodIndex(pod)).To(Equal(3))
+	})
+
+	Context("when the pod belongs to a Deployment", func() {
+		BeforeEach(func() {
+			pod.Name = "app-space-random-5d8f7c-22467"
+			pod.OwnerReferences = []meta.OwnerReference{{Kind: "ReplicaSet", Name: "app-space-random-5d8f7c"}}
+		})
+
+		It("should not guess the index from the pod name", func() {
+			_, err := PodIndex(pod)
+			Expect(errors.Cause(err)).To(Equal(ErrIndexNotAssigned))
+		})
+
+		It("should take the index from the annotation once it is assigned", func() {
+			pod.Annotations = map[string]string{eirini.InstanceIndex: "1"}
+			Expect(PodIndex(pod)).To(Equal(1))
+		})
+	})
+
+	It("should fail for an invalid index annotation", func() {
+		pod.Annotations = map[string]string{eirini.InstanceIndex: "one"}
+		_, err := PodIndex(pod)
+		Expect(err).To(MatchError(ContainSubstring("invalid instance index")))
+	})
+})
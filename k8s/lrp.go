@@ -0,0 +1,489 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/k8s/utils"
+	"code.cloudfoundry.org/eirini/models/cf"
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/eirini/rootfspatcher"
+	"code.cloudfoundry.org/eirini/util"
+	"code.cloudfoundry.org/lager"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The helpers in this file are shared by the StatefulSet and Deployment
+// backed desirers, which only differ in the workload that owns the pods
+
+// LRPDesirerOptions configure the StatefulSet and Deployment backed
+// desirers. The zero value of every option is its default.
+type LRPDesirerOptions struct {
+	ConflictPolicy string
+	Placement      PlacementPolicy
+	Security       SecurityPolicy
+	CPU            CPUPolicy
+	Memory         MemoryPolicy
+	Shutdown       ShutdownPolicy
+	Namespacer     Namespacer
+	// UpdatePartition and Cache only apply to the StatefulSet backend.
+	// UpdatePartition is the number of instances which keep the previous
+	// version when an app is updated, until the others are ready.
+	UpdatePartition int32
+	// MaxUnavailable only applies to the Deployment backend, as
+	// StatefulSets replace one instance at a time
+	MaxUnavailable  intstr.IntOrString
+	Cache           *LRPCache
+	NetworkPolicies NetworkPolicySyncer
+	SecurityGroups  SecurityGroupSyncer
+}
+
+func lrpName(hasher util.Hasher, lrp *opi.LRP) string {
+	nameSuffix, err := hasher.Hash(fmt.Sprintf("%s-%s", lrp.GUID, lrp.Version))
+	if err != nil {
+		panic(err)
+	}
+	namePrefix := fmt.Sprintf("%s-%s", lrp.AppName, lrp.SpaceName)
+	namePrefix = utils.SanitizeName(namePrefix, lrp.GUID)
+
+	return fmt.Sprintf("%s-%s", namePrefix, nameSuffix)
+}
+
+func selectorLabels(lrp *opi.LRP) map[string]string {
+	return map[string]string{
+		"guid":        lrp.GUID,
+		"version":     lrp.Version,
+		"source_type": AppSourceType,
+	}
+}
+
+func lrpLabels(lrp *opi.LRP, rootfsVersion string) map[string]string {
+	labels := map[string]string{
+		"guid":                           lrp.GUID,
+		"version":                        lrp.Version,
+		"source_type":                    AppSourceType,
+		rootfspatcher.RootfsVersionLabel: rootfsVersion,
+	}
+	setSpaceLabel(labels, lrp.SpaceGUID)
+	if lrp.OrgGUID != "" {
+		labels[OrgGUIDLabel] = lrp.OrgGUID
+	}
+	return labels
+}
+
+// setSpaceLabel labels pods with their space, which the network policies
+// of application security groups select them by
+func setSpaceLabel(labels map[string]string, spaceGUID string) {
+	if spaceGUID != "" {
+		labels[SpaceGUIDLabel] = spaceGUID
+	}
+}
+
+func lrpAnnotations(lrp *opi.LRP) map[string]string {
+	annotations := lrp.Metadata
+	annotations[eirini.RegisteredRoutes] = lrp.Metadata[cf.VcapAppUris]
+	annotations[cf.VcapSpaceName] = lrp.SpaceName
+	annotations[eirini.OriginalRequest] = lrp.LRP
+	annotations[eirini.CPUWeight] = strconv.Itoa(int(lrp.CPUWeight))
+	setAnnotationList(annotations, eirini.InternalRoutes, lrp.InternalRoutes)
+	setAnnotationList(annotations, eirini.PlacementTags, lrp.PlacementTags)
+	return annotations
+}
+
+// storeLRPDependents writes the objects which hold the parts of an LRP that
+// do not belong in its workload: the env secret and a large original request
+func storeLRPDependents(client kubernetes.Interface, namespace string, objectMeta *meta.ObjectMeta, lrp *opi.LRP, owner *meta.OwnerReference) error {
+	if err := applyEnvSecret(client, namespace, objectMeta.Name, lrp, owner); err != nil {
+		return err
+	}
+	return storeOriginalRequest(client, namespace, objectMeta, lrp.LRP, owner)
+}
+
+// deleteLRPDependents deletes the objects written by storeLRPDependents,
+// which is only needed while they are not owned by a workload
+func deleteLRPDependents(client kubernetes.Interface, namespace, workloadName string) error {
+	if err := deleteEnvSecret(client, namespace, workloadName); err != nil {
+		return err
+	}
+	return deleteRequestConfigMap(client, namespace, workloadName)
+}
+
+// applyWorkloadDependents writes the objects which select the pods of a
+// workload, and so can only be written once it exists: its Services and
+// its PodDisruptionBudget
+func applyWorkloadDependents(client kubernetes.Interface, namespace, workloadName string, selector map[string]string, lrp *opi.LRP, owner *meta.OwnerReference) error {
+	if err := applyServices(client, namespace, workloadName, selector, lrp, owner); err != nil {
+		return err
+	}
+	return applyPodDisruptionBudget(client, namespace, workloadName, selector, lrp, owner)
+}
+
+// deleteWorkloadDependents deletes the objects written by
+// applyWorkloadDependents, so that they do not outlive a stopped app until
+// they are garbage collected
+func deleteWorkloadDependents(client kubernetes.Interface, namespace, workloadName string, selector map[string]string) error {
+	if err := deleteServices(client, namespace, workloadName, selector); err != nil {
+		return err
+	}
+	return deletePodDisruptionBudget(client, namespace, workloadName)
+}
+
+func toPodTemplateSpec(lrp *opi.LRP, name string, livenessProbe, readinessProbe *corev1.Probe) corev1.PodTemplateSpec {
+	volumes, volumeMounts := getVolumeSpecs(lrp.VolumeMounts)
+	automountServiceAccountToken := false
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: meta.ObjectMeta{
+			Annotations: map[string]string{
+				cf.ProcessGUID: lrp.Metadata[cf.ProcessGUID],
+				cf.VcapAppID:   lrp.Metadata[cf.VcapAppID],
+			},
+		},
+		Spec: corev1.PodSpec{
+			AutomountServiceAccountToken: &automountServiceAccountToken,
+			Containers: []corev1.Container{
+				{
+					Name:            "opi",
+					Image:           lrp.Image,
+					ImagePullPolicy: corev1.PullAlways,
+					Command:         lrp.Command,
+					Env:             toAppEnvVars(lrp.Env, envSecretName(name), nil),
+					Ports:           toContainerPorts(lrp.Ports),
+					Resources:       toResourceRequirements(lrp),
+					LivenessProbe:   livenessProbe,
+					ReadinessProbe:  readinessProbe,
+					VolumeMounts:    volumeMounts,
+				},
+			},
+			Volumes: volumes,
+		},
+	}
+}
+
+func applyLRPToPodTemplate(objectMeta *meta.ObjectMeta, template *corev1.PodTemplateSpec, lrp *opi.LRP, livenessProbeCreator, readinessProbeCreator ProbeCreator) {
+	objectMeta.Annotations[cf.LastUpdated] = lrp.Metadata[cf.LastUpdated]
+	objectMeta.Annotations[eirini.RegisteredRoutes] = lrp.Metadata[cf.VcapAppUris]
+	objectMeta.Annotations[eirini.CPUWeight] = strconv.Itoa(int(lrp.CPUWeight))
+	setAnnotationList(objectMeta.Annotations, eirini.InternalRoutes, lrp.InternalRoutes)
+
+	container := &template.Spec.Containers[0]
+	container.Image = lrp.Image
+	var keptEnv []corev1.EnvVar
+	if lrp.KeepSensitiveEnv {
+		keptEnv = container.Env
+	}
+	container.Env = toAppEnvVars(lrp.Env, envSecretName(objectMeta.Name), keptEnv)
+	container.Ports = toContainerPorts(lrp.Ports)
+	container.Resources = toResourceRequirements(lrp)
+	container.LivenessProbe = livenessProbeCreator(lrp)
+	container.ReadinessProbe = readinessProbeCreator(lrp)
+}
+
+func toLRP(objectMeta meta.ObjectMeta, template corev1.PodTemplateSpec, replicas *int32, readyReplicas int32) *opi.LRP {
+	ports := []int32{}
+	container := template.Spec.Containers[0]
+
+	for _, port := range container.Ports {
+		ports = append(ports, port.ContainerPort)
+	}
+
+	memory := container.Resources.Limits.Memory().ScaledValue(resource.Mega)
+	disk := container.Resources.Limits.StorageEphemeral().ScaledValue(resource.Mega)
+	// the CPU request only tells the weight of apps desired before it was
+	// annotated, when the shares policy was the only one
+	cpuWeight, err := strconv.Atoi(objectMeta.Annotations[eirini.CPUWeight])
+	if err != nil {
+		cpuWeight = int(container.Resources.Requests.Cpu().MilliValue() / millicoresPerWeight)
+	}
+	volMounts := []opi.VolumeMount{}
+	for _, vol := range container.VolumeMounts {
+		volMounts = append(volMounts, opi.VolumeMount{
+			ClaimName: vol.Name,
+			MountPath: vol.MountPath,
+		})
+	}
+
+	targetInstances := 0
+	if replicas != nil {
+		targetInstances = int(*replicas)
+	}
+
+	annotations := objectMeta.Annotations
+	return &opi.LRP{
+		LRPIdentifier: opi.LRPIdentifier{
+			GUID:    annotations[cf.VcapAppID],
+			Version: annotations[cf.VcapVersion],
+		},
+		AppName:          annotations[cf.VcapAppName],
+		SpaceName:        annotations[cf.VcapSpaceName],
+		SpaceGUID:        objectMeta.Labels[SpaceGUIDLabel],
+		OrgGUID:          objectMeta.Labels[OrgGUIDLabel],
+		Image:            container.Image,
+		Command:          container.Command,
+		Env:              envVarsToMap(container.Env),
+		Health:           probeToHealthcheck(container.LivenessProbe, container.ReadinessProbe),
+		TargetInstances:  targetInstances,
+		RunningInstances: int(readyReplicas),
+		Ports:            ports,
+		InternalRoutes:   parseAnnotationList(annotations[eirini.InternalRoutes]),
+		Metadata: map[string]string{
+			cf.ProcessGUID: annotations[cf.ProcessGUID],
+			cf.LastUpdated: annotations[cf.LastUpdated],
+			cf.VcapAppUris: annotations[cf.VcapAppUris],
+			cf.VcapAppID:   annotations[cf.VcapAppID],
+			cf.VcapVersion: annotations[cf.VcapVersion],
+			cf.VcapAppName: annotations[cf.VcapAppName],
+		},
+		MemoryMB:      memory,
+		DiskMB:        disk,
+		CPUWeight:     uint8(cpuWeight),
+		VolumeMounts:  volMounts,
+		PlacementTags: parseAnnotationList(annotations[eirini.PlacementTags]),
+		LRP:           annotations[eirini.OriginalRequest],
+	}
+}
+
+// setAnnotationList records a list, like the internal routes of an LRP, on
+// its workload, so that it is known when the LRP is read back
+func setAnnotationList(annotations map[string]string, key string, values []string) {
+	if len(values) == 0 {
+		delete(annotations, key)
+		return
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		panic(err)
+	}
+	annotations[key] = string(data)
+}
+
+func parseAnnotationList(annotation string) []string {
+	if annotation == "" {
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal([]byte(annotation), &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+func toInstances(reader lrpReader, pods []corev1.Pod, logger lager.Logger) ([]*opi.Instance, error) {
+	instances := []*opi.Instance{}
+	zones := map[string]string{}
+	for _, pod := range pods {
+		events, err := reader.getEvents(pod)
+		if err != nil {
+			logger.Error("failed-to-get-k8s-events", err, lager.Data{"pod-name": pod.Name})
+			return []*opi.Instance{}, ToOpiError(err, "failed to get events")
+		}
+
+		if IsStopped(events) {
+			continue
+		}
+
+		index, err := PodIndex(&pod)
+		if errors.Cause(err) == ErrIndexNotAssigned {
+			logger.Debug("pod-without-index", lager.Data{"pod-name": pod.Name})
+			continue
+		}
+		if err != nil {
+			return []*opi.Instance{}, err
+		}
+
+		since := int64(0)
+		if pod.Status.StartTime != nil {
+			since = pod.Status.StartTime.UnixNano()
+		}
+
+		var state, placementError string
+		if hasInsufficientMemory(events) {
+			state, placementError = opi.ErrorState, insufficientMemoryError(pod)
+		} else {
+			state = utils.GetPodState(pod)
+		}
+
+		instance := opi.Instance{
+			Since:          since,
+			Index:          index,
+			State:          state,
+			PlacementError: placementError,
+			Zone:           instanceZone(reader, pod, zones, logger),
+		}
+		instances = append(instances, &instance)
+	}
+
+	return instances, nil
+}
+
+// instanceZone returns the zone of the node that runs a pod. The zones of
+// nodes are remembered in zones, as instances share nodes.
+func instanceZone(reader lrpReader, pod corev1.Pod, zones map[string]string, logger lager.Logger) string {
+	nodeName := pod.Spec.NodeName
+	if nodeName == "" {
+		return ""
+	}
+	if zone, ok := zones[nodeName]; ok {
+		return zone
+	}
+
+	node, err := reader.getNode(nodeName)
+	if err != nil {
+		logger.Error("failed-to-get-node", err, lager.Data{"node-name": nodeName})
+		return ""
+	}
+	zones[nodeName] = nodeZone(node)
+	return zones[nodeName]
+}
+
+func hasInsufficientMemory(eventList *corev1.EventList) bool {
+	events := eventList.Items
+
+	if len(events) == 0 {
+		return false
+	}
+
+	event := events[len(events)-1]
+	return event.Reason == eventFailedScheduling && strings.Contains(event.Message, "Insufficient memory")
+}
+
+func envVarsToMap(envVars []corev1.EnvVar) map[string]string {
+	env := map[string]string{}
+	for _, e := range envVars {
+		if e.ValueFrom != nil {
+			continue
+		}
+		env[e.Name] = e.Value
+	}
+	return env
+}
+
+func probeToHealthcheck(probe, readinessProbe *corev1.Probe) opi.Healtcheck {
+	if probe == nil {
+		return opi.Healtcheck{}
+	}
+
+	health := opi.Healtcheck{
+		TimeoutMs:           uint(probe.InitialDelaySeconds) * 1000,
+		InvocationTimeoutMs: uint(probe.TimeoutSeconds) * 1000,
+		IntervalMs:          uint(probe.PeriodSeconds) * 1000,
+	}
+	switch {
+	case probe.HTTPGet != nil:
+		health.Type = opi.HTTPHealthCheck
+		health.Endpoint = probe.HTTPGet.Path
+		health.Port = probe.HTTPGet.Port.IntVal
+		if readinessProbe != nil && readinessProbe.HTTPGet != nil && readinessProbe.HTTPGet.Path != health.Endpoint {
+			health.ReadinessEndpoint = readinessProbe.HTTPGet.Path
+		}
+	case probe.TCPSocket != nil:
+		health.Type = opi.PortHealthCheck
+		health.Port = probe.TCPSocket.Port.IntVal
+	case probe.Exec != nil:
+		health.Type = opi.ExecHealthCheck
+		health.Command = probe.Exec.Command
+	default:
+		return opi.Healtcheck{}
+	}
+	return health
+}
+
+func toEnvVars(env map[string]string) []corev1.EnvVar {
+	envs := MapToEnvVar(env)
+	fieldEnvs := []corev1.EnvVar{
+		{
+			Name: "POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "metadata.name",
+				},
+			},
+		},
+		{
+			Name: "CF_INSTANCE_IP",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "status.podIP",
+				},
+			},
+		},
+		{
+			Name: "CF_INSTANCE_INTERNAL_IP",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "status.podIP",
+				},
+			},
+		},
+	}
+
+	return append(envs, fieldEnvs...)
+}
+
+func toContainerPorts(lrpPorts []int32) []corev1.ContainerPort {
+	ports := []corev1.ContainerPort{}
+	for _, port := range lrpPorts {
+		ports = append(ports, corev1.ContainerPort{ContainerPort: port})
+	}
+	return ports
+}
+
+func toResourceRequirements(lrp *opi.LRP) corev1.ResourceRequirements {
+	memory, err := resource.ParseQuantity(fmt.Sprintf("%dM", lrp.MemoryMB))
+	if err != nil {
+		panic(err)
+	}
+
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: memory,
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: memory,
+		},
+	}
+
+	if lrp.DiskMB > 0 {
+		disk := resource.MustParse(fmt.Sprintf("%dM", lrp.DiskMB))
+		resources.Limits[corev1.ResourceEphemeralStorage] = disk
+		resources.Requests[corev1.ResourceEphemeralStorage] = disk
+	}
+
+	return resources
+}
+
+// applyResourcePolicies sets the CPU of the app container, and overcommits
+// the memory request that toResourceRequirements sets to the memory quota
+func applyResourcePolicies(template *corev1.PodTemplateSpec, lrp *opi.LRP, cpu CPUPolicy, memory MemoryPolicy) {
+	resources := &template.Spec.Containers[0].Resources
+	cpu.apply(resources, lrp.MemoryMB, lrp.CPUWeight)
+	memory.apply(resources, lrp)
+}
+
+func getVolumeSpecs(lrpVolumeMounts []opi.VolumeMount) ([]corev1.Volume, []corev1.VolumeMount) {
+	volumes := []corev1.Volume{}
+	volumeMounts := []corev1.VolumeMount{}
+	for _, vm := range lrpVolumeMounts {
+		volumes = append(volumes, corev1.Volume{
+			Name: vm.ClaimName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: vm.ClaimName,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      vm.ClaimName,
+			MountPath: vm.MountPath,
+		})
+	}
+	return volumes, volumeMounts
+}
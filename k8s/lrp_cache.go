@@ -0,0 +1,176 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/eirini/opi"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	lrpIndex            = "lrp"
+	involvedObjectIndex = "involved-object"
+)
+
+// LRPCache keeps informer backed copies of the StatefulSets, Pods and Events
+// of LRPs, and of the Nodes they run on, so that the StatefulSetDesirer can
+// serve reads without listing them from the API server on every request
+type LRPCache struct {
+	statefulSets cache.SharedIndexInformer
+	pods         cache.SharedIndexInformer
+	events       cache.SharedIndexInformer
+	nodes        cache.SharedIndexInformer
+}
+
+func NewLRPCache(client kubernetes.Interface, namespace string, resyncPeriod time.Duration) *LRPCache {
+	appFactory := informers.NewSharedInformerFactoryWithOptions(
+		client,
+		resyncPeriod,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *meta.ListOptions) {
+			options.LabelSelector = fmt.Sprintf("source_type=%s", AppSourceType)
+		}),
+	)
+	eventFactory := informers.NewSharedInformerFactoryWithOptions(
+		client,
+		resyncPeriod,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *meta.ListOptions) {
+			options.FieldSelector = "involvedObject.kind=Pod"
+		}),
+	)
+
+	// nodes are not namespaced
+	nodeFactory := informers.NewSharedInformerFactory(client, resyncPeriod)
+
+	statefulSets := appFactory.Apps().V1().StatefulSets().Informer()
+	pods := appFactory.Core().V1().Pods().Informer()
+	events := eventFactory.Core().V1().Events().Informer()
+	nodes := nodeFactory.Core().V1().Nodes().Informer()
+
+	mustAddIndexers(statefulSets, cache.Indexers{lrpIndex: lrpIndexFunc})
+	mustAddIndexers(pods, cache.Indexers{lrpIndex: lrpIndexFunc})
+	mustAddIndexers(events, cache.Indexers{involvedObjectIndex: involvedObjectIndexFunc})
+
+	return &LRPCache{
+		statefulSets: statefulSets,
+		pods:         pods,
+		events:       events,
+		nodes:        nodes,
+	}
+}
+
+// Run starts the informers and returns immediately. Use HasSynced to find out
+// when the cache is safe to read from.
+func (c *LRPCache) Run(stopCh <-chan struct{}) {
+	go c.statefulSets.Run(stopCh)
+	go c.pods.Run(stopCh)
+	go c.events.Run(stopCh)
+	go c.nodes.Run(stopCh)
+}
+
+// HasSynced leaves out the nodes, which only tell the zones of instances.
+// Deployments which may not list nodes report instances without zones.
+func (c *LRPCache) HasSynced() bool {
+	return c.statefulSets.HasSynced() && c.pods.HasSynced() && c.events.HasSynced()
+}
+
+func (c *LRPCache) listStatefulSets() ([]appsv1.StatefulSet, error) {
+	statefulSets := []appsv1.StatefulSet{}
+	for _, obj := range c.statefulSets.GetStore().List() {
+		statefulSets = append(statefulSets, *obj.(*appsv1.StatefulSet))
+	}
+	return statefulSets, nil
+}
+
+func (c *LRPCache) getStatefulSets(identifier opi.LRPIdentifier) ([]appsv1.StatefulSet, error) {
+	objs, err := c.statefulSets.GetIndexer().ByIndex(lrpIndex, identifier.ProcessGUID())
+	if err != nil {
+		return nil, err
+	}
+
+	statefulSets := []appsv1.StatefulSet{}
+	for _, obj := range objs {
+		statefulSets = append(statefulSets, *obj.(*appsv1.StatefulSet))
+	}
+	return statefulSets, nil
+}
+
+func (c *LRPCache) getPods(identifier opi.LRPIdentifier) ([]corev1.Pod, error) {
+	objs, err := c.pods.GetIndexer().ByIndex(lrpIndex, identifier.ProcessGUID())
+	if err != nil {
+		return nil, err
+	}
+
+	pods := []corev1.Pod{}
+	for _, obj := range objs {
+		pods = append(pods, *obj.(*corev1.Pod))
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+func (c *LRPCache) getEvents(pod corev1.Pod) (*corev1.EventList, error) {
+	objs, err := c.events.GetIndexer().ByIndex(involvedObjectIndex, string(pod.UID))
+	if err != nil {
+		return nil, err
+	}
+
+	events := []corev1.Event{}
+	for _, obj := range objs {
+		events = append(events, *obj.(*corev1.Event))
+	}
+
+	// callers look at the most recent event, which the API server would
+	// have listed last
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].LastTimestamp.Equal(&events[j].LastTimestamp) {
+			return events[i].LastTimestamp.Before(&events[j].LastTimestamp)
+		}
+		return events[i].Name < events[j].Name
+	})
+	return &corev1.EventList{Items: events}, nil
+}
+
+func (c *LRPCache) getNode(name string) (*corev1.Node, error) {
+	obj, exists, err := c.nodes.GetStore().GetByKey(name)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return obj.(*corev1.Node), nil
+}
+
+func lrpIndexFunc(obj interface{}) ([]string, error) {
+	object, err := apimeta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := object.GetLabels()
+	identifier := opi.LRPIdentifier{GUID: labels["guid"], Version: labels["version"]}
+	return []string{identifier.ProcessGUID()}, nil
+}
+
+func involvedObjectIndexFunc(obj interface{}) ([]string, error) {
+	event, ok := obj.(*corev1.Event)
+	if !ok {
+		return nil, fmt.Errorf("expected an event, got %T", obj)
+	}
+	return []string{string(event.InvolvedObject.UID)}, nil
+}
+
+func mustAddIndexers(informer cache.SharedIndexInformer, indexers cache.Indexers) {
+	if err := informer.AddIndexers(indexers); err != nil {
+		panic(err)
+	}
+}
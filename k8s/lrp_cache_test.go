@@ -0,0 +1,202 @@
+package k8s_test
+
+import (
+	"errors"
+
+	. "code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	testcore "k8s.io/client-go/testing"
+)
+
+var _ = Describe("LRPCache", func() {
+
+	var (
+		client   *fake.Clientset
+		lrpCache *LRPCache
+		desirer  *StatefulSetDesirer
+		stopCh   chan struct{}
+		lrp      *opi.LRP
+	)
+
+	appPod := func(index int, since *meta.Time) *corev1.Pod {
+		pod := toPod("baldur-space-foo-random", index, since)
+		pod.Labels["source_type"] = "APP"
+		return pod
+	}
+
+	failListCalls := func(resource string) {
+		client.PrependReactor("list", resource, func(action testcore.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("the cache should have been used")
+		})
+	}
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset()
+		lrp = createLRP("Baldur", "my.example.route")
+
+		_, err := client.AppsV1().StatefulSets(namespace).Create(toStatefulSet(lrp))
+		Expect(err).ToNot(HaveOccurred())
+
+		since := meta.Unix(123, 0)
+		for i := 0; i < 2; i++ {
+			_, err = client.CoreV1().Pods(namespace).Create(appPod(i, &since))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		stopCh = make(chan struct{})
+		lrpCache = NewLRPCache(client, namespace, 0)
+		lrpCache.Run(stopCh)
+		Eventually(lrpCache.HasSynced).Should(BeTrue())
+
+		desirer = &StatefulSetDesirer{
+			Client:    client,
+			Namespace: namespace,
+			Cache:     lrpCache,
+			Logger:    lagertest.NewTestLogger("lrp-cache-test"),
+		}
+	})
+
+	AfterEach(func() {
+		close(stopCh)
+	})
+
+	It("should list apps from the cache", func() {
+		failListCalls("statefulsets")
+
+		lrps, err := desirer.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lrps).To(HaveLen(1))
+		Expect(lrps[0].AppName).To(Equal("Baldur"))
+	})
+
+	It("should get an app from the cache", func() {
+		failListCalls("statefulsets")
+
+		actualLRP, err := desirer.Get(lrp.LRPIdentifier)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualLRP.ProcessGUID()).To(Equal(lrp.ProcessGUID()))
+	})
+
+	It("should return not found for apps which are not cached", func() {
+		_, err := desirer.Get(opi.LRPIdentifier{GUID: "not", Version: "cached"})
+		Expect(opi.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should get instances from the cache", func() {
+		failListCalls("pods")
+		failListCalls("events")
+
+		instances, err := desirer.GetInstances(lrp.LRPIdentifier)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(Equal([]*opi.Instance{
+			toInstance(0, 123000000000),
+			toInstance(1, 123000000000),
+		}))
+	})
+
+	It("should get the zones of instances from the cache", func() {
+		_, err := client.CoreV1().Nodes().Create(&corev1.Node{
+			ObjectMeta: meta.ObjectMeta{Name: "node-a", Labels: map[string]string{ZoneLabel: "zone-a"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		pod := appPod(2, nil)
+		pod.Spec.NodeName = "node-a"
+		_, err = client.CoreV1().Pods(namespace).Create(pod)
+		Expect(err).ToNot(HaveOccurred())
+		client.PrependReactor("get", "nodes", func(action testcore.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("the cache should have been used")
+		})
+
+		Eventually(func() []string {
+			instances, getErr := desirer.GetInstances(lrp.LRPIdentifier)
+			Expect(getErr).ToNot(HaveOccurred())
+			zones := []string{}
+			for _, instance := range instances {
+				zones = append(zones, instance.Zone)
+			}
+			return zones
+		}).Should(Equal([]string{"", "", "zone-a"}))
+	})
+
+	It("should sync and report no zones when nodes cannot be listed", func() {
+		_, err := client.CoreV1().Nodes().Create(&corev1.Node{
+			ObjectMeta: meta.ObjectMeta{Name: "node-a", Labels: map[string]string{ZoneLabel: "zone-a"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		pod := appPod(2, nil)
+		pod.Spec.NodeName = "node-a"
+		_, err = client.CoreV1().Pods(namespace).Create(pod)
+		Expect(err).ToNot(HaveOccurred())
+		client.PrependReactor("list", "nodes", func(action testcore.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("nodes is forbidden")
+		})
+
+		nodelessCache := NewLRPCache(client, namespace, 0)
+		nodelessCache.Run(stopCh)
+		Eventually(nodelessCache.HasSynced).Should(BeTrue())
+		desirer.Cache = nodelessCache
+
+		instances, err := desirer.GetInstances(lrp.LRPIdentifier)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(HaveLen(3))
+		for _, instance := range instances {
+			Expect(instance.Zone).To(BeEmpty())
+		}
+	})
+
+	It("should pick up changes made after it synced", func() {
+		_, err := client.CoreV1().Pods(namespace).Create(appPod(2, nil))
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() ([]*opi.Instance, error) {
+			return desirer.GetInstances(lrp.LRPIdentifier)
+		}).Should(HaveLen(3))
+	})
+
+	It("should skip instances whose latest event says they are stopping", func() {
+		_, err := client.CoreV1().Events(namespace).Create(&corev1.Event{
+			ObjectMeta: meta.ObjectMeta{Name: "scheduled"},
+			Reason:     "Scheduled",
+			InvolvedObject: corev1.ObjectReference{
+				Kind: "Pod",
+				Name: "baldur-space-foo-random-1",
+				UID:  "baldur-space-foo-random-1-uid",
+			},
+			LastTimestamp: meta.Unix(100, 0),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = client.CoreV1().Events(namespace).Create(&corev1.Event{
+			ObjectMeta: meta.ObjectMeta{Name: "killing"},
+			Reason:     "Killing",
+			InvolvedObject: corev1.ObjectReference{
+				Kind: "Pod",
+				Name: "baldur-space-foo-random-1",
+				UID:  "baldur-space-foo-random-1-uid",
+			},
+			LastTimestamp: meta.Unix(200, 0),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() ([]*opi.Instance, error) {
+			return desirer.GetInstances(lrp.LRPIdentifier)
+		}).Should(Equal([]*opi.Instance{toInstance(0, 123000000000)}))
+	})
+
+	It("should stop an instance of a cached app", func() {
+		failListCalls("statefulsets")
+
+		Expect(desirer.StopInstance(lrp.LRPIdentifier, 1)).To(Succeed())
+
+		pods, err := client.CoreV1().Pods(namespace).List(meta.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal("baldur-space-foo-random-0"))
+	})
+})
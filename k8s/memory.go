@@ -0,0 +1,88 @@
+package k8s
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/eirini/opi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// MemoryPolicy overcommits the memory of nodes. Apps are always limited to
+// their memory quota, but only request RequestRatio of it, and at least
+// MinRequestMB. OrgRequestRatios override the ratio for the apps of orgs.
+// The zero value requests the whole quota.
+type MemoryPolicy struct {
+	RequestRatio     float64
+	MinRequestMB     int64
+	OrgRequestRatios map[string]float64
+}
+
+func NewMemoryPolicy(requestRatio float64, minRequestMB int64, orgRequestRatios map[string]float64) (MemoryPolicy, error) {
+	if err := validateRequestRatio(requestRatio); err != nil {
+		return MemoryPolicy{}, err
+	}
+	for org, ratio := range orgRequestRatios {
+		if err := validateRequestRatio(ratio); err != nil {
+			return MemoryPolicy{}, fmt.Errorf("org %s: %s", org, err)
+		}
+	}
+	if minRequestMB < 0 {
+		return MemoryPolicy{}, fmt.Errorf("invalid minimum memory request %dMB", minRequestMB)
+	}
+	return MemoryPolicy{
+		RequestRatio:     requestRatio,
+		MinRequestMB:     minRequestMB,
+		OrgRequestRatios: orgRequestRatios,
+	}, nil
+}
+
+func validateRequestRatio(ratio float64) error {
+	if ratio < 0 || ratio > 1 {
+		return fmt.Errorf("invalid memory request ratio %g", ratio)
+	}
+	return nil
+}
+
+// RequestMB is the memory an LRP requests for each of its instances
+func (p MemoryPolicy) RequestMB(lrp *opi.LRP) int64 {
+	ratio := p.RequestRatio
+	if orgRatio, ok := p.OrgRequestRatios[lrp.OrgGUID]; ok {
+		ratio = orgRatio
+	}
+	if ratio == 0 || ratio == 1 {
+		return lrp.MemoryMB
+	}
+
+	request := int64(float64(lrp.MemoryMB) * ratio)
+	if request < p.MinRequestMB {
+		request = p.MinRequestMB
+	}
+	if request > lrp.MemoryMB {
+		request = lrp.MemoryMB
+	}
+	return request
+}
+
+func (p MemoryPolicy) apply(resources *corev1.ResourceRequirements, lrp *opi.LRP) {
+	request := p.RequestMB(lrp)
+	if request == lrp.MemoryMB {
+		return
+	}
+	resources.Requests[corev1.ResourceMemory] = resource.MustParse(fmt.Sprintf("%dM", request))
+}
+
+// insufficientMemoryError explains when an instance could not be placed
+// because the memory policy made it request less memory than its limit
+func insufficientMemoryError(pod corev1.Pod) string {
+	if len(pod.Spec.Containers) == 0 {
+		return opi.InsufficientMemoryError
+	}
+
+	resources := pod.Spec.Containers[0].Resources
+	request, limit := resources.Requests.Memory(), resources.Limits.Memory()
+	if request.IsZero() || request.Cmp(*limit) == 0 {
+		return opi.InsufficientMemoryError
+	}
+	return fmt.Sprintf("%s (the memory overcommit policy requests %s of the %s limit)", opi.InsufficientMemoryError, request, limit)
+}
@@ -0,0 +1,76 @@
+package k8s_test
+
+import (
+	. "code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/eirini/opi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryPolicy", func() {
+
+	var (
+		policy MemoryPolicy
+		lrp    *opi.LRP
+	)
+
+	BeforeEach(func() {
+		policy = MemoryPolicy{}
+		lrp = &opi.LRP{OrgGUID: "org-guid", MemoryMB: 1024}
+	})
+
+	It("should request the memory quota by default", func() {
+		Expect(policy.RequestMB(lrp)).To(Equal(int64(1024)))
+	})
+
+	Context("When a request ratio is configured", func() {
+
+		BeforeEach(func() {
+			policy.RequestRatio = 0.25
+		})
+
+		It("should request that ratio of the memory quota", func() {
+			Expect(policy.RequestMB(lrp)).To(Equal(int64(256)))
+		})
+
+		Context("and a minimum request", func() {
+
+			BeforeEach(func() {
+				policy.MinRequestMB = 512
+			})
+
+			It("should request at least the minimum", func() {
+				Expect(policy.RequestMB(lrp)).To(Equal(int64(512)))
+			})
+
+			It("should never request more than the memory quota", func() {
+				lrp.MemoryMB = 128
+				Expect(policy.RequestMB(lrp)).To(Equal(int64(128)))
+			})
+		})
+
+		Context("and the org of the app overrides it", func() {
+
+			BeforeEach(func() {
+				policy.OrgRequestRatios = map[string]float64{"org-guid": 0.5}
+			})
+
+			It("should request the ratio of the org", func() {
+				Expect(policy.RequestMB(lrp)).To(Equal(int64(512)))
+			})
+		})
+	})
+
+	Context("When creating a policy", func() {
+
+		It("should reject request ratios above 1", func() {
+			_, err := NewMemoryPolicy(1.5, 0, nil)
+			Expect(err).To(MatchError("invalid memory request ratio 1.5"))
+		})
+
+		It("should reject invalid org request ratios", func() {
+			_, err := NewMemoryPolicy(0.5, 0, map[string]float64{"org-guid": -1})
+			Expect(err).To(MatchError("org org-guid: invalid memory request ratio -1"))
+		})
+	})
+})
@@ -5,7 +5,6 @@ import (
 
 	"code.cloudfoundry.org/eirini/metrics"
 	"code.cloudfoundry.org/eirini/route"
-	"code.cloudfoundry.org/eirini/util"
 	"code.cloudfoundry.org/lager"
 	"golang.org/x/xerrors"
 	apiv1 "k8s.io/api/core/v1"
@@ -18,12 +17,12 @@ import (
 type MetricsCollector struct {
 	work          chan<- []metrics.Message
 	metricsClient metricsv1beta1.PodMetricsInterface
-	podClient     typedv1.PodInterface
+	podClient     typedv1.PodsGetter
 	scheduler     route.TaskScheduler
 	logger        lager.Logger
 }
 
-func NewMetricsCollector(work chan []metrics.Message, scheduler route.TaskScheduler, metricsClient metricsv1beta1.PodMetricsInterface, podClient typedv1.PodInterface, logger lager.Logger) *MetricsCollector {
+func NewMetricsCollector(work chan []metrics.Message, scheduler route.TaskScheduler, metricsClient metricsv1beta1.PodMetricsInterface, podClient typedv1.PodsGetter, logger lager.Logger) *MetricsCollector {
 	return &MetricsCollector{
 		work:          work,
 		metricsClient: metricsClient,
@@ -35,7 +34,7 @@ func NewMetricsCollector(work chan []metrics.Message, scheduler route.TaskSchedu
 
 func (c *MetricsCollector) Start() {
 	c.scheduler.Schedule(func() error {
-		metrics, err := c.metricsClient.List(metav1.ListOptions{})
+		metrics, err := c.metricsClient.List(metav1.ListOptions{LabelSelector: "source_type=APP"})
 		if err != nil {
 			return xerrors.Errorf("%w", err)
 		}
@@ -57,32 +56,52 @@ func (c *MetricsCollector) convertMetricsList(podMetrics *metricsv1beta1api.PodM
 			continue
 		}
 		container := metric.Containers[0]
-		indexID, err := util.ParseAppIndex(metric.Name)
+		pod, err := c.podClient.Pods(metric.Namespace).Get(metric.Name, metav1.GetOptions{})
+		if err != nil {
+			c.logger.Info("cannot-find-pod", lager.Data{"pod": metric.Name})
+			continue
+		}
+
+		indexID, err := PodIndex(pod)
 		if err != nil {
 			c.logger.Info("incorrect-pod-name", lager.Data{"pod": metric.Name})
 			continue
 		}
 		usage := container.Usage
 		res := usage[apiv1.ResourceCPU]
-		cpuValue := res.Value()
+		cpuValue := cpuPercentage(res.MilliValue(), pod)
 		res = usage[apiv1.ResourceMemory]
 		memoryValue := res.Value()
-
-		pod, err := c.podClient.Get(metric.Name, metav1.GetOptions{})
-		if err != nil {
-			c.logger.Info("cannot-find-pod", lager.Data{"pod": metric.Name})
-			continue
+		diskQuota := int64(0)
+		if len(pod.Spec.Containers) > 0 {
+			diskQuota = pod.Spec.Containers[0].Resources.Limits.StorageEphemeral().Value()
 		}
 
 		messages = append(messages, metrics.Message{
 			AppID:       pod.Labels["guid"],
 			IndexID:     strconv.Itoa(indexID),
-			CPU:         float64(cpuValue),
+			CPU:         cpuValue,
 			Memory:      float64(memoryValue),
 			MemoryQuota: 10,
 			Disk:        42000000,
-			DiskQuota:   10,
+			DiskQuota:   float64(diskQuota),
 		})
 	}
 	return messages
 }
+
+// cpuPercentage is the CPU usage of an instance in percent of its CPU
+// entitlement, which is its limit, or else its request. Instances without
+// either are entitled to one core.
+func cpuPercentage(usageMillicores int64, pod *apiv1.Pod) float64 {
+	entitlement := int64(1000)
+	if len(pod.Spec.Containers) > 0 {
+		resources := pod.Spec.Containers[0].Resources
+		if limit := resources.Limits.Cpu().MilliValue(); limit > 0 {
+			entitlement = limit
+		} else if request := resources.Requests.Cpu().MilliValue(); request > 0 {
+			entitlement = request
+		}
+	}
+	return float64(usageMillicores) * 100 / float64(entitlement)
+}
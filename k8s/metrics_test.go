@@ -41,6 +41,7 @@ var _ = Describe("Metrics", func() {
 		brokenMetrics    metricsv1beta1api.PodMetrics
 		wrongNameMetrics metricsv1beta1api.PodMetrics
 		podlessMetrics   metricsv1beta1api.PodMetrics
+		client           *fake.Clientset
 	)
 
 	BeforeEach(func() {
@@ -48,7 +49,7 @@ var _ = Describe("Metrics", func() {
 		metricsClient = &metricsfake.Clientset{}
 		podMetricsClient = metricsClient.MetricsV1beta1().PodMetricses("opi")
 
-		client := fake.NewSimpleClientset()
+		client = fake.NewSimpleClientset()
 		podClient = client.CoreV1().Pods("opi")
 		validMetrics = createPodForMetrics(podName)
 		wrongNameMetrics = createPodForMetrics("iamstagingtask")
@@ -61,7 +62,7 @@ var _ = Describe("Metrics", func() {
 	JustBeforeEach(func() {
 		scheduler = new(routefakes.FakeTaskScheduler)
 		work = make(chan []metrics.Message, 1)
-		collector = NewMetricsCollector(work, scheduler, podMetricsClient, podClient, logger)
+		collector = NewMetricsCollector(work, scheduler, podMetricsClient, client.CoreV1(), logger)
 	})
 
 	Context("When collecting metrics", func() {
@@ -92,11 +93,11 @@ var _ = Describe("Metrics", func() {
 				{
 					AppID:       "app-guid",
 					IndexID:     "9000",
-					CPU:         420,
+					CPU:         50,
 					Memory:      430080,
 					MemoryQuota: 10,
 					Disk:        42000000,
-					DiskQuota:   10,
+					DiskQuota:   1000000000,
 				},
 			})))
 		})
@@ -213,11 +214,11 @@ var _ = Describe("Metrics", func() {
 					{
 						AppID:       "app-guid",
 						IndexID:     "9000",
-						CPU:         420,
+						CPU:         50,
 						Memory:      430080,
 						MemoryQuota: 10,
 						Disk:        42000000,
-						DiskQuota:   10,
+						DiskQuota:   1000000000,
 					},
 				})))
 			})
@@ -233,10 +234,22 @@ func createPodForMetrics(podName string) metricsv1beta1api.PodMetrics {
 				"guid": "app-guid",
 			},
 		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Resources: v1.ResourceRequirements{
+						Limits: v1.ResourceList{
+							v1.ResourceCPU:              resource.MustParse("840"),
+							v1.ResourceEphemeralStorage: resource.MustParse("1G"),
+						},
+					},
+				},
+			},
+		},
 	})
 	Expect(createErr).ToNot(HaveOccurred())
 	return metricsv1beta1api.PodMetrics{
-		ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: "opi", ResourceVersion: "10", Labels: map[string]string{"key": "value"}},
+		ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: "opi", ResourceVersion: "10", Labels: map[string]string{"key": "value", "source_type": "APP"}},
 		Containers: []metricsv1beta1api.ContainerMetrics{
 			{
 				Usage: v1.ResourceList{
@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"fmt"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// NamespaceStrategySingle runs every app in the configured namespace
+	NamespaceStrategySingle = "single"
+	// NamespaceStrategyPerOrg runs the apps of each CF org in a namespace of its own
+	NamespaceStrategyPerOrg = "per-org"
+	// NamespaceStrategyPerSpace runs the apps of each CF space in a namespace of its own
+	NamespaceStrategyPerSpace = "per-space"
+
+	OrgGUIDLabel   = "org_guid"
+	SpaceGUIDLabel = "space_guid"
+)
+
+//go:generate counterfeiter . Namespacer
+type Namespacer interface {
+	// EnsureNamespace returns the namespace that the workloads of the given
+	// org and space belong to, creating it when it does not exist yet
+	EnsureNamespace(orgGUID, spaceGUID string) (string, error)
+	// WatchNamespace returns the namespace which contains every workload,
+	// which is all namespaces unless a single namespace is used
+	WatchNamespace() string
+}
+
+// NamespaceMapper maps CF orgs and spaces to namespaces according to a
+// strategy. Workloads which lack the guid that the strategy needs go to the
+// default namespace.
+type NamespaceMapper struct {
+	Client           kubernetes.Interface
+	Strategy         string
+	DefaultNamespace string
+	Logger           lager.Logger
+
+	mutex   sync.Mutex
+	ensured map[string]bool
+}
+
+func NewNamespaceMapper(client kubernetes.Interface, strategy string, defaultNamespace string, logger lager.Logger) (*NamespaceMapper, error) {
+	switch strategy {
+	case "":
+		strategy = NamespaceStrategySingle
+	case NamespaceStrategySingle, NamespaceStrategyPerOrg, NamespaceStrategyPerSpace:
+	default:
+		return nil, fmt.Errorf("unsupported namespace strategy %q", strategy)
+	}
+
+	return &NamespaceMapper{
+		Client:           client,
+		Strategy:         strategy,
+		DefaultNamespace: defaultNamespace,
+		Logger:           logger,
+	}, nil
+}
+
+func (m *NamespaceMapper) NamespaceFor(orgGUID, spaceGUID string) string {
+	switch {
+	case m.Strategy == NamespaceStrategyPerOrg && orgGUID != "":
+		return fmt.Sprintf("%s-org-%s", m.DefaultNamespace, orgGUID)
+	case m.Strategy == NamespaceStrategyPerSpace && spaceGUID != "":
+		return fmt.Sprintf("%s-space-%s", m.DefaultNamespace, spaceGUID)
+	default:
+		return m.DefaultNamespace
+	}
+}
+
+func (m *NamespaceMapper) EnsureNamespace(orgGUID, spaceGUID string) (string, error) {
+	name := m.NamespaceFor(orgGUID, spaceGUID)
+	if name == m.DefaultNamespace {
+		return name, nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.ensured[name] {
+		return name, nil
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{OrgGUIDLabel: orgGUID},
+		},
+	}
+	if m.Strategy == NamespaceStrategyPerSpace {
+		namespace.Labels[SpaceGUIDLabel] = spaceGUID
+	}
+
+	_, err := m.Client.CoreV1().Namespaces().Create(namespace)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		m.Logger.Error("failed-to-create-namespace", err, lager.Data{"namespace": name})
+		return "", ToOpiError(err, "failed to create namespace")
+	}
+	if err == nil {
+		m.Logger.Info("created-namespace", lager.Data{"namespace": name})
+	}
+
+	if m.ensured == nil {
+		m.ensured = map[string]bool{}
+	}
+	m.ensured[name] = true
+	return name, nil
+}
+
+func (m *NamespaceMapper) WatchNamespace() string {
+	if m.Strategy == NamespaceStrategySingle {
+		return m.DefaultNamespace
+	}
+	return meta.NamespaceAll
+}
+
+func ensureNamespace(namespacer Namespacer, defaultNamespace, orgGUID, spaceGUID string) (string, error) {
+	if namespacer == nil {
+		return defaultNamespace, nil
+	}
+	return namespacer.EnsureNamespace(orgGUID, spaceGUID)
+}
+
+func watchNamespace(namespacer Namespacer, defaultNamespace string) string {
+	if namespacer == nil {
+		return defaultNamespace
+	}
+	return namespacer.WatchNamespace()
+}
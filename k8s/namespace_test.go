@@ -0,0 +1,122 @@
+package k8s_test
+
+import (
+	. "code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("NamespaceMapper", func() {
+
+	var (
+		client   *fake.Clientset
+		strategy string
+		mapper   *NamespaceMapper
+	)
+
+	namespaceCreations := func() int {
+		count := 0
+		for _, action := range client.Actions() {
+			if action.Matches("create", "namespaces") {
+				count++
+			}
+		}
+		return count
+	}
+
+	getNamespace := func(name string) *corev1.Namespace {
+		namespace, err := client.CoreV1().Namespaces().Get(name, meta.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return namespace
+	}
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset()
+		strategy = ""
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		mapper, err = NewNamespaceMapper(client, strategy, "eirini", lagertest.NewTestLogger("test-logger"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("When using a single namespace", func() {
+		BeforeEach(func() {
+			strategy = NamespaceStrategySingle
+		})
+
+		It("should put everything in the default namespace", func() {
+			Expect(mapper.EnsureNamespace("org-guid", "space-guid")).To(Equal("eirini"))
+			Expect(namespaceCreations()).To(BeZero())
+		})
+
+		It("should only watch the default namespace", func() {
+			Expect(mapper.WatchNamespace()).To(Equal("eirini"))
+		})
+	})
+
+	Context("When using a namespace per space", func() {
+		BeforeEach(func() {
+			strategy = NamespaceStrategyPerSpace
+		})
+
+		It("should create a namespace labelled with the org and space", func() {
+			Expect(mapper.EnsureNamespace("org-guid", "space-guid")).To(Equal("eirini-space-space-guid"))
+			Expect(getNamespace("eirini-space-space-guid").Labels).To(Equal(map[string]string{
+				OrgGUIDLabel:   "org-guid",
+				SpaceGUIDLabel: "space-guid",
+			}))
+		})
+
+		It("should create each namespace only once", func() {
+			Expect(mapper.EnsureNamespace("org-guid", "space-guid")).To(Equal("eirini-space-space-guid"))
+			Expect(mapper.EnsureNamespace("org-guid", "space-guid")).To(Equal("eirini-space-space-guid"))
+			Expect(namespaceCreations()).To(Equal(1))
+		})
+
+		It("should use the default namespace when the space is unknown", func() {
+			Expect(mapper.EnsureNamespace("org-guid", "")).To(Equal("eirini"))
+			Expect(namespaceCreations()).To(BeZero())
+		})
+
+		It("should watch all namespaces", func() {
+			Expect(mapper.WatchNamespace()).To(Equal(meta.NamespaceAll))
+		})
+
+		Context("and the namespace already exists", func() {
+			BeforeEach(func() {
+				_, err := client.CoreV1().Namespaces().Create(&corev1.Namespace{
+					ObjectMeta: meta.ObjectMeta{Name: "eirini-space-space-guid"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should use it", func() {
+				Expect(mapper.EnsureNamespace("org-guid", "space-guid")).To(Equal("eirini-space-space-guid"))
+			})
+		})
+	})
+
+	Context("When using a namespace per org", func() {
+		BeforeEach(func() {
+			strategy = NamespaceStrategyPerOrg
+		})
+
+		It("should create a namespace labelled with the org", func() {
+			Expect(mapper.EnsureNamespace("org-guid", "space-guid")).To(Equal("eirini-org-org-guid"))
+			Expect(getNamespace("eirini-org-org-guid").Labels).To(Equal(map[string]string{
+				OrgGUIDLabel: "org-guid",
+			}))
+		})
+	})
+
+	It("should reject unknown strategies", func() {
+		_, err := NewNamespaceMapper(client, "per-app", "eirini", lagertest.NewTestLogger("test-logger"))
+		Expect(err).To(MatchError(ContainSubstring("unsupported namespace strategy")))
+	})
+})
@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NetworkPolicy ports are single ports before Kubernetes 1.21, so port
+// ranges are expanded, up to MaxNetworkPolicyPorts ports
+const MaxNetworkPolicyPorts = 1024
+
+//go:generate counterfeiter . NetworkPolicySyncer
+type NetworkPolicySyncer interface {
+	// AppDesired applies the container networking policies of an app
+	AppDesired(namespace, appGUID string) error
+	// AppStopped removes the policies of an app once none of its versions
+	// is left in the namespace
+	AppStopped(namespace, appGUID string) error
+}
+
+//go:generate counterfeiter . SecurityGroupSyncer
+type SecurityGroupSyncer interface {
+	// SpaceDesired applies the security groups of a space to its pods with
+	// a source type in a namespace, before they are created
+	SpaceDesired(namespace, spaceGUID, sourceType string) error
+}
+
+// restrictEgress applies the security groups of a space to the pods which
+// are about to be created, when security groups are enforced and the pods
+// have a space
+func restrictEgress(syncer SecurityGroupSyncer, namespace, spaceGUID, sourceType string) error {
+	if syncer == nil || spaceGUID == "" {
+		return nil
+	}
+	return errors.Wrap(syncer.SpaceDesired(namespace, spaceGUID, sourceType), "failed to apply security groups")
+}
+
+// NetworkPolicyPorts returns the ports from first to last. Other ranges of
+// more than MaxNetworkPolicyPorts ports than the one of every port are
+// rejected, as they cannot be allowed without allowing more.
+func NetworkPolicyPorts(protocol corev1.Protocol, first, last int) ([]networkingv1.NetworkPolicyPort, error) {
+	if first == 1 && last == 65535 {
+		return []networkingv1.NetworkPolicyPort{{Protocol: &protocol}}, nil
+	}
+	if last-first+1 > MaxNetworkPolicyPorts {
+		return nil, fmt.Errorf("ports %d-%d are more than %d ports", first, last, MaxNetworkPolicyPorts)
+	}
+
+	ports := []networkingv1.NetworkPolicyPort{}
+	for port := first; port <= last; port++ {
+		p := intstr.FromInt(port)
+		ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p})
+	}
+	return ports, nil
+}
@@ -0,0 +1,115 @@
+package k8s
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+
+	"code.cloudfoundry.org/eirini"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The annotations of an object must not exceed 256KB in total, so original
+// requests above this size are stored gzipped in a ConfigMap which is owned
+// by the workload instead
+const (
+	maxAnnotatedRequestSize = 64 * 1024
+	originalRequestKey      = "original_request.gz"
+)
+
+func requestConfigMapName(workloadName string) string {
+	return workloadName + "-request"
+}
+
+// storeOriginalRequest records the original request on the workload, or
+// in its request ConfigMap when it is too large for an annotation
+func storeOriginalRequest(client kubernetes.Interface, namespace string, objectMeta *meta.ObjectMeta, request string, owner *meta.OwnerReference) error {
+	if len(request) <= maxAnnotatedRequestSize {
+		// a request ConfigMap that is left over from a larger request is
+		// no longer referenced, and goes away with the workload
+		objectMeta.Annotations[eirini.OriginalRequest] = request
+		delete(objectMeta.Annotations, eirini.OriginalRequestConfigMap)
+		return nil
+	}
+
+	compressed, err := gzipString(request)
+	if err != nil {
+		return err
+	}
+
+	name := requestConfigMapName(objectMeta.Name)
+	delete(objectMeta.Annotations, eirini.OriginalRequest)
+	objectMeta.Annotations[eirini.OriginalRequestConfigMap] = name
+
+	configMaps := client.CoreV1().ConfigMaps(namespace)
+	configMap, err := configMaps.Get(name, meta.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{ObjectMeta: meta.ObjectMeta{Name: name}}
+		setRequestData(configMap, compressed, owner)
+		_, err = configMaps.Create(configMap)
+		return ToOpiError(err, "failed to create request configmap")
+	}
+	if err != nil {
+		return ToOpiError(err, "failed to get request configmap")
+	}
+
+	setRequestData(configMap, compressed, owner)
+	_, err = configMaps.Update(configMap)
+	return ToOpiError(err, "failed to update request configmap")
+}
+
+// loadOriginalRequest returns the original request of a workload, wherever
+// it is stored
+func loadOriginalRequest(client kubernetes.Interface, objectMeta meta.ObjectMeta) (string, error) {
+	name, ok := objectMeta.Annotations[eirini.OriginalRequestConfigMap]
+	if !ok {
+		return objectMeta.Annotations[eirini.OriginalRequest], nil
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps(objectMeta.Namespace).Get(name, meta.GetOptions{})
+	if err != nil {
+		return "", ToOpiError(err, "failed to get request configmap")
+	}
+	return gunzipString(configMap.BinaryData[originalRequestKey])
+}
+
+func setRequestData(configMap *corev1.ConfigMap, compressed []byte, owner *meta.OwnerReference) {
+	configMap.BinaryData = map[string][]byte{originalRequestKey: compressed}
+	if owner != nil {
+		configMap.OwnerReferences = []meta.OwnerReference{*owner}
+	}
+}
+
+func deleteRequestConfigMap(client kubernetes.Interface, namespace, workloadName string) error {
+	err := client.CoreV1().ConfigMaps(namespace).Delete(requestConfigMapName(workloadName), nil)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return ToOpiError(err, "failed to delete request configmap")
+}
+
+func gzipString(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(s)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipString(data []byte) (string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	s, err := ioutil.ReadAll(reader)
+	return string(s), err
+}
@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"code.cloudfoundry.org/eirini/opi"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// minAvailable keeps the majority of the instances of an app running while
+// nodes are drained, without blocking drains when an instance is crashing
+func minAvailable(targetInstances int) int {
+	return (targetInstances + 1) / 2
+}
+
+// applyPodDisruptionBudget keeps a PodDisruptionBudget named after the
+// workload for LRPs with more than one instance. The spec of a budget
+// cannot be updated before Kubernetes 1.15, so it is recreated instead.
+func applyPodDisruptionBudget(client kubernetes.Interface, namespace, workloadName string, selector map[string]string, lrp *opi.LRP, owner *meta.OwnerReference) error {
+	if lrp.TargetInstances <= 1 {
+		return deletePodDisruptionBudget(client, namespace, workloadName)
+	}
+
+	desired := toPodDisruptionBudget(workloadName, selector, minAvailable(lrp.TargetInstances), owner)
+	budgets := client.PolicyV1beta1().PodDisruptionBudgets(namespace)
+	current, err := budgets.Get(workloadName, meta.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ToOpiError(err, "failed to get pod disruption budget")
+	}
+
+	if err == nil {
+		if current.Spec.MinAvailable != nil && *current.Spec.MinAvailable == *desired.Spec.MinAvailable {
+			return nil
+		}
+		if err = deletePodDisruptionBudget(client, namespace, workloadName); err != nil {
+			return err
+		}
+	}
+
+	_, err = budgets.Create(desired)
+	return ToOpiError(err, "failed to create pod disruption budget")
+}
+
+func toPodDisruptionBudget(name string, selector map[string]string, minAvailable int, owner *meta.OwnerReference) *policyv1beta1.PodDisruptionBudget {
+	min := intstr.FromInt(minAvailable)
+	budget := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   name,
+			Labels: selector,
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable: &min,
+			Selector:     &meta.LabelSelector{MatchLabels: selector},
+		},
+	}
+	if owner != nil {
+		budget.OwnerReferences = []meta.OwnerReference{*owner}
+	}
+	return budget
+}
+
+func deletePodDisruptionBudget(client kubernetes.Interface, namespace, workloadName string) error {
+	err := client.PolicyV1beta1().PodDisruptionBudgets(namespace).Delete(workloadName, nil)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return ToOpiError(err, "failed to delete pod disruption budget")
+}
@@ -0,0 +1,144 @@
+package k8s
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/opi"
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AntiAffinityNone lets the scheduler place instances of an app anywhere
+	AntiAffinityNone = "none"
+	// AntiAffinityPreferred spreads the instances of an app over nodes where
+	// possible
+	AntiAffinityPreferred = "preferred"
+	// AntiAffinityRequired never puts two instances of an app on one node
+	AntiAffinityRequired = "required"
+
+	ZoneLabel = "topology.kubernetes.io/zone"
+
+	hostnameLabel          = "kubernetes.io/hostname"
+	nodeAntiAffinityWeight = 100
+	zoneAntiAffinityWeight = 50
+)
+
+// PlacementPolicy spreads the instances of an app like Diego spreads them
+// over cells. The vendored API predates topology spread constraints, so
+// zones are spread with a preferred anti-affinity as well.
+// IsolationSegments confine apps with placement tags to the nodes of their
+// isolation segment.
+type PlacementPolicy struct {
+	AntiAffinity      string
+	SpreadZones       bool
+	IsolationSegments map[string]eirini.IsolationSegment
+}
+
+func NewPlacementPolicy(antiAffinity string, spreadZones bool, isolationSegments map[string]eirini.IsolationSegment) (PlacementPolicy, error) {
+	switch antiAffinity {
+	case "":
+		antiAffinity = AntiAffinityNone
+	case AntiAffinityNone, AntiAffinityPreferred, AntiAffinityRequired:
+	default:
+		return PlacementPolicy{}, fmt.Errorf("unsupported pod anti-affinity %q", antiAffinity)
+	}
+	return PlacementPolicy{
+		AntiAffinity:      antiAffinity,
+		SpreadZones:       spreadZones,
+		IsolationSegments: isolationSegments,
+	}, nil
+}
+
+func (p PlacementPolicy) affinity(lrp *opi.LRP) *corev1.Affinity {
+	antiAffinity := &corev1.PodAntiAffinity{}
+	instances := &meta.LabelSelector{
+		MatchLabels: map[string]string{"guid": lrp.GUID},
+	}
+
+	switch p.AntiAffinity {
+	case AntiAffinityRequired:
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = []corev1.PodAffinityTerm{
+			{LabelSelector: instances, TopologyKey: hostnameLabel},
+		}
+	case AntiAffinityPreferred:
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			weightedTerm(nodeAntiAffinityWeight, instances, hostnameLabel),
+		)
+	}
+
+	if p.SpreadZones {
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			weightedTerm(zoneAntiAffinityWeight, instances, ZoneLabel),
+		)
+	}
+
+	if antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil &&
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution == nil {
+		return nil
+	}
+	return &corev1.Affinity{PodAntiAffinity: antiAffinity}
+}
+
+// isolate confines a pod to the isolation segments of the given placement
+// tags. An app with a tag that has no isolation segment is rejected, as it
+// must not run on shared nodes.
+func (p PlacementPolicy) isolate(spec *corev1.PodSpec, placementTags []string) error {
+	for _, tag := range placementTags {
+		segment, ok := p.IsolationSegments[tag]
+		if !ok {
+			return opi.NewInvalidError("no isolation segment is configured for placement tag %q", tag)
+		}
+
+		if len(segment.NodeSelector) > 0 && spec.NodeSelector == nil {
+			spec.NodeSelector = map[string]string{}
+		}
+		for key, value := range segment.NodeSelector {
+			spec.NodeSelector[key] = value
+		}
+
+		for _, toleration := range segment.Tolerations {
+			spec.Tolerations = append(spec.Tolerations, corev1.Toleration{
+				Key:      toleration.Key,
+				Operator: corev1.TolerationOperator(toleration.Operator),
+				Value:    toleration.Value,
+				Effect:   corev1.TaintEffect(toleration.Effect),
+			})
+		}
+
+		if segment.RuntimeClassName == "" {
+			continue
+		}
+		if spec.RuntimeClassName != nil && *spec.RuntimeClassName != segment.RuntimeClassName {
+			return opi.NewInvalidError("placement tags %v require different runtime classes", placementTags)
+		}
+		runtimeClassName := segment.RuntimeClassName
+		spec.RuntimeClassName = &runtimeClassName
+	}
+	return nil
+}
+
+func weightedTerm(weight int32, selector *meta.LabelSelector, topologyKey string) corev1.WeightedPodAffinityTerm {
+	return corev1.WeightedPodAffinityTerm{
+		Weight: weight,
+		PodAffinityTerm: corev1.PodAffinityTerm{
+			LabelSelector: selector,
+			TopologyKey:   topologyKey,
+		},
+	}
+}
+
+// nodeZone returns the zone of a node, which older clusters only label
+// with the beta zone label
+func nodeZone(node *corev1.Node) string {
+	if node == nil {
+		return ""
+	}
+	if zone, ok := node.Labels[ZoneLabel]; ok {
+		return zone
+	}
+	return node.Labels[corev1.LabelZoneFailureDomain]
+}
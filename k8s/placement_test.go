@@ -0,0 +1,29 @@
+package k8s_test
+
+import (
+	. "code.cloudfoundry.org/eirini/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PlacementPolicy", func() {
+
+	It("should place instances anywhere by default", func() {
+		placement, err := NewPlacementPolicy("", true, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(placement).To(Equal(PlacementPolicy{AntiAffinity: AntiAffinityNone, SpreadZones: true}))
+	})
+
+	It("should accept the supported anti-affinities", func() {
+		for _, antiAffinity := range []string{AntiAffinityNone, AntiAffinityPreferred, AntiAffinityRequired} {
+			placement, err := NewPlacementPolicy(antiAffinity, false, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(placement.AntiAffinity).To(Equal(antiAffinity))
+		}
+	})
+
+	It("should reject unknown anti-affinities", func() {
+		_, err := NewPlacementPolicy("sometimes", false, nil)
+		Expect(err).To(MatchError(ContainSubstring("unsupported pod anti-affinity")))
+	})
+})
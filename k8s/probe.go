@@ -6,46 +6,60 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// CreateLivenessProbe restarts instances which stop being healthy. The
+// Kubernetes API in use predates startup probes, so the liveness of an
+// instance is only checked once its start timeout has passed.
 func CreateLivenessProbe(lrp *opi.LRP) *v1.Probe {
-	checkType := lrp.Health.Type
-	initialDelay := toSeconds(lrp.Health.TimeoutMs)
-	if checkType == "http" {
-		return createHTTPProbe(lrp, initialDelay, 4)
-	} else if checkType == "port" {
-		return createPortProbe(lrp, initialDelay, 4)
+	handler := probeHandler(lrp, lrp.Health.Endpoint)
+	if handler == nil {
+		return nil
 	}
 
-	return nil
+	probe := createProbe(lrp, *handler, 4)
+	probe.InitialDelaySeconds = toSeconds(lrp.Health.TimeoutMs)
+	return probe
 }
 
+// CreateReadinessProbe keeps traffic away from instances which are not
+// ready, checking the readiness endpoint of http health checks if there is
+// one
 func CreateReadinessProbe(lrp *opi.LRP) *v1.Probe {
-	checkType := lrp.Health.Type
-	if checkType == "http" {
-		return createHTTPProbe(lrp, 0, 1)
-	} else if checkType == "port" {
-		return createPortProbe(lrp, 0, 1)
+	endpoint := lrp.Health.ReadinessEndpoint
+	if endpoint == "" {
+		endpoint = lrp.Health.Endpoint
 	}
 
-	return nil
+	handler := probeHandler(lrp, endpoint)
+	if handler == nil {
+		return nil
+	}
+	return createProbe(lrp, *handler, 1)
 }
 
-func createPortProbe(lrp *opi.LRP, initialDelay, failureThreshold int32) *v1.Probe {
-	return &v1.Probe{
-		Handler: v1.Handler{
-			TCPSocket: tcpSocketAction(lrp),
-		},
-		InitialDelaySeconds: initialDelay,
-		FailureThreshold:    failureThreshold,
+// probeHandler returns nil for process health checks, as Kubernetes
+// restarts containers whose process exits without any probe
+func probeHandler(lrp *opi.LRP, endpoint string) *v1.Handler {
+	switch lrp.Health.Type {
+	case opi.HTTPHealthCheck:
+		return &v1.Handler{HTTPGet: httpGetAction(lrp, endpoint)}
+	case opi.PortHealthCheck:
+		return &v1.Handler{TCPSocket: tcpSocketAction(lrp)}
+	case opi.ExecHealthCheck:
+		if len(lrp.Health.Command) == 0 {
+			return nil
+		}
+		return &v1.Handler{Exec: &v1.ExecAction{Command: lrp.Health.Command}}
+	default:
+		return nil
 	}
 }
 
-func createHTTPProbe(lrp *opi.LRP, initialDelay, failureThreshold int32) *v1.Probe {
+func createProbe(lrp *opi.LRP, handler v1.Handler, failureThreshold int32) *v1.Probe {
 	return &v1.Probe{
-		Handler: v1.Handler{
-			HTTPGet: httpGetAction(lrp),
-		},
-		InitialDelaySeconds: initialDelay,
-		FailureThreshold:    failureThreshold,
+		Handler:          handler,
+		TimeoutSeconds:   toSeconds(lrp.Health.InvocationTimeoutMs),
+		PeriodSeconds:    toSeconds(lrp.Health.IntervalMs),
+		FailureThreshold: failureThreshold,
 	}
 }
 
@@ -54,15 +68,15 @@ func toSeconds(millis uint) int32 {
 	return int32(seconds)
 }
 
-func httpGetAction(lrp *opi.LRP) *v1.HTTPGetAction {
+func httpGetAction(lrp *opi.LRP, endpoint string) *v1.HTTPGetAction {
 	return &v1.HTTPGetAction{
-		Path: lrp.Health.Endpoint,
-		Port: intstr.IntOrString{Type: intstr.Int, IntVal: lrp.Health.Port},
+		Path: endpoint,
+		Port: intstr.IntOrString{Type: intstr.Int, IntVal: lrp.HealthCheckPort()},
 	}
 }
 
 func tcpSocketAction(lrp *opi.LRP) *v1.TCPSocketAction {
 	return &v1.TCPSocketAction{
-		Port: intstr.IntOrString{Type: intstr.Int, IntVal: lrp.Health.Port},
+		Port: intstr.IntOrString{Type: intstr.Int, IntVal: lrp.HealthCheckPort()},
 	}
 }
@@ -73,6 +73,68 @@ var _ = Describe("PrrobeCreator", func() {
 			})
 		})
 
+		Context("When the health check port is not set", func() {
+
+			BeforeEach(func() {
+				lrp.Health.Type = "port"
+				lrp.Health.Port = 0
+				lrp.Ports = []int32{9000, 9001}
+			})
+
+			It("targets the first port of the app", func() {
+				Expect(probe.TCPSocket.Port).To(Equal(intstr.FromInt(9000)))
+			})
+		})
+
+		Context("When healthcheck type is Exec", func() {
+
+			BeforeEach(func() {
+				lrp.Health.Type = "exec"
+				lrp.Health.Command = []string{"/bin/check", "--live"}
+			})
+
+			It("creates a probe with Exec action", func() {
+				Expect(probe.Exec).To(Equal(&v1.ExecAction{Command: []string{"/bin/check", "--live"}}))
+				Expect(probe.InitialDelaySeconds).To(Equal(int32(3)))
+			})
+
+			Context("and there is no command", func() {
+
+				BeforeEach(func() {
+					lrp.Health.Command = nil
+				})
+
+				It("returns nil", func() {
+					Expect(probe).To(BeNil())
+				})
+			})
+		})
+
+		Context("When healthcheck type is Process", func() {
+
+			BeforeEach(func() {
+				lrp.Health.Type = "process"
+			})
+
+			It("returns nil", func() {
+				Expect(probe).To(BeNil())
+			})
+		})
+
+		Context("When the invocation timeout and interval are set", func() {
+
+			BeforeEach(func() {
+				lrp.Health.Type = "port"
+				lrp.Health.InvocationTimeoutMs = 2000
+				lrp.Health.IntervalMs = 10000
+			})
+
+			It("sets the timeout and period of the probe", func() {
+				Expect(probe.TimeoutSeconds).To(Equal(int32(2)))
+				Expect(probe.PeriodSeconds).To(Equal(int32(10)))
+			})
+		})
+
 		Context("When timeout is not a whole number", func() {
 
 			BeforeEach(func() {
@@ -125,6 +187,37 @@ var _ = Describe("PrrobeCreator", func() {
 			})
 		})
 
+		Context("When there is a readiness endpoint", func() {
+
+			BeforeEach(func() {
+				lrp.Health.Type = "http"
+				lrp.Health.ReadinessEndpoint = "/ready"
+			})
+
+			It("should check the readiness endpoint", func() {
+				Expect(probe.HTTPGet.Path).To(Equal("/ready"))
+			})
+		})
+
+		Context("When Healthcheck type is Exec", func() {
+
+			BeforeEach(func() {
+				lrp.Health.Type = "exec"
+				lrp.Health.Command = []string{"/bin/check"}
+				lrp.Health.IntervalMs = 5000
+			})
+
+			It("should create a probe with an Exec action", func() {
+				Expect(probe).To(Equal(&v1.Probe{
+					Handler: v1.Handler{
+						Exec: &v1.ExecAction{Command: []string{"/bin/check"}},
+					},
+					PeriodSeconds:    5,
+					FailureThreshold: 1,
+				}))
+			})
+		})
+
 		Context("When Healthcheck type is Port", func() {
 
 			BeforeEach(func() {
@@ -0,0 +1,137 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// VcapUID is the uid and gid of the vcap user of the cflinuxfs3 rootfs
+	VcapUID = 2000
+
+	seccompPodAnnotation  = "seccomp.security.alpha.kubernetes.io/pod"
+	seccompRuntimeDefault = "runtime/default"
+)
+
+// writableDir is an emptyDir volume which keeps a directory writable when
+// the root filesystem of a container is read-only
+type writableDir struct {
+	volume string
+	path   string
+}
+
+var (
+	appWritableDirs     = []writableDir{{"tmp", "/tmp"}, {"home-tmp", "/home/vcap/tmp"}}
+	stagingWritableDirs = []writableDir{{"tmp", "/tmp"}}
+)
+
+// SecurityPolicy is the security context of app, task and staging pods. The
+// zero value runs containers as vcap, without privilege escalation or
+// capabilities, under the runtime's default seccomp profile.
+type SecurityPolicy struct {
+	// Unconfined leaves containers with the user and capabilities of their
+	// image
+	Unconfined             bool
+	RunAsUser              int64
+	RunAsGroup             int64
+	ReadOnlyRootFilesystem bool
+}
+
+// Apply hardens every container of a pod template. It can be applied to
+// templates which are already hardened.
+func (p SecurityPolicy) Apply(template *corev1.PodTemplateSpec) {
+	p.apply(template, appWritableDirs)
+}
+
+func (p SecurityPolicy) applyToStaging(template *corev1.PodTemplateSpec) {
+	p.apply(template, stagingWritableDirs)
+}
+
+func (p SecurityPolicy) apply(template *corev1.PodTemplateSpec, writableDirs []writableDir) {
+	if p.Unconfined {
+		return
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[seccompPodAnnotation] = seccompRuntimeDefault
+
+	uid, gid := p.ids()
+	runAsNonRoot := true
+	template.Spec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsUser:    &uid,
+		RunAsGroup:   &gid,
+		RunAsNonRoot: &runAsNonRoot,
+		FSGroup:      &gid,
+	}
+
+	if p.ReadOnlyRootFilesystem {
+		for _, dir := range writableDirs {
+			addVolume(&template.Spec, corev1.Volume{
+				Name:         dir.volume,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+		}
+	}
+
+	for i := range template.Spec.InitContainers {
+		p.applyToContainer(&template.Spec.InitContainers[i], writableDirs)
+	}
+	for i := range template.Spec.Containers {
+		p.applyToContainer(&template.Spec.Containers[i], writableDirs)
+	}
+}
+
+func (p SecurityPolicy) applyToContainer(container *corev1.Container, writableDirs []writableDir) {
+	allowPrivilegeEscalation := false
+	readOnlyRootFilesystem := p.ReadOnlyRootFilesystem
+	container.SecurityContext = &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+
+	if !p.ReadOnlyRootFilesystem {
+		return
+	}
+	for _, dir := range writableDirs {
+		if hasVolumeMountAt(container, dir.path) {
+			continue
+		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      dir.volume,
+			MountPath: dir.path,
+		})
+	}
+}
+
+func (p SecurityPolicy) ids() (uid, gid int64) {
+	uid, gid = p.RunAsUser, p.RunAsGroup
+	if uid == 0 {
+		uid = VcapUID
+	}
+	if gid == 0 {
+		gid = VcapUID
+	}
+	return uid, gid
+}
+
+func addVolume(spec *corev1.PodSpec, volume corev1.Volume) {
+	for _, v := range spec.Volumes {
+		if v.Name == volume.Name {
+			return
+		}
+	}
+	spec.Volumes = append(spec.Volumes, volume)
+}
+
+func hasVolumeMountAt(container *corev1.Container, path string) bool {
+	for _, mount := range container.VolumeMounts {
+		if mount.MountPath == path {
+			return true
+		}
+	}
+	return false
+}
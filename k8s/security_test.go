@@ -0,0 +1,101 @@
+package k8s_test
+
+import (
+	. "code.cloudfoundry.org/eirini/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("SecurityPolicy", func() {
+
+	var (
+		policy   SecurityPolicy
+		template *corev1.PodTemplateSpec
+	)
+
+	container := func() corev1.Container {
+		return template.Spec.Containers[0]
+	}
+
+	mountPaths := func() []string {
+		paths := []string{}
+		for _, mount := range container().VolumeMounts {
+			paths = append(paths, mount.MountPath)
+		}
+		return paths
+	}
+
+	BeforeEach(func() {
+		policy = SecurityPolicy{}
+		template = &corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "opi"}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		policy.Apply(template)
+	})
+
+	It("should run containers as vcap", func() {
+		securityContext := template.Spec.SecurityContext
+		Expect(*securityContext.RunAsUser).To(Equal(int64(VcapUID)))
+		Expect(*securityContext.RunAsGroup).To(Equal(int64(VcapUID)))
+		Expect(*securityContext.RunAsNonRoot).To(BeTrue())
+	})
+
+	It("should not let containers gain privileges", func() {
+		securityContext := container().SecurityContext
+		Expect(*securityContext.AllowPrivilegeEscalation).To(BeFalse())
+		Expect(securityContext.Capabilities.Drop).To(ConsistOf(corev1.Capability("ALL")))
+		Expect(*securityContext.ReadOnlyRootFilesystem).To(BeFalse())
+	})
+
+	It("should use the default seccomp profile of the runtime", func() {
+		Expect(template.Annotations).To(HaveKeyWithValue("seccomp.security.alpha.kubernetes.io/pod", "runtime/default"))
+	})
+
+	Context("when other ids are configured", func() {
+		BeforeEach(func() {
+			policy.RunAsUser = 1000
+			policy.RunAsGroup = 1001
+		})
+
+		It("should use them", func() {
+			Expect(*template.Spec.SecurityContext.RunAsUser).To(Equal(int64(1000)))
+			Expect(*template.Spec.SecurityContext.RunAsGroup).To(Equal(int64(1001)))
+		})
+	})
+
+	Context("when the root filesystem is read-only", func() {
+		BeforeEach(func() {
+			policy.ReadOnlyRootFilesystem = true
+		})
+
+		It("should keep the tmp directories writable", func() {
+			Expect(*container().SecurityContext.ReadOnlyRootFilesystem).To(BeTrue())
+			Expect(mountPaths()).To(ConsistOf("/tmp", "/home/vcap/tmp"))
+			Expect(template.Spec.Volumes).To(HaveLen(2))
+		})
+
+		It("should not add the volumes twice", func() {
+			policy.Apply(template)
+			Expect(mountPaths()).To(HaveLen(2))
+			Expect(template.Spec.Volumes).To(HaveLen(2))
+		})
+	})
+
+	Context("when containers are unconfined", func() {
+		BeforeEach(func() {
+			policy.Unconfined = true
+		})
+
+		It("should leave the template alone", func() {
+			Expect(template.Spec.SecurityContext).To(BeNil())
+			Expect(container().SecurityContext).To(BeNil())
+			Expect(template.Annotations).To(BeEmpty())
+		})
+	})
+})
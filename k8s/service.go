@@ -0,0 +1,365 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/eirini"
+	"code.cloudfoundry.org/eirini/opi"
+	"code.cloudfoundry.org/eirini/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Every LRP gets a ClusterIP Service and a headless Service for its ports,
+// and a headless Service for each of its internal routes. The Service of an
+// internal route like myapp.apps.internal is cf-myapp-apps-internal, and
+// that of my.app.apps.internal is cf-my-app-apps-internal, so apps resolve
+// it in their own namespace, and cluster DNS can rewrite the internal
+// domain to it. CoreDNS cannot replace every dot of a name, so it needs a
+// rule for each number of labels, most labels first, e.g.
+//
+//   rewrite name regex ([^.]+)\.([^.]+)\.apps\.internal cf-{1}-{2}-apps-internal.<namespace>.svc.cluster.local
+//   rewrite name regex ([^.]+)\.apps\.internal cf-{1}-apps-internal.<namespace>.svc.cluster.local
+//
+// Names longer than a Service name are truncated and hashed, and cannot be
+// rewritten.
+//
+// An internal route may be mapped to several apps, and to several versions
+// of an app while it is updated, so its Service does not belong to one
+// workload. Each workload which maps the route claims the Service, and the
+// last one to unmap it deletes it. A Service selects pods by equal labels
+// only, so it resolves to the instances of one of the apps which map it.
+
+const (
+	maxServiceNameLength = 63
+
+	internalRouteLabel = "internal_route"
+)
+
+func internalRouteServiceName(hostname string) string {
+	name := eirini.GetInternalServiceName(strings.ReplaceAll(strings.ToLower(hostname), ".", "-"))
+	if len(name) <= maxServiceNameLength {
+		return name
+	}
+
+	suffix, err := util.TruncatedSHA256Hasher{}.Hash(hostname)
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%s-%s", name[:maxServiceNameLength-len(suffix)-1], suffix)
+}
+
+func toServices(workloadName string, selector map[string]string, lrp *opi.LRP) []*corev1.Service {
+	ports := toServicePorts(lrp.Ports)
+	services := []*corev1.Service{
+		toService(eirini.GetInternalHeadlessServiceName(workloadName), selector, ports, corev1.ClusterIPNone),
+	}
+	// a ClusterIP Service without ports is invalid
+	if len(ports) > 0 {
+		services = append(services, toService(eirini.GetInternalServiceName(workloadName), selector, ports, ""))
+	}
+	return services
+}
+
+func toService(name string, selector map[string]string, ports []corev1.ServicePort, clusterIP string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   name,
+			Labels: selector,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: clusterIP,
+			Selector:  selector,
+			Ports:     ports,
+		},
+	}
+}
+
+func toServicePorts(lrpPorts []int32) []corev1.ServicePort {
+	ports := []corev1.ServicePort{}
+	for _, port := range lrpPorts {
+		ports = append(ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("port-%d", port),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       port,
+			TargetPort: intstr.FromInt(int(port)),
+		})
+	}
+	return ports
+}
+
+// applyServices makes the Services of a workload match its LRP, which
+// includes releasing the Services of internal routes it no longer has
+func applyServices(client kubernetes.Interface, namespace, workloadName string, selector map[string]string, lrp *opi.LRP, owner *meta.OwnerReference) error {
+	// Services of internal routes which were written with the labels of the
+	// workload are adopted before the others are listed
+	claim := internalRouteClaim{
+		workloadName: workloadName,
+		appGUID:      lrp.GUID,
+		ports:        toServicePorts(lrp.Ports),
+		owner:        owner,
+	}
+	if err := applyInternalRouteServices(client, namespace, lrp.InternalRoutes, claim); err != nil {
+		return err
+	}
+
+	existing, err := listServices(client, namespace, selector)
+	if err != nil {
+		return err
+	}
+
+	services := client.CoreV1().Services(namespace)
+	for _, desired := range toServices(workloadName, selector, lrp) {
+		if owner != nil {
+			desired.OwnerReferences = []meta.OwnerReference{*owner}
+		}
+
+		current, ok := existing[desired.Name]
+		delete(existing, desired.Name)
+		if !ok {
+			_, err = services.Create(desired)
+			if apierrors.IsAlreadyExists(err) {
+				return opi.NewConflictError("service %s belongs to another app", desired.Name)
+			}
+			if err != nil {
+				return ToOpiError(err, "failed to create service")
+			}
+			continue
+		}
+
+		// the cluster IP of a Service is immutable, so it is kept
+		current.Labels = desired.Labels
+		current.Annotations = desired.Annotations
+		current.OwnerReferences = desired.OwnerReferences
+		current.Spec.Selector = desired.Spec.Selector
+		current.Spec.Ports = desired.Spec.Ports
+		if _, err = services.Update(&current); err != nil {
+			return ToOpiError(err, "failed to update service")
+		}
+	}
+
+	for name := range existing {
+		if err = deleteService(client, namespace, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteServices(client kubernetes.Interface, namespace, workloadName string, selector map[string]string) error {
+	if err := applyInternalRouteServices(client, namespace, nil, internalRouteClaim{workloadName: workloadName}); err != nil {
+		return err
+	}
+
+	existing, err := listServices(client, namespace, selector)
+	if err != nil {
+		return err
+	}
+	for name := range existing {
+		if err = deleteService(client, namespace, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listServices(client kubernetes.Interface, namespace string, selector map[string]string) (map[string]corev1.Service, error) {
+	list, err := client.CoreV1().Services(namespace).List(meta.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return nil, ToOpiError(err, "failed to list services")
+	}
+
+	services := map[string]corev1.Service{}
+	for _, service := range list.Items {
+		services[service.Name] = service
+	}
+	return services, nil
+}
+
+func deleteService(client kubernetes.Interface, namespace, name string) error {
+	err := client.CoreV1().Services(namespace).Delete(name, nil)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return ToOpiError(err, "failed to delete service")
+}
+
+// internalRouteClaim is a workload which maps an internal route
+type internalRouteClaim struct {
+	workloadName string
+	appGUID      string
+	ports        []corev1.ServicePort
+	owner        *meta.OwnerReference
+}
+
+// applyInternalRouteServices claims the Services of the internal routes of
+// a workload, and releases the Services of the routes it no longer has
+func applyInternalRouteServices(client kubernetes.Interface, namespace string, hostnames []string, claim internalRouteClaim) error {
+	existing, err := listServices(client, namespace, map[string]string{internalRouteLabel: "true"})
+	if err != nil {
+		return err
+	}
+
+	for _, hostname := range hostnames {
+		name := internalRouteServiceName(hostname)
+		current, ok := existing[name]
+		delete(existing, name)
+		if err = claimInternalRouteService(client, namespace, hostname, current, ok, claim); err != nil {
+			return err
+		}
+	}
+
+	for _, service := range existing {
+		if err = releaseInternalRouteService(client, namespace, service, claim.workloadName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func claimInternalRouteService(client kubernetes.Interface, namespace, hostname string, current corev1.Service, exists bool, claim internalRouteClaim) error {
+	services := client.CoreV1().Services(namespace)
+	if !exists {
+		desired := toService(internalRouteServiceName(hostname), nil, nil, corev1.ClusterIPNone)
+		desired.Annotations = map[string]string{eirini.InternalRoute: hostname}
+		claim.apply(desired)
+		_, err := services.Create(desired)
+		if !apierrors.IsAlreadyExists(err) {
+			return ToOpiError(err, "failed to create service")
+		}
+
+		// the Service was written by an older release, or by another app
+		// at the same time
+		found, err := services.Get(desired.Name, meta.GetOptions{})
+		if err != nil {
+			return ToOpiError(err, "failed to get service")
+		}
+		current = *found
+	}
+
+	if current.Annotations == nil {
+		current.Annotations = map[string]string{}
+	}
+	current.Annotations[eirini.InternalRoute] = hostname
+	claim.apply(&current)
+	_, err := services.Update(&current)
+	return ToOpiError(err, "failed to update service")
+}
+
+func (c internalRouteClaim) apply(service *corev1.Service) {
+	claims := internalRouteClaims(service)
+	claims[c.workloadName] = c.appGUID
+	setInternalRouteClaims(service, claims)
+	service.Labels = map[string]string{
+		internalRouteLabel: "true",
+		"source_type":      AppSourceType,
+	}
+
+	if c.owner != nil && !hasOwner(service, c.workloadName) {
+		service.OwnerReferences = append(service.OwnerReferences, *c.owner)
+	}
+
+	// the Service keeps the app it selects for as long as the app maps it
+	selected := service.Spec.Selector["guid"]
+	if selected == c.appGUID || !claimedByApp(claims, selected) {
+		selectApp(service, c.appGUID)
+		service.Spec.Ports = c.ports
+	}
+}
+
+func releaseInternalRouteService(client kubernetes.Interface, namespace string, service corev1.Service, workloadName string) error {
+	claims := internalRouteClaims(&service)
+	if _, ok := claims[workloadName]; !ok {
+		return nil
+	}
+	delete(claims, workloadName)
+	if len(claims) == 0 {
+		return deleteService(client, namespace, service.Name)
+	}
+
+	setInternalRouteClaims(&service, claims)
+	owners := []meta.OwnerReference{}
+	for _, owner := range service.OwnerReferences {
+		if owner.Name != workloadName {
+			owners = append(owners, owner)
+		}
+	}
+	service.OwnerReferences = owners
+
+	// the ports of the other apps are not known until they are updated
+	if !claimedByApp(claims, service.Spec.Selector["guid"]) {
+		selectApp(&service, claims[firstClaim(claims)])
+	}
+
+	_, err := client.CoreV1().Services(namespace).Update(&service)
+	return ToOpiError(err, "failed to update service")
+}
+
+// internalRouteClaims returns the app GUIDs of the workloads which claim the
+// Service of an internal route, by workload name
+func internalRouteClaims(service *corev1.Service) map[string]string {
+	claims := map[string]string{}
+	if annotation := service.Annotations[eirini.InternalRouteClaims]; annotation != "" {
+		if err := json.Unmarshal([]byte(annotation), &claims); err != nil {
+			return map[string]string{}
+		}
+	}
+	return claims
+}
+
+func setInternalRouteClaims(service *corev1.Service, claims map[string]string) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		panic(err)
+	}
+	if service.Annotations == nil {
+		service.Annotations = map[string]string{}
+	}
+	service.Annotations[eirini.InternalRouteClaims] = string(data)
+}
+
+func claimedByApp(claims map[string]string, appGUID string) bool {
+	for _, guid := range claims {
+		if guid == appGUID {
+			return true
+		}
+	}
+	return false
+}
+
+func firstClaim(claims map[string]string) string {
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+func hasOwner(service *corev1.Service, workloadName string) bool {
+	for _, owner := range service.OwnerReferences {
+		if owner.Name == workloadName {
+			return true
+		}
+	}
+	return false
+}
+
+// selectApp selects the instances of every version of an app, so that the
+// route keeps resolving while the app is updated
+func selectApp(service *corev1.Service, appGUID string) {
+	service.Spec.Selector = map[string]string{
+		"guid":        appGUID,
+		"source_type": AppSourceType,
+	}
+}
@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultGracePeriodSeconds is the termination grace period Kubernetes
+// gives to pods which do not set one
+const DefaultGracePeriodSeconds = 30
+
+// ShutdownPolicy decides how app instances stop. A preStop hook keeps an
+// instance serving for DrainSeconds after its routes are unregistered, so
+// that gorouter stops sending it requests before it gets SIGTERM, and
+// GracePeriodSeconds is the time it has to stop before it gets SIGKILL.
+// The zero value stops instances right away, within the default grace
+// period.
+type ShutdownPolicy struct {
+	GracePeriodSeconds int64
+	DrainSeconds       int64
+}
+
+func NewShutdownPolicy(gracePeriodSeconds, drainSeconds int64) (ShutdownPolicy, error) {
+	if gracePeriodSeconds < 0 {
+		return ShutdownPolicy{}, fmt.Errorf("invalid termination grace period %ds", gracePeriodSeconds)
+	}
+	if drainSeconds < 0 {
+		return ShutdownPolicy{}, fmt.Errorf("invalid drain period %ds", drainSeconds)
+	}
+
+	policy := ShutdownPolicy{
+		GracePeriodSeconds: gracePeriodSeconds,
+		DrainSeconds:       drainSeconds,
+	}
+	// the grace period includes the preStop hook, so apps would be killed
+	// before they got SIGTERM
+	if drainSeconds > 0 && drainSeconds >= policy.gracePeriodSeconds() {
+		return ShutdownPolicy{}, fmt.Errorf("drain period %ds must be shorter than the termination grace period %ds", drainSeconds, policy.gracePeriodSeconds())
+	}
+	return policy, nil
+}
+
+func (p ShutdownPolicy) gracePeriodSeconds() int64 {
+	if p.GracePeriodSeconds == 0 {
+		return DefaultGracePeriodSeconds
+	}
+	return p.GracePeriodSeconds
+}
+
+// Apply sets the grace period and the preStop hook of the app container of
+// a pod template. Images without sleep fail the hook and stop right away.
+func (p ShutdownPolicy) Apply(template *corev1.PodTemplateSpec) {
+	if p.GracePeriodSeconds > 0 {
+		gracePeriodSeconds := p.GracePeriodSeconds
+		template.Spec.TerminationGracePeriodSeconds = &gracePeriodSeconds
+	} else {
+		template.Spec.TerminationGracePeriodSeconds = nil
+	}
+
+	container := &template.Spec.Containers[0]
+	if p.DrainSeconds == 0 {
+		container.Lifecycle = nil
+		return
+	}
+	container.Lifecycle = &corev1.Lifecycle{
+		PreStop: &corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"sleep", strconv.FormatInt(p.DrainSeconds, 10)},
+			},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package k8s_test
+
+import (
+	. "code.cloudfoundry.org/eirini/k8s"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("ShutdownPolicy", func() {
+
+	var (
+		policy   ShutdownPolicy
+		template *corev1.PodTemplateSpec
+	)
+
+	BeforeEach(func() {
+		policy = ShutdownPolicy{}
+		template = &corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "opi"}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		policy.Apply(template)
+	})
+
+	It("should keep the default grace period", func() {
+		Expect(template.Spec.TerminationGracePeriodSeconds).To(BeNil())
+	})
+
+	It("should not add a preStop hook", func() {
+		Expect(template.Spec.Containers[0].Lifecycle).To(BeNil())
+	})
+
+	Context("When a grace period and a drain period are configured", func() {
+
+		BeforeEach(func() {
+			policy = ShutdownPolicy{GracePeriodSeconds: 60, DrainSeconds: 15}
+		})
+
+		It("should set the grace period", func() {
+			Expect(*template.Spec.TerminationGracePeriodSeconds).To(Equal(int64(60)))
+		})
+
+		It("should sleep for the drain period before the app is stopped", func() {
+			preStop := template.Spec.Containers[0].Lifecycle.PreStop
+			Expect(preStop.Exec.Command).To(Equal([]string{"sleep", "15"}))
+		})
+
+		Context("and the policy is changed back to the default", func() {
+
+			JustBeforeEach(func() {
+				ShutdownPolicy{}.Apply(template)
+			})
+
+			It("should remove the grace period and the preStop hook", func() {
+				Expect(template.Spec.TerminationGracePeriodSeconds).To(BeNil())
+				Expect(template.Spec.Containers[0].Lifecycle).To(BeNil())
+			})
+		})
+	})
+
+	Context("When creating a policy", func() {
+
+		It("should accept a drain period within the grace period", func() {
+			_, err := NewShutdownPolicy(60, 15)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should accept a drain period within the default grace period", func() {
+			_, err := NewShutdownPolicy(0, 15)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should reject a drain period which takes the whole grace period", func() {
+			_, err := NewShutdownPolicy(0, DefaultGracePeriodSeconds)
+			Expect(err).To(MatchError(ContainSubstring("must be shorter than the termination grace period 30s")))
+		})
+
+		It("should reject negative periods", func() {
+			_, err := NewShutdownPolicy(-1, 0)
+			Expect(err).To(HaveOccurred())
+			_, err = NewShutdownPolicy(0, -1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
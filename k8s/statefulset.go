@@ -2,19 +2,14 @@ package k8s
 
 import (
 	"fmt"
-	"strings"
 
 	"code.cloudfoundry.org/eirini"
-	"code.cloudfoundry.org/eirini/k8s/utils"
-	"code.cloudfoundry.org/eirini/models/cf"
 	"code.cloudfoundry.org/eirini/opi"
-	"code.cloudfoundry.org/eirini/rootfspatcher"
 	"code.cloudfoundry.org/eirini/util"
 	"code.cloudfoundry.org/lager"
-	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	types "k8s.io/client-go/kubernetes/typed/apps/v1"
@@ -23,39 +18,85 @@ import (
 const (
 	eventKilling          = "Killing"
 	eventFailedScheduling = "FailedScheduling"
-	appSourceType         = "APP"
+	AppSourceType         = "APP"
+
+	// ConflictPolicyReject makes Desire fail when an app is desired again
+	// with a request that differs from the one it was created with
+	ConflictPolicyReject = "reject"
+	// ConflictPolicyUpdate makes Desire apply the differing request to the
+	// existing app instead
+	ConflictPolicyUpdate = "update"
 )
 
 type StatefulSetDesirer struct {
 	Client                kubernetes.Interface
 	Namespace             string
 	RootfsVersion         string
+	UpdatePartition       int32
+	ConflictPolicy        string
+	Placement             PlacementPolicy
+	Security              SecurityPolicy
+	CPU                   CPUPolicy
+	Memory                MemoryPolicy
+	Shutdown              ShutdownPolicy
 	LivenessProbeCreator  ProbeCreator
 	ReadinessProbeCreator ProbeCreator
 	Hasher                util.Hasher
 	Logger                lager.Logger
+	// Cache is used to serve reads when set. Writes, and the reads they
+	// depend on, always go to the API server.
+	Cache *LRPCache
+	// Namespacer picks the namespace of new apps when set. Otherwise all
+	// apps are in Namespace.
+	Namespacer Namespacer
+	// NetworkPolicies applies the container networking policies of apps
+	// when set
+	NetworkPolicies NetworkPolicySyncer
+	// SecurityGroups restricts the egress of new apps when set
+	SecurityGroups SecurityGroupSyncer
+
+	desireLocks util.KeyedMutex
+}
+
+type lrpReader interface {
+	listStatefulSets() ([]appsv1.StatefulSet, error)
+	getStatefulSets(identifier opi.LRPIdentifier) ([]appsv1.StatefulSet, error)
+	getPods(identifier opi.LRPIdentifier) ([]corev1.Pod, error)
+	getEvents(pod corev1.Pod) (*corev1.EventList, error)
+	getNode(name string) (*corev1.Node, error)
 }
 
 //go:generate counterfeiter . ProbeCreator
 type ProbeCreator func(lrp *opi.LRP) *corev1.Probe
 
-func NewStatefulSetDesirer(client kubernetes.Interface, namespace string, rootfsVersion string, logger lager.Logger) opi.Desirer {
+func NewStatefulSetDesirer(client kubernetes.Interface, namespace string, rootfsVersion string, options LRPDesirerOptions, logger lager.Logger) opi.Desirer {
 	return &StatefulSetDesirer{
 		Client:                client,
 		Namespace:             namespace,
 		RootfsVersion:         rootfsVersion,
+		UpdatePartition:       options.UpdatePartition,
+		ConflictPolicy:        options.ConflictPolicy,
+		Placement:             options.Placement,
+		Security:              options.Security,
+		CPU:                   options.CPU,
+		Memory:                options.Memory,
+		Shutdown:              options.Shutdown,
 		LivenessProbeCreator:  CreateLivenessProbe,
 		ReadinessProbeCreator: CreateReadinessProbe,
 		Hasher:                util.TruncatedSHA256Hasher{},
 		Logger:                logger,
+		Cache:                 options.Cache,
+		Namespacer:            options.Namespacer,
+		NetworkPolicies:       options.NetworkPolicies,
+		SecurityGroups:        options.SecurityGroups,
 	}
 }
 
 func (m *StatefulSetDesirer) List() ([]*opi.LRP, error) {
-	statefulsets, err := m.statefulSets().List(meta.ListOptions{})
+	statefulsets, err := m.reader().listStatefulSets()
 	if err != nil {
 		m.Logger.Error("failed-to-list-statefulsets", err)
-		return nil, err
+		return nil, ToOpiError(err, "failed to list statefulsets")
 	}
 
 	lrps := statefulSetsToLRPs(statefulsets)
@@ -69,139 +110,282 @@ func (m *StatefulSetDesirer) Stop(identifier opi.LRPIdentifier) error {
 		return err
 	}
 
+	if err = deleteWorkloadDependents(m.Client, statefulSet.Namespace, statefulSet.Name, statefulSet.Spec.Selector.MatchLabels); err != nil {
+		return err
+	}
+
 	backgroundPropagation := meta.DeletePropagationBackground
-	return m.statefulSets().Delete(statefulSet.Name, &meta.DeleteOptions{PropagationPolicy: &backgroundPropagation})
+	err = m.statefulSets(statefulSet.Namespace).Delete(statefulSet.Name, &meta.DeleteOptions{PropagationPolicy: &backgroundPropagation})
+	if err != nil {
+		return ToOpiError(err, "failed to delete statefulset")
+	}
+
+	if m.NetworkPolicies != nil {
+		if err = m.NetworkPolicies.AppStopped(statefulSet.Namespace, identifier.GUID); err != nil {
+			m.Logger.Error("failed-to-remove-network-policies", err, lager.Data{"process-guid": identifier.ProcessGUID()})
+		}
+	}
+	return nil
 }
 
 func (m *StatefulSetDesirer) StopInstance(identifier opi.LRPIdentifier, index uint) error {
-	selector := fmt.Sprintf("guid=%s,version=%s", identifier.GUID, identifier.Version)
-	options := meta.ListOptions{LabelSelector: selector}
-	statefulsets, err := m.statefulSets().List(options)
+	statefulsets, err := m.reader().getStatefulSets(identifier)
 	if err != nil {
 		m.Logger.Error("failed-to-get-statefulsets", err, lager.Data{"process-guid": identifier.GUID})
-		return errors.Wrap(err, "failed to get statefulset")
+		return ToOpiError(err, "failed to get statefulset")
 	}
-	if len(statefulsets.Items) == 0 {
-		return errors.New("app does not exist")
+	if len(statefulsets) == 0 {
+		return opi.NewNotFoundError("app %s does not exist", identifier.ProcessGUID())
 	}
 
-	st := statefulsets.Items[0]
-	return m.Client.CoreV1().Pods(m.Namespace).Delete(fmt.Sprintf("%s-%d", st.Name, index), nil)
+	st := statefulsets[0]
+	err = m.Client.CoreV1().Pods(st.Namespace).Delete(fmt.Sprintf("%s-%d", st.Name, index), nil)
+	return ToOpiError(err, "failed to delete pod")
 }
 
 func (m *StatefulSetDesirer) Desire(lrp *opi.LRP) error {
-	_, err := m.statefulSets().Create(m.toStatefulSet(lrp))
-	return err
+	unlock := m.desireLocks.Lock(lrp.ProcessGUID())
+	defer unlock()
+
+	statefulSet, err := m.getStatefulSet(lrp.LRPIdentifier)
+	if opi.IsNotFound(err) {
+		return m.create(lrp)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger := m.Logger.Session("desire-existing-app", lager.Data{"process-guid": lrp.ProcessGUID()})
+	storedRequest, err := loadOriginalRequest(m.Client, statefulSet.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	// the stored request does not tell the credentials of the app apart
+	sameEnv, err := envSecretMatches(m.Client, statefulSet.Namespace, statefulSet.Name, lrp.Env)
+	if err != nil {
+		return err
+	}
+	if storedRequest == lrp.LRP && sameEnv {
+		logger.Debug("app-already-desired")
+		// the dependents may have failed to apply when the app was created
+		return applyWorkloadDependents(m.Client, statefulSet.Namespace, statefulSet.Name, statefulSet.Spec.Selector.MatchLabels, lrp, ownerReference("StatefulSet", statefulSet.ObjectMeta))
+	}
+
+	if m.ConflictPolicy != ConflictPolicyUpdate {
+		logger.Info("rejecting-changed-app")
+		return opi.NewConflictError("app %s already exists with a different configuration", lrp.ProcessGUID())
+	}
+
+	logger.Info("updating-changed-app")
+	m.applyLRP(statefulSet, lrp)
+	for key, value := range lrp.Metadata {
+		statefulSet.Annotations[key] = value
+	}
+
+	if err = storeLRPDependents(m.Client, statefulSet.Namespace, &statefulSet.ObjectMeta, lrp, ownerReference("StatefulSet", statefulSet.ObjectMeta)); err != nil {
+		return err
+	}
+
+	return m.update(statefulSet, lrp)
+}
+
+func (m *StatefulSetDesirer) create(lrp *opi.LRP) error {
+	statefulSet := m.toStatefulSet(lrp)
+	if err := m.Placement.isolate(&statefulSet.Spec.Template.Spec, lrp.PlacementTags); err != nil {
+		return err
+	}
+
+	namespace, err := ensureNamespace(m.Namespacer, m.Namespace, lrp.OrgGUID, lrp.SpaceGUID)
+	if err != nil {
+		return err
+	}
+	if err = restrictEgress(m.SecurityGroups, namespace, lrp.SpaceGUID, AppSourceType); err != nil {
+		return err
+	}
+
+	if err = storeLRPDependents(m.Client, namespace, &statefulSet.ObjectMeta, lrp, nil); err != nil {
+		return err
+	}
+
+	created, err := m.statefulSets(namespace).Create(statefulSet)
+	if err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			m.cleanUpDependents(namespace, statefulSet.Name)
+		}
+		return ToOpiError(err, "failed to create statefulset")
+	}
+
+	owner := ownerReference("StatefulSet", created.ObjectMeta)
+	if err = storeLRPDependents(m.Client, namespace, &created.ObjectMeta, lrp, owner); err != nil {
+		return err
+	}
+	if err = applyWorkloadDependents(m.Client, namespace, created.Name, created.Spec.Selector.MatchLabels, lrp, owner); err != nil {
+		return err
+	}
+
+	// the policies are synced periodically as well, so a failure does not
+	// fail the desire
+	if m.NetworkPolicies != nil {
+		if err = m.NetworkPolicies.AppDesired(namespace, lrp.GUID); err != nil {
+			m.Logger.Error("failed-to-apply-network-policies", err, lager.Data{"process-guid": lrp.ProcessGUID()})
+		}
+	}
+	return nil
+}
+
+// cleanUpDependents deletes the dependents of a workload which could not
+// be created, as nothing would garbage collect them
+func (m *StatefulSetDesirer) cleanUpDependents(namespace, name string) {
+	if err := deleteLRPDependents(m.Client, namespace, name); err != nil {
+		m.Logger.Error("failed-to-delete-dependents", err, lager.Data{"name": name})
+	}
 }
 
 func (m *StatefulSetDesirer) Update(lrp *opi.LRP) error {
+	unlock := m.desireLocks.Lock(lrp.ProcessGUID())
+	defer unlock()
+
 	statefulSet, err := m.getStatefulSet(opi.LRPIdentifier{GUID: lrp.GUID, Version: lrp.Version})
 	if err != nil {
 		return err
 	}
 
+	m.applyLRP(statefulSet, lrp)
+
+	if err = applyEnvSecret(m.Client, statefulSet.Namespace, statefulSet.Name, lrp, ownerReference("StatefulSet", statefulSet.ObjectMeta)); err != nil {
+		return err
+	}
+
+	return m.update(statefulSet, lrp)
+}
+
+func (m *StatefulSetDesirer) update(statefulSet *appsv1.StatefulSet, lrp *opi.LRP) error {
+	updated, err := m.statefulSets(statefulSet.Namespace).Update(statefulSet)
+	if err != nil {
+		return ToOpiError(err, "failed to update statefulset")
+	}
+	return applyWorkloadDependents(m.Client, updated.Namespace, updated.Name, updated.Spec.Selector.MatchLabels, lrp, ownerReference("StatefulSet", updated.ObjectMeta))
+}
+
+func (m *StatefulSetDesirer) applyLRP(statefulSet *appsv1.StatefulSet, lrp *opi.LRP) {
 	count := int32(lrp.TargetInstances)
 	statefulSet.Spec.Replicas = &count
-	statefulSet.Annotations[cf.LastUpdated] = lrp.Metadata[cf.LastUpdated]
-	statefulSet.Annotations[eirini.RegisteredRoutes] = lrp.Metadata[cf.VcapAppUris]
-
-	_, err = m.statefulSets().Update(statefulSet)
-	return err
+	statefulSet.Spec.UpdateStrategy = updateStrategy(m.UpdatePartition)
+	applyLRPToPodTemplate(&statefulSet.ObjectMeta, &statefulSet.Spec.Template, lrp, m.LivenessProbeCreator, m.ReadinessProbeCreator)
+	applyResourcePolicies(&statefulSet.Spec.Template, lrp, m.CPU, m.Memory)
+	m.Shutdown.Apply(&statefulSet.Spec.Template)
 }
 
 func (m *StatefulSetDesirer) Get(identifier opi.LRPIdentifier) (*opi.LRP, error) {
-	statefulset, err := m.getStatefulSet(identifier)
+	statefulset, err := m.findStatefulSet(m.reader(), identifier)
 	if err != nil {
 		return nil, err
 	}
-	return statefulSetToLRP(*statefulset), nil
+
+	lrp := statefulSetToLRP(*statefulset)
+	if lrp.LRP, err = loadOriginalRequest(m.Client, statefulset.ObjectMeta); err != nil {
+		return nil, err
+	}
+	return lrp, nil
 }
 
+// getStatefulSet always reads from the API server, as the result is used
+// to decide what to write
 func (m *StatefulSetDesirer) getStatefulSet(identifier opi.LRPIdentifier) (*appsv1.StatefulSet, error) {
-	options := meta.ListOptions{LabelSelector: fmt.Sprintf("guid=%s,version=%s", identifier.GUID, identifier.Version)}
-	statefulSet, err := m.statefulSets().List(options)
+	return m.findStatefulSet(m.apiReader(), identifier)
+}
+
+func (m *StatefulSetDesirer) findStatefulSet(reader lrpReader, identifier opi.LRPIdentifier) (*appsv1.StatefulSet, error) {
+	statefulsets, err := reader.getStatefulSets(identifier)
 	if err != nil {
 		m.Logger.Error("failed-to-get-statefulset", err, lager.Data{"process-guid": identifier.GUID})
-		return nil, err
+		return nil, ToOpiError(err, "failed to get statefulset")
 	}
-	statefulsets := statefulSet.Items
 	switch len(statefulsets) {
 	case 0:
-		return nil, errors.New("app not found")
+		return nil, opi.NewNotFoundError("app %s not found", identifier.ProcessGUID())
 	case 1:
 		return &statefulsets[0], nil
 	default:
-		panic(fmt.Sprintf("more than one was identified as %+v", identifier))
+		m.Logger.Error("multiple-statefulsets-found", nil, lager.Data{"process-guid": identifier.GUID, "count": len(statefulsets)})
+		return nil, opi.NewConflictError("more than one statefulset was identified as %s", identifier.ProcessGUID())
 	}
 }
 
 func (m *StatefulSetDesirer) GetInstances(identifier opi.LRPIdentifier) ([]*opi.Instance, error) {
-	options := meta.ListOptions{LabelSelector: fmt.Sprintf("guid=%s,version=%s", identifier.GUID, identifier.Version)}
-	pods, err := m.Client.CoreV1().Pods(m.Namespace).List(options)
+	reader := m.reader()
+	pods, err := reader.getPods(identifier)
 	if err != nil {
 		m.Logger.Error("failed-to-list-pods", err, lager.Data{"process-guid": identifier.GUID})
-		return []*opi.Instance{}, err
+		return []*opi.Instance{}, ToOpiError(err, "failed to list pods")
 	}
 
-	instances := []*opi.Instance{}
-	for _, pod := range pods.Items {
-		events, err := GetEvents(m.Client, pod)
-		if err != nil {
-			m.Logger.Error("failed-to-get-k8s-events", err, lager.Data{"pod-name": pod.Name})
-			return []*opi.Instance{}, err
-		}
+	return toInstances(reader, pods, m.Logger)
+}
 
-		if IsStopped(events) {
-			continue
-		}
+func (m *StatefulSetDesirer) statefulSets(namespace string) types.StatefulSetInterface {
+	return m.Client.AppsV1().StatefulSets(namespace)
+}
 
-		index, err := util.ParseAppIndex(pod.Name)
-		if err != nil {
-			return []*opi.Instance{}, err
-		}
+func (m *StatefulSetDesirer) reader() lrpReader {
+	if m.Cache != nil {
+		return m.Cache
+	}
+	return m.apiReader()
+}
 
-		since := int64(0)
-		if pod.Status.StartTime != nil {
-			since = pod.Status.StartTime.UnixNano()
-		}
+func (m *StatefulSetDesirer) apiReader() lrpReader {
+	return &apiLRPReader{client: m.Client, namespace: watchNamespace(m.Namespacer, m.Namespace)}
+}
 
-		var state, placementError string
-		if hasInsufficientMemory(events) {
-			state, placementError = opi.ErrorState, opi.InsufficientMemoryError
-		} else {
-			state = utils.GetPodState(pod)
-		}
+type apiLRPReader struct {
+	client    kubernetes.Interface
+	namespace string
+}
 
-		instance := opi.Instance{
-			Since:          since,
-			Index:          index,
-			State:          state,
-			PlacementError: placementError,
-		}
-		instances = append(instances, &instance)
+func (r *apiLRPReader) listStatefulSets() ([]appsv1.StatefulSet, error) {
+	statefulSets, err := r.client.AppsV1().StatefulSets(r.namespace).List(meta.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
-
-	return instances, nil
+	return statefulSets.Items, nil
 }
 
-func hasInsufficientMemory(eventList *corev1.EventList) bool {
-	events := eventList.Items
+func (r *apiLRPReader) getStatefulSets(identifier opi.LRPIdentifier) ([]appsv1.StatefulSet, error) {
+	statefulSets, err := r.client.AppsV1().StatefulSets(r.namespace).List(lrpListOptions(identifier))
+	if err != nil {
+		return nil, err
+	}
+	return statefulSets.Items, nil
+}
 
-	if len(events) == 0 {
-		return false
+func (r *apiLRPReader) getPods(identifier opi.LRPIdentifier) ([]corev1.Pod, error) {
+	pods, err := r.client.CoreV1().Pods(r.namespace).List(lrpListOptions(identifier))
+	if err != nil {
+		return nil, err
 	}
+	return pods.Items, nil
+}
 
-	event := events[len(events)-1]
-	return event.Reason == eventFailedScheduling && strings.Contains(event.Message, "Insufficient memory")
+func (r *apiLRPReader) getEvents(pod corev1.Pod) (*corev1.EventList, error) {
+	return GetEvents(r.client, pod)
 }
 
-func (m *StatefulSetDesirer) statefulSets() types.StatefulSetInterface {
-	return m.Client.AppsV1().StatefulSets(m.Namespace)
+func (r *apiLRPReader) getNode(name string) (*corev1.Node, error) {
+	node, err := r.client.CoreV1().Nodes().Get(name, meta.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return node, err
 }
 
-func statefulSetsToLRPs(statefulSets *appsv1.StatefulSetList) []*opi.LRP {
+func lrpListOptions(identifier opi.LRPIdentifier) meta.ListOptions {
+	return meta.ListOptions{LabelSelector: fmt.Sprintf("guid=%s,version=%s", identifier.GUID, identifier.Version)}
+}
+
+func statefulSetsToLRPs(statefulSets []appsv1.StatefulSet) []*opi.LRP {
 	lrps := []*opi.LRP{}
-	for _, s := range statefulSets.Items {
+	for _, s := range statefulSets {
 		lrp := statefulSetToLRP(s)
 		lrps = append(lrps, lrp)
 	}
@@ -209,192 +393,51 @@ func statefulSetsToLRPs(statefulSets *appsv1.StatefulSetList) []*opi.LRP {
 }
 
 func statefulSetToLRP(s appsv1.StatefulSet) *opi.LRP {
-	ports := []int32{}
-	container := s.Spec.Template.Spec.Containers[0]
-
-	for _, port := range container.Ports {
-		ports = append(ports, port.ContainerPort)
-	}
-
-	memory := container.Resources.Requests.Memory().ScaledValue(resource.Mega)
-	volMounts := []opi.VolumeMount{}
-	for _, vol := range container.VolumeMounts {
-		volMounts = append(volMounts, opi.VolumeMount{
-			ClaimName: vol.Name,
-			MountPath: vol.MountPath,
-		})
-	}
-
-	return &opi.LRP{
-		LRPIdentifier: opi.LRPIdentifier{
-			GUID:    s.Annotations[cf.VcapAppID],
-			Version: s.Annotations[cf.VcapVersion],
-		},
-		AppName:          s.Annotations[cf.VcapAppName],
-		SpaceName:        s.Annotations[cf.VcapSpaceName],
-		Image:            container.Image,
-		Command:          container.Command,
-		RunningInstances: int(s.Status.ReadyReplicas),
-		Ports:            ports,
-		Metadata: map[string]string{
-			cf.ProcessGUID: s.Annotations[cf.ProcessGUID],
-			cf.LastUpdated: s.Annotations[cf.LastUpdated],
-			cf.VcapAppUris: s.Annotations[cf.VcapAppUris],
-			cf.VcapAppID:   s.Annotations[cf.VcapAppID],
-			cf.VcapVersion: s.Annotations[cf.VcapVersion],
-			cf.VcapAppName: s.Annotations[cf.VcapAppName],
-		},
-		MemoryMB:     memory,
-		VolumeMounts: volMounts,
-	}
+	return toLRP(s.ObjectMeta, s.Spec.Template, s.Spec.Replicas, s.Status.ReadyReplicas)
 }
 
 func (m *StatefulSetDesirer) toStatefulSet(lrp *opi.LRP) *appsv1.StatefulSet {
-	envs := MapToEnvVar(lrp.Env)
-	fieldEnvs := []corev1.EnvVar{
-		{
-			Name: "POD_NAME",
-			ValueFrom: &corev1.EnvVarSource{
-				FieldRef: &corev1.ObjectFieldSelector{
-					FieldPath: "metadata.name",
-				},
-			},
-		},
-		{
-			Name: "CF_INSTANCE_IP",
-			ValueFrom: &corev1.EnvVarSource{
-				FieldRef: &corev1.ObjectFieldSelector{
-					FieldPath: "status.podIP",
-				},
-			},
-		},
-		{
-			Name: "CF_INSTANCE_INTERNAL_IP",
-			ValueFrom: &corev1.EnvVarSource{
-				FieldRef: &corev1.ObjectFieldSelector{
-					FieldPath: "status.podIP",
-				},
-			},
-		},
-	}
-
-	envs = append(envs, fieldEnvs...)
-	ports := []corev1.ContainerPort{}
-	for _, port := range lrp.Ports {
-		ports = append(ports, corev1.ContainerPort{ContainerPort: port})
-	}
-
-	livenessProbe := m.LivenessProbeCreator(lrp)
-	readinessProbe := m.ReadinessProbeCreator(lrp)
-
-	memory, err := resource.ParseQuantity(fmt.Sprintf("%dM", lrp.MemoryMB))
-	if err != nil {
-		panic(err)
-	}
-
-	cpu, err := resource.ParseQuantity(fmt.Sprintf("%dm", lrp.CPUWeight*10))
-	if err != nil {
-		panic(err)
-	}
-
-	volumes, volumeMounts := getVolumeSpecs(lrp.VolumeMounts)
-	automountServiceAccountToken := false
-
-	nameSuffix, err := m.Hasher.Hash(fmt.Sprintf("%s-%s", lrp.GUID, lrp.Version))
-	if err != nil {
-		panic(err)
-	}
-	namePrefix := fmt.Sprintf("%s-%s", lrp.AppName, lrp.SpaceName)
-	namePrefix = utils.SanitizeName(namePrefix, lrp.GUID)
-
+	name := lrpName(m.Hasher, lrp)
 	statefulSet := &appsv1.StatefulSet{
 		ObjectMeta: meta.ObjectMeta{
-			Name: fmt.Sprintf("%s-%s", namePrefix, nameSuffix),
+			Name: name,
 		},
 		Spec: appsv1.StatefulSetSpec{
+			ServiceName:         eirini.GetInternalHeadlessServiceName(name),
 			PodManagementPolicy: "Parallel",
 			Replicas:            int32ptr(lrp.TargetInstances),
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: meta.ObjectMeta{
-					Annotations: map[string]string{
-						cf.ProcessGUID: lrp.Metadata[cf.ProcessGUID],
-						cf.VcapAppID:   lrp.Metadata[cf.VcapAppID],
-					},
-				},
-				Spec: corev1.PodSpec{
-					AutomountServiceAccountToken: &automountServiceAccountToken,
-					Containers: []corev1.Container{
-						{
-							Name:            "opi",
-							Image:           lrp.Image,
-							ImagePullPolicy: corev1.PullAlways,
-							Command:         lrp.Command,
-							Env:             envs,
-							Ports:           ports,
-							Resources: corev1.ResourceRequirements{
-								Limits: corev1.ResourceList{
-									corev1.ResourceMemory: memory,
-								},
-								Requests: corev1.ResourceList{
-									corev1.ResourceMemory: memory,
-									corev1.ResourceCPU:    cpu,
-								},
-							},
-							LivenessProbe:  livenessProbe,
-							ReadinessProbe: readinessProbe,
-							VolumeMounts:   volumeMounts,
-						},
-					},
-					Volumes: volumes,
-				},
-			},
+			UpdateStrategy:      updateStrategy(0),
+			Template:            toPodTemplateSpec(lrp, name, m.LivenessProbeCreator(lrp), m.ReadinessProbeCreator(lrp)),
 		},
 	}
 
-	selectorLabels := map[string]string{
-		"guid":        lrp.GUID,
-		"version":     lrp.Version,
-		"source_type": appSourceType,
-	}
-
 	statefulSet.Spec.Selector = &meta.LabelSelector{
-		MatchLabels: selectorLabels,
+		MatchLabels: selectorLabels(lrp),
 	}
 
-	labels := map[string]string{
-		"guid":                           lrp.GUID,
-		"version":                        lrp.Version,
-		"source_type":                    appSourceType,
-		rootfspatcher.RootfsVersionLabel: m.RootfsVersion,
-	}
+	statefulSet.Spec.Template.Spec.Affinity = m.Placement.affinity(lrp)
+	m.Security.Apply(&statefulSet.Spec.Template)
+	applyResourcePolicies(&statefulSet.Spec.Template, lrp, m.CPU, m.Memory)
+	m.Shutdown.Apply(&statefulSet.Spec.Template)
 
+	labels := lrpLabels(lrp, m.RootfsVersion)
 	statefulSet.Spec.Template.Labels = labels
 	statefulSet.Labels = labels
-
-	statefulSet.Annotations = lrp.Metadata
-	statefulSet.Annotations[eirini.RegisteredRoutes] = lrp.Metadata[cf.VcapAppUris]
-	statefulSet.Annotations[cf.VcapSpaceName] = lrp.SpaceName
-	statefulSet.Annotations[eirini.OriginalRequest] = lrp.LRP
+	statefulSet.Annotations = lrpAnnotations(lrp)
 
 	return statefulSet
 }
 
-func getVolumeSpecs(lrpVolumeMounts []opi.VolumeMount) ([]corev1.Volume, []corev1.VolumeMount) {
-	volumes := []corev1.Volume{}
-	volumeMounts := []corev1.VolumeMount{}
-	for _, vm := range lrpVolumeMounts {
-		volumes = append(volumes, corev1.Volume{
-			Name: vm.ClaimName,
-			VolumeSource: corev1.VolumeSource{
-				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-					ClaimName: vm.ClaimName,
-				},
-			},
-		})
-		volumeMounts = append(volumeMounts, corev1.VolumeMount{
-			Name:      vm.ClaimName,
-			MountPath: vm.MountPath,
-		})
-	}
-	return volumes, volumeMounts
+// updateStrategy rolls out changes to the instances from the last one down
+// to the partition, so that a partition stages the rollout of an update. The
+// rollout.PartitionInformer lowers the partition to 0 once those instances
+// are ready. New apps are not partitioned, so that their first update, e.g.
+// by the rootfs patcher, reaches every instance.
+func updateStrategy(partition int32) appsv1.StatefulSetUpdateStrategy {
+	return appsv1.StatefulSetUpdateStrategy{
+		Type: appsv1.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+			Partition: &partition,
+		},
+	}
 }
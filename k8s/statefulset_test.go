@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -18,8 +19,11 @@ import (
 	"code.cloudfoundry.org/lager/lagertest"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -45,6 +49,12 @@ var _ = Describe("Statefulset", func() {
 		readinessProbeCreator *k8sfakes.FakeProbeCreator
 		hasher                *utilfakes.FakeHasher
 		rootfsVersion         string
+		updatePartition       int32
+		conflictPolicy        string
+		cpuPolicy             CPUPolicy
+		memoryPolicy          MemoryPolicy
+		shutdownPolicy        ShutdownPolicy
+		namespacer            *k8sfakes.FakeNamespacer
 	)
 
 	listStatefulSets := func() []appsv1.StatefulSet {
@@ -68,18 +78,33 @@ var _ = Describe("Statefulset", func() {
 		hasher = new(utilfakes.FakeHasher)
 		hasher.HashReturns("random", nil)
 		rootfsVersion = "version1"
+		updatePartition = 0
+		conflictPolicy = ConflictPolicyReject
+		cpuPolicy = CPUPolicy{}
+		memoryPolicy = MemoryPolicy{}
+		shutdownPolicy = ShutdownPolicy{}
+		namespacer = nil
 	})
 
 	JustBeforeEach(func() {
-		statefulSetDesirer = &StatefulSetDesirer{
+		desirer := &StatefulSetDesirer{
 			Client:                client,
 			Namespace:             namespace,
 			RootfsVersion:         rootfsVersion,
+			UpdatePartition:       updatePartition,
+			ConflictPolicy:        conflictPolicy,
+			CPU:                   cpuPolicy,
+			Memory:                memoryPolicy,
+			Shutdown:              shutdownPolicy,
 			LivenessProbeCreator:  livenessProbeCreator.Spy,
 			ReadinessProbeCreator: readinessProbeCreator.Spy,
 			Hasher:                hasher,
 			Logger:                lagertest.NewTestLogger("test-logger"),
 		}
+		if namespacer != nil {
+			desirer.Namespacer = namespacer
+		}
+		statefulSetDesirer = desirer
 	})
 
 	Context("When creating an LRP", func() {
@@ -135,23 +160,184 @@ var _ = Describe("Statefulset", func() {
 				Expect(string(statefulSet.Spec.Template.Spec.Containers[0].ImagePullPolicy)).To(Equal("Always"))
 			})
 
+			It("should use a rolling update strategy", func() {
+				statefulSet := getStatefulSetFromK8s(lrp)
+				Expect(string(statefulSet.Spec.UpdateStrategy.Type)).To(Equal("RollingUpdate"))
+				Expect(*statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(0)))
+			})
+
+			Context("When an update partition is configured", func() {
+				BeforeEach(func() {
+					updatePartition = 2
+				})
+
+				It("should not partition the new app", func() {
+					statefulSet := getStatefulSetFromK8s(lrp)
+					Expect(*statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(0)))
+				})
+
+				Context("and the app is updated", func() {
+					JustBeforeEach(func() {
+						lrp.TargetInstances = 4
+						Expect(statefulSetDesirer.Update(lrp)).To(Succeed())
+					})
+
+					It("should set the partition of the rolling update", func() {
+						statefulSet := getStatefulSetFromK8s(lrp)
+						Expect(*statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+					})
+				})
+			})
+
+			It("should harden the security context of the app", func() {
+				statefulSet := getStatefulSetFromK8s(lrp)
+				Expect(*statefulSet.Spec.Template.Spec.SecurityContext.RunAsUser).To(Equal(int64(VcapUID)))
+				securityContext := statefulSet.Spec.Template.Spec.Containers[0].SecurityContext
+				Expect(*securityContext.AllowPrivilegeEscalation).To(BeFalse())
+			})
+
 			It("should set rootfsVersion as a label", func() {
 				statefulSet := getStatefulSetFromK8s(lrp)
 				Expect(statefulSet.Labels).To(HaveKeyWithValue(rootfspatcher.RootfsVersionLabel, rootfsVersion))
 				Expect(statefulSet.Spec.Template.Labels).To(HaveKeyWithValue(rootfspatcher.RootfsVersionLabel, rootfsVersion))
 			})
 
-			Context("When redeploying an existing LRP", func() {
+			It("should keep the CPU weight of the app", func() {
+				statefulSet := getStatefulSetFromK8s(lrp)
+				Expect(statefulSet.Annotations).To(HaveKeyWithValue(eirini.CPUWeight, "0"))
+			})
+
+			Context("When memory is overcommitted", func() {
+				BeforeEach(func() {
+					memoryPolicy = MemoryPolicy{RequestRatio: 0.5}
+				})
+
+				It("should request part of the memory of the app and limit it to all of it", func() {
+					resources := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Containers[0].Resources
+					Expect(resources.Limits.Memory().String()).To(Equal("1024M"))
+					Expect(resources.Requests.Memory().String()).To(Equal("512M"))
+				})
+
+				It("should still get the memory of the app", func() {
+					actualLRP, getErr := statefulSetDesirer.Get(lrp.LRPIdentifier)
+					Expect(getErr).ToNot(HaveOccurred())
+					Expect(actualLRP.MemoryMB).To(Equal(int64(1024)))
+				})
+			})
+
+			Context("When CPU is limited in proportion to memory", func() {
+				BeforeEach(func() {
+					cpuPolicy = CPUPolicy{Type: CPUPolicyLimits, MillicoresPerGB: 500, OvercommitRatio: 2}
+				})
+
+				It("should limit the CPU of the app and request it overcommitted", func() {
+					resources := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Containers[0].Resources
+					Expect(resources.Limits.Cpu().String()).To(Equal("500m"))
+					Expect(resources.Requests.Cpu().String()).To(Equal("250m"))
+				})
+			})
+
+			Context("When instances drain before they stop", func() {
 				BeforeEach(func() {
-					lrp = createLRP("Baldur", "my.example.route")
-					_, createErr := client.AppsV1().StatefulSets(namespace).Create(toStatefulSet(lrp))
-					Expect(createErr).ToNot(HaveOccurred())
+					shutdownPolicy = ShutdownPolicy{GracePeriodSeconds: 60, DrainSeconds: 15}
 				})
 
-				It("should fail", func() {
-					Expect(err).To(HaveOccurred())
+				It("should give the instances the grace period and a preStop hook", func() {
+					podSpec := getStatefulSetFromK8s(lrp).Spec.Template.Spec
+					Expect(*podSpec.TerminationGracePeriodSeconds).To(Equal(int64(60)))
+					Expect(podSpec.Containers[0].Lifecycle.PreStop.Exec.Command).To(Equal([]string{"sleep", "15"}))
 				})
 			})
+
+		})
+
+		Context("When redeploying an existing LRP", func() {
+			var existingLRP *opi.LRP
+
+			BeforeEach(func() {
+				existingLRP = createLRP("Baldur", "my.example.route")
+				lrp = createLRP("Baldur", "my.example.route")
+			})
+
+			JustBeforeEach(func() {
+				existing := toStatefulSet(existingLRP)
+				existing.Annotations[eirini.OriginalRequest] = existingLRP.LRP
+				_, createErr := client.AppsV1().StatefulSets(namespace).Create(existing)
+				Expect(createErr).ToNot(HaveOccurred())
+
+				err = statefulSetDesirer.Desire(lrp)
+			})
+
+			Context("with the same request", func() {
+				It("should succeed", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("should not create another statefulset", func() {
+					Expect(listStatefulSets()).To(HaveLen(1))
+				})
+			})
+
+			Context("with a different request", func() {
+				BeforeEach(func() {
+					existingLRP.LRP = "an older request"
+				})
+
+				It("should return a conflict error", func() {
+					Expect(opi.IsConflict(err)).To(BeTrue())
+				})
+
+				It("should not change the existing statefulset", func() {
+					statefulSet := getStatefulSetFromK8s(lrp)
+					Expect(statefulSet.Annotations[eirini.OriginalRequest]).To(Equal("an older request"))
+				})
+
+				Context("and the conflict policy is to update", func() {
+					BeforeEach(func() {
+						conflictPolicy = ConflictPolicyUpdate
+					})
+
+					It("should succeed", func() {
+						Expect(err).ToNot(HaveOccurred())
+					})
+
+					It("should update the existing statefulset", func() {
+						Expect(listStatefulSets()).To(HaveLen(1))
+						statefulSet := getStatefulSetFromK8s(lrp)
+						Expect(statefulSet.Annotations[eirini.OriginalRequest]).To(Equal(lrp.LRP))
+						Expect(statefulSet.Annotations[cf.LastUpdated]).To(Equal(lrp.Metadata[cf.LastUpdated]))
+					})
+				})
+			})
+
+			Context("with a renamed app", func() {
+				BeforeEach(func() {
+					existingLRP.AppName = "Odin"
+					existingLRP.LRP = "an older request"
+					conflictPolicy = ConflictPolicyUpdate
+				})
+
+				It("should not create a second statefulset for the same app", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(listStatefulSets()).To(HaveLen(1))
+				})
+			})
+		})
+
+		Context("When the same LRP is desired concurrently", func() {
+			It("should create a single statefulset", func() {
+				errs := make(chan error, 10)
+				for i := 0; i < 10; i++ {
+					go func() {
+						errs <- statefulSetDesirer.Desire(createLRP("Baldur", "my.example.route"))
+					}()
+				}
+
+				for i := 0; i < 10; i++ {
+					Expect(<-errs).ToNot(HaveOccurred())
+				}
+				Expect(listStatefulSets()).To(HaveLen(1))
+			})
 		})
 
 		Context("When the app name contains unsupported characters", func() {
@@ -194,6 +380,59 @@ var _ = Describe("Statefulset", func() {
 			Expect(expectedLRP).To(Equal(actualLRP))
 		})
 
+		Context("when the app has a health check", func() {
+			BeforeEach(func() {
+				expectedLRP = createLRP("Thor", "my.example.route")
+				expectedLRP.GUID = "guid_5678"
+				expectedLRP.Metadata[cf.VcapAppID] = "guid_5678"
+				expectedLRP.Health = opi.Healtcheck{
+					Type:                "http",
+					Endpoint:            "/healthz",
+					ReadinessEndpoint:   "/ready",
+					Port:                8080,
+					TimeoutMs:           3000,
+					InvocationTimeoutMs: 2000,
+					IntervalMs:          10000,
+				}
+				statefulSet := toStatefulSet(expectedLRP)
+				statefulSet.Name = "thor"
+				statefulSet.Spec.Template.Spec.Containers[0].LivenessProbe = CreateLivenessProbe(expectedLRP)
+				statefulSet.Spec.Template.Spec.Containers[0].ReadinessProbe = CreateReadinessProbe(expectedLRP)
+				_, createErr := client.AppsV1().StatefulSets(namespace).Create(statefulSet)
+				Expect(createErr).ToNot(HaveOccurred())
+			})
+
+			JustBeforeEach(func() {
+				actualLRP, err = statefulSetDesirer.Get(opi.LRPIdentifier{GUID: "guid_5678", Version: "version_1234"})
+			})
+
+			It("should reconstruct the health check from the liveness probe", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualLRP.Health).To(Equal(expectedLRP.Health))
+			})
+
+			Context("which runs a command", func() {
+				BeforeEach(func() {
+					expectedLRP.Health = opi.Healtcheck{
+						Type:      "exec",
+						Command:   []string{"/bin/check"},
+						TimeoutMs: 3000,
+					}
+					statefulSet, getErr := client.AppsV1().StatefulSets(namespace).Get("thor", meta.GetOptions{})
+					Expect(getErr).ToNot(HaveOccurred())
+					statefulSet.Spec.Template.Spec.Containers[0].LivenessProbe = CreateLivenessProbe(expectedLRP)
+					statefulSet.Spec.Template.Spec.Containers[0].ReadinessProbe = CreateReadinessProbe(expectedLRP)
+					_, updateErr := client.AppsV1().StatefulSets(namespace).Update(statefulSet)
+					Expect(updateErr).ToNot(HaveOccurred())
+				})
+
+				It("should reconstruct the command", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(actualLRP.Health).To(Equal(expectedLRP.Health))
+				})
+			})
+		})
+
 		Context("when the app does not exist", func() {
 			JustBeforeEach(func() {
 				_, err = statefulSetDesirer.Get(opi.LRPIdentifier{GUID: "idontknow", Version: "42"})
@@ -267,6 +506,59 @@ var _ = Describe("Statefulset", func() {
 				})
 			})
 
+			Context("with modified app configuration", func() {
+				BeforeEach(func() {
+					livenessProbeCreator.Returns(&corev1.Probe{InitialDelaySeconds: 5})
+					readinessProbeCreator.Returns(&corev1.Probe{FailureThreshold: 1})
+				})
+
+				JustBeforeEach(func() {
+					lrp.Image = "eirini/new-droplet"
+					lrp.MemoryMB = 2048
+					lrp.DiskMB = 1024
+					lrp.CPUWeight = 50
+					lrp.Ports = []int32{8080}
+					lrp.Env = map[string]string{"NEW": "env"}
+					err = statefulSetDesirer.Update(lrp)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("updates the pod template", func() {
+					container := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Containers[0]
+					Expect(container.Image).To(Equal("eirini/new-droplet"))
+					Expect(container.Ports).To(Equal([]corev1.ContainerPort{{ContainerPort: 8080}}))
+					Expect(container.Resources.Limits.Memory().String()).To(Equal("2048M"))
+					Expect(container.Resources.Requests.Cpu().String()).To(Equal("500m"))
+					Expect(container.Resources.Limits.StorageEphemeral().String()).To(Equal("1024M"))
+					Expect(container.Resources.Requests.StorageEphemeral().String()).To(Equal("1024M"))
+					Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "NEW", Value: "env"}))
+					Expect(container.Env).To(ContainElement(corev1.EnvVar{
+						Name: "POD_NAME",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+						},
+					}))
+				})
+
+				It("recreates the probes", func() {
+					Expect(livenessProbeCreator.ArgsForCall(0)).To(Equal(lrp))
+					Expect(readinessProbeCreator.ArgsForCall(0)).To(Equal(lrp))
+					container := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Containers[0]
+					Expect(container.LivenessProbe).To(Equal(&corev1.Probe{InitialDelaySeconds: 5}))
+					Expect(container.ReadinessProbe).To(Equal(&corev1.Probe{FailureThreshold: 1}))
+				})
+
+				It("does not change the command", func() {
+					container := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Containers[0]
+					Expect(container.Command).To(Equal(originalStatefulSet.Spec.Template.Spec.Containers[0].Command))
+				})
+
+				It("rolls the change out with a rolling update", func() {
+					strategy := getStatefulSetFromK8s(lrp).Spec.UpdateStrategy
+					Expect(string(strategy.Type)).To(Equal("RollingUpdate"))
+					Expect(*strategy.RollingUpdate.Partition).To(Equal(int32(0)))
+				})
+			})
 		})
 
 		Context("when the app does not exist", func() {
@@ -385,6 +677,98 @@ var _ = Describe("Statefulset", func() {
 		})
 	})
 
+	Context("When the network policies of apps are synced", func() {
+		var (
+			networkPolicies *k8sfakes.FakeNetworkPolicySyncer
+			lrp             *opi.LRP
+		)
+
+		BeforeEach(func() {
+			networkPolicies = new(k8sfakes.FakeNetworkPolicySyncer)
+			lrp = createLRP("Baldur", "my.example.route")
+			livenessProbeCreator.Returns(&corev1.Probe{})
+			readinessProbeCreator.Returns(&corev1.Probe{})
+		})
+
+		JustBeforeEach(func() {
+			statefulSetDesirer.(*StatefulSetDesirer).NetworkPolicies = networkPolicies
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		It("should apply the network policies of a desired app", func() {
+			Expect(networkPolicies.AppDesiredCallCount()).To(Equal(1))
+			ns, guid := networkPolicies.AppDesiredArgsForCall(0)
+			Expect(ns).To(Equal(namespace))
+			Expect(guid).To(Equal(lrp.GUID))
+		})
+
+		It("should remove the network policies of a stopped app", func() {
+			Expect(statefulSetDesirer.Stop(lrp.LRPIdentifier)).To(Succeed())
+
+			Expect(networkPolicies.AppStoppedCallCount()).To(Equal(1))
+			ns, guid := networkPolicies.AppStoppedArgsForCall(0)
+			Expect(ns).To(Equal(namespace))
+			Expect(guid).To(Equal(lrp.GUID))
+		})
+
+		Context("and the network policies cannot be applied", func() {
+			BeforeEach(func() {
+				networkPolicies.AppDesiredReturns(errors.New("boom"))
+			})
+
+			It("should still desire the app", func() {
+				Expect(listStatefulSets()).To(HaveLen(1))
+			})
+		})
+	})
+
+	Context("When security groups are enforced", func() {
+		var (
+			securityGroups *k8sfakes.FakeSecurityGroupSyncer
+			lrp            *opi.LRP
+		)
+
+		BeforeEach(func() {
+			securityGroups = new(k8sfakes.FakeSecurityGroupSyncer)
+			lrp = createLRP("Baldur", "my.example.route")
+			lrp.SpaceGUID = "space-guid"
+			livenessProbeCreator.Returns(&corev1.Probe{})
+			readinessProbeCreator.Returns(&corev1.Probe{})
+		})
+
+		JustBeforeEach(func() {
+			statefulSetDesirer.(*StatefulSetDesirer).SecurityGroups = securityGroups
+		})
+
+		It("should restrict the egress of the app before its statefulset exists", func() {
+			securityGroups.SpaceDesiredStub = func(string, string, string) error {
+				Expect(listStatefulSets()).To(BeEmpty())
+				return nil
+			}
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+
+			Expect(securityGroups.SpaceDesiredCallCount()).To(Equal(1))
+			ns, spaceGUID, sourceType := securityGroups.SpaceDesiredArgsForCall(0)
+			Expect(ns).To(Equal(namespace))
+			Expect(spaceGUID).To(Equal("space-guid"))
+			Expect(sourceType).To(Equal(AppSourceType))
+			Expect(listStatefulSets()).To(HaveLen(1))
+		})
+
+		It("should not restrict the egress of an app without a space", func() {
+			lrp.SpaceGUID = ""
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+			Expect(securityGroups.SpaceDesiredCallCount()).To(Equal(0))
+			Expect(listStatefulSets()).To(HaveLen(1))
+		})
+
+		It("should not desire the app when its egress cannot be restricted", func() {
+			securityGroups.SpaceDesiredReturns(errors.New("boom"))
+			Expect(statefulSetDesirer.Desire(lrp)).ToNot(Succeed())
+			Expect(listStatefulSets()).To(BeEmpty())
+		})
+	})
+
 	Context("Stop an LRP instance", func() {
 
 		BeforeEach(func() {
@@ -426,11 +810,26 @@ var _ = Describe("Statefulset", func() {
 			})
 		})
 
+		Context("when K8s cannot be reached", func() {
+
+			It("should return a backend unavailable error", func() {
+
+				reaction := func(action testcore.Action) (handled bool, ret runtime.Object, err error) {
+					return true, nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+				}
+				client.PrependReactor("list", "statefulsets", reaction)
+
+				err = statefulSetDesirer.StopInstance(opi.LRPIdentifier{GUID: "guid_1234", Version: "version_1234"}, 1)
+				Expect(opi.IsBackendUnavailable(err)).To(BeTrue())
+			})
+		})
+
 		Context("when the statefulset does not exist", func() {
 
 			It("returns an error", func() {
 				err = statefulSetDesirer.StopInstance(opi.LRPIdentifier{GUID: "some", Version: "thing"}, 1)
-				Expect(err).To(MatchError("app does not exist"))
+				Expect(err).To(MatchError("app some-thing does not exist"))
+				Expect(opi.IsNotFound(err)).To(BeTrue())
 			})
 		})
 
@@ -439,6 +838,7 @@ var _ = Describe("Statefulset", func() {
 			It("returns an error", func() {
 				err = statefulSetDesirer.StopInstance(opi.LRPIdentifier{GUID: "guid_1234", Version: "version_1234"}, 42)
 				Expect(err).To(HaveOccurred())
+				Expect(opi.IsNotFound(err)).To(BeTrue())
 			})
 		})
 	})
@@ -539,6 +939,779 @@ var _ = Describe("Statefulset", func() {
 			})
 		})
 
+		Context("and the instances are scheduled on nodes in zones", func() {
+
+			BeforeEach(func() {
+				pod1.Spec.NodeName = "node-a"
+				pod2.Spec.NodeName = "node-b"
+
+				_, clientErr := client.CoreV1().Nodes().Create(&corev1.Node{
+					ObjectMeta: meta.ObjectMeta{Name: "node-a", Labels: map[string]string{ZoneLabel: "zone-a"}},
+				})
+				Expect(clientErr).ToNot(HaveOccurred())
+				_, clientErr = client.CoreV1().Nodes().Create(&corev1.Node{
+					ObjectMeta: meta.ObjectMeta{Name: "node-b", Labels: map[string]string{corev1.LabelZoneFailureDomain: "zone-b"}},
+				})
+				Expect(clientErr).ToNot(HaveOccurred())
+			})
+
+			It("should report the zone of each instance", func() {
+				Expect(instances).To(HaveLen(2))
+				Expect(instances[0].Zone).To(Equal("zone-a"))
+				Expect(instances[1].Zone).To(Equal("zone-b"))
+			})
+		})
+
+		Context("and the instances do not fit on any node", func() {
+
+			BeforeEach(func() {
+				pod1.Spec.Containers = []corev1.Container{
+					{
+						Name: "opi",
+						Resources: corev1.ResourceRequirements{
+							Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1024M")},
+							Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256M")},
+						},
+					},
+				}
+
+				event := &corev1.Event{
+					ObjectMeta: meta.ObjectMeta{Name: "failed-scheduling"},
+					Reason:     "FailedScheduling",
+					Message:    "0/3 nodes are available: 3 Insufficient memory.",
+				}
+				_, clientErr := client.CoreV1().Events(namespace).Create(event)
+				Expect(clientErr).ToNot(HaveOccurred())
+			})
+
+			It("should report the insufficient memory", func() {
+				Expect(instances).To(HaveLen(2))
+				Expect(instances[1].State).To(Equal(opi.ErrorState))
+				Expect(instances[1].PlacementError).To(Equal(opi.InsufficientMemoryError))
+			})
+
+			It("should explain when the memory overcommit policy lowered the request", func() {
+				Expect(instances[0].State).To(Equal(opi.ErrorState))
+				Expect(instances[0].PlacementError).To(Equal("Insufficient resources: memory (the memory overcommit policy requests 256M of the 1024M limit)"))
+			})
+		})
+
+	})
+
+	Context("When the app has multiple instances", func() {
+
+		var lrp *opi.LRP
+
+		getBudget := func() (*policyv1beta1.PodDisruptionBudget, error) {
+			return client.PolicyV1beta1().PodDisruptionBudgets(namespace).Get("baldur-space-foo-random", meta.GetOptions{})
+		}
+
+		minAvailable := func() int {
+			budget, getErr := getBudget()
+			Expect(getErr).ToNot(HaveOccurred())
+			return budget.Spec.MinAvailable.IntValue()
+		}
+
+		BeforeEach(func() {
+			lrp = createLRP("Baldur", "my.example.route")
+			lrp.TargetInstances = 3
+		})
+
+		JustBeforeEach(func() {
+			livenessProbeCreator.Returns(&corev1.Probe{})
+			readinessProbeCreator.Returns(&corev1.Probe{})
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		It("should keep the majority of the instances available", func() {
+			budget, getErr := getBudget()
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(budget.Spec.MinAvailable.IntValue()).To(Equal(2))
+			Expect(budget.Spec.Selector.MatchLabels).To(Equal(map[string]string{
+				"guid":        "guid_1234",
+				"version":     "version_1234",
+				"source_type": "APP",
+			}))
+			Expect(budget.OwnerReferences).To(HaveLen(1))
+			Expect(budget.OwnerReferences[0].Kind).To(Equal("StatefulSet"))
+		})
+
+		Context("and it is scaled up", func() {
+			JustBeforeEach(func() {
+				lrp.TargetInstances = 5
+				Expect(statefulSetDesirer.Update(lrp)).To(Succeed())
+			})
+
+			It("should adjust the budget", func() {
+				Expect(minAvailable()).To(Equal(3))
+			})
+		})
+
+		Context("and it is scaled down to a single instance", func() {
+			JustBeforeEach(func() {
+				lrp.TargetInstances = 1
+				Expect(statefulSetDesirer.Update(lrp)).To(Succeed())
+			})
+
+			It("should delete the budget", func() {
+				_, getErr := getBudget()
+				Expect(apierrors.IsNotFound(getErr)).To(BeTrue())
+			})
+		})
+
+		Context("and it is stopped", func() {
+			JustBeforeEach(func() {
+				Expect(statefulSetDesirer.Stop(lrp.LRPIdentifier)).To(Succeed())
+			})
+
+			It("should delete the budget", func() {
+				_, getErr := getBudget()
+				Expect(apierrors.IsNotFound(getErr)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("When the app has a single instance", func() {
+		JustBeforeEach(func() {
+			livenessProbeCreator.Returns(&corev1.Probe{})
+			readinessProbeCreator.Returns(&corev1.Probe{})
+			lrp := createLRP("Baldur", "my.example.route")
+			lrp.TargetInstances = 1
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		It("should not create a budget", func() {
+			budgets, listErr := client.PolicyV1beta1().PodDisruptionBudgets(namespace).List(meta.ListOptions{})
+			Expect(listErr).ToNot(HaveOccurred())
+			Expect(budgets.Items).To(BeEmpty())
+		})
+	})
+
+	Context("When a placement policy is configured", func() {
+
+		var (
+			placement PlacementPolicy
+			lrp       *opi.LRP
+		)
+
+		podAntiAffinity := func() *corev1.PodAntiAffinity {
+			affinity := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Affinity
+			Expect(affinity).ToNot(BeNil())
+			return affinity.PodAntiAffinity
+		}
+
+		instancesOfApp := &meta.LabelSelector{MatchLabels: map[string]string{"guid": "guid_1234"}}
+
+		JustBeforeEach(func() {
+			statefulSetDesirer.(*StatefulSetDesirer).Placement = placement
+			livenessProbeCreator.Returns(&corev1.Probe{})
+			readinessProbeCreator.Returns(&corev1.Probe{})
+			lrp = createLRP("Baldur", "my.example.route")
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		Context("that requires anti-affinity", func() {
+			BeforeEach(func() {
+				placement = PlacementPolicy{AntiAffinity: AntiAffinityRequired}
+			})
+
+			It("should never put two instances on one node", func() {
+				Expect(podAntiAffinity().RequiredDuringSchedulingIgnoredDuringExecution).To(ConsistOf(corev1.PodAffinityTerm{
+					LabelSelector: instancesOfApp,
+					TopologyKey:   "kubernetes.io/hostname",
+				}))
+			})
+		})
+
+		Context("that prefers anti-affinity and spreads zones", func() {
+			BeforeEach(func() {
+				placement = PlacementPolicy{AntiAffinity: AntiAffinityPreferred, SpreadZones: true}
+			})
+
+			It("should prefer spreading instances over nodes and zones", func() {
+				antiAffinity := podAntiAffinity()
+				Expect(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+				Expect(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(ConsistOf(
+					corev1.WeightedPodAffinityTerm{
+						Weight:          100,
+						PodAffinityTerm: corev1.PodAffinityTerm{LabelSelector: instancesOfApp, TopologyKey: "kubernetes.io/hostname"},
+					},
+					corev1.WeightedPodAffinityTerm{
+						Weight:          50,
+						PodAffinityTerm: corev1.PodAffinityTerm{LabelSelector: instancesOfApp, TopologyKey: ZoneLabel},
+					},
+				))
+			})
+		})
+
+		Context("that places instances anywhere", func() {
+			BeforeEach(func() {
+				placement = PlacementPolicy{AntiAffinity: AntiAffinityNone}
+			})
+
+			It("should not set an affinity", func() {
+				Expect(getStatefulSetFromK8s(lrp).Spec.Template.Spec.Affinity).To(BeNil())
+			})
+		})
+	})
+
+	Context("When the app has service credentials", func() {
+
+		var lrp *opi.LRP
+
+		getEnvSecret := func() *corev1.Secret {
+			secret, getErr := client.CoreV1().Secrets(namespace).Get("baldur-space-foo-random-env", meta.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			return secret
+		}
+
+		BeforeEach(func() {
+			lrp = createLRP("Baldur", "my.example.route")
+			lrp.Env = map[string]string{
+				"PORT":          "8080",
+				"VCAP_SERVICES": `{"user-provided": [{"credentials": {"password": "notpassword1"}}]}`,
+			}
+		})
+
+		Context("and it is desired", func() {
+
+			JustBeforeEach(func() {
+				Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+			})
+
+			It("should store them in a secret owned by the statefulset", func() {
+				secret := getEnvSecret()
+				Expect(secret.Data).To(Equal(map[string][]byte{
+					"VCAP_SERVICES": []byte(`{"user-provided": [{"credentials": {"password": "notpassword1"}}]}`),
+				}))
+				Expect(secret.OwnerReferences).To(ConsistOf(meta.OwnerReference{
+					APIVersion: "apps/v1",
+					Kind:       "StatefulSet",
+					Name:       "baldur-space-foo-random",
+				}))
+			})
+
+			It("should read them from the secret in the container", func() {
+				env := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Containers[0].Env
+				Expect(env).To(ContainElement(corev1.EnvVar{Name: "PORT", Value: "8080"}))
+				Expect(env).To(ContainElement(corev1.EnvVar{
+					Name: "VCAP_SERVICES",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "baldur-space-foo-random-env"},
+							Key:                  "VCAP_SERVICES",
+						},
+					},
+				}))
+			})
+
+			It("should not report them with the app", func() {
+				actualLRP, getErr := statefulSetDesirer.Get(lrp.LRPIdentifier)
+				Expect(getErr).ToNot(HaveOccurred())
+				Expect(actualLRP.Env).ToNot(HaveKey("VCAP_SERVICES"))
+			})
+
+			Context("and they change", func() {
+				JustBeforeEach(func() {
+					lrp.Env = map[string]string{"VCAP_SERVICES": "{}"}
+					Expect(statefulSetDesirer.Update(lrp)).To(Succeed())
+				})
+
+				It("should update the secret", func() {
+					Expect(getEnvSecret().Data).To(HaveKeyWithValue("VCAP_SERVICES", []byte("{}")))
+				})
+			})
+
+			Context("and the app is updated without an environment", func() {
+				JustBeforeEach(func() {
+					lrp.Env = map[string]string{}
+					lrp.KeepSensitiveEnv = true
+					Expect(statefulSetDesirer.Update(lrp)).To(Succeed())
+				})
+
+				It("should keep reading them from the secret", func() {
+					env := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Containers[0].Env
+					Expect(envNames(env)).To(ContainElement("VCAP_SERVICES"))
+					Expect(getEnvSecret().Data).To(HaveKey("VCAP_SERVICES"))
+				})
+			})
+
+			Context("and the service is unbound", func() {
+				JustBeforeEach(func() {
+					lrp.Env = map[string]string{"PORT": "8080"}
+					Expect(statefulSetDesirer.Update(lrp)).To(Succeed())
+				})
+
+				It("should stop reading them from the secret", func() {
+					env := getStatefulSetFromK8s(lrp).Spec.Template.Spec.Containers[0].Env
+					Expect(envNames(env)).ToNot(ContainElement("VCAP_SERVICES"))
+					Expect(getEnvSecret().Data).ToNot(HaveKey("VCAP_SERVICES"))
+				})
+			})
+
+			Context("and it is desired again with other credentials", func() {
+				BeforeEach(func() {
+					conflictPolicy = ConflictPolicyUpdate
+				})
+
+				JustBeforeEach(func() {
+					lrp.Env = map[string]string{"VCAP_SERVICES": "{}"}
+					Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+				})
+
+				It("should update the secret, although the redacted request is the same", func() {
+					Expect(getEnvSecret().Data).To(HaveKeyWithValue("VCAP_SERVICES", []byte("{}")))
+				})
+			})
+		})
+
+		Context("and the statefulset cannot be created", func() {
+			BeforeEach(func() {
+				client.PrependReactor("create", "statefulsets", func(action testcore.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("boom")
+				})
+			})
+
+			It("should not leave the secret behind", func() {
+				Expect(statefulSetDesirer.Desire(lrp)).ToNot(Succeed())
+				secrets, listErr := client.CoreV1().Secrets(namespace).List(meta.ListOptions{})
+				Expect(listErr).ToNot(HaveOccurred())
+				Expect(secrets.Items).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("When the original request is too large for an annotation", func() {
+
+		var lrp *opi.LRP
+
+		BeforeEach(func() {
+			lrp = createLRP("Baldur", "my.example.route")
+			lrp.LRP = strings.Repeat("a very long request ", 10000)
+		})
+
+		JustBeforeEach(func() {
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		It("should store it in a configmap owned by the statefulset", func() {
+			statefulSet := getStatefulSetFromK8s(lrp)
+			Expect(statefulSet.Annotations).ToNot(HaveKey(eirini.OriginalRequest))
+			Expect(statefulSet.Annotations).To(HaveKeyWithValue(eirini.OriginalRequestConfigMap, "baldur-space-foo-random-request"))
+
+			configMap, getErr := client.CoreV1().ConfigMaps(namespace).Get("baldur-space-foo-random-request", meta.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(len(configMap.BinaryData["original_request.gz"])).To(BeNumerically("<", len(lrp.LRP)))
+			Expect(configMap.OwnerReferences).To(HaveLen(1))
+			Expect(configMap.OwnerReferences[0].Kind).To(Equal("StatefulSet"))
+		})
+
+		It("should resolve it when getting the app", func() {
+			actualLRP, getErr := statefulSetDesirer.Get(lrp.LRPIdentifier)
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(actualLRP.LRP).To(Equal(lrp.LRP))
+		})
+
+		It("should recognise the same request when the app is desired again", func() {
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		Context("and the request shrinks", func() {
+			BeforeEach(func() {
+				conflictPolicy = ConflictPolicyUpdate
+			})
+
+			JustBeforeEach(func() {
+				lrp.LRP = "a short request"
+				Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+			})
+
+			It("should store it as an annotation again", func() {
+				statefulSet := getStatefulSetFromK8s(lrp)
+				Expect(statefulSet.Annotations).To(HaveKeyWithValue(eirini.OriginalRequest, "a short request"))
+				Expect(statefulSet.Annotations).ToNot(HaveKey(eirini.OriginalRequestConfigMap))
+			})
+		})
+	})
+
+	Context("When apps are spread over namespaces", func() {
+
+		const spaceNamespace = "testing-space-the-space-guid"
+
+		var lrp *opi.LRP
+
+		BeforeEach(func() {
+			namespacer = new(k8sfakes.FakeNamespacer)
+			namespacer.EnsureNamespaceReturns(spaceNamespace, nil)
+			namespacer.WatchNamespaceReturns(meta.NamespaceAll)
+
+			lrp = createLRP("Baldur", "my.example.route")
+			lrp.OrgGUID = "the-org-guid"
+			lrp.SpaceGUID = "the-space-guid"
+		})
+
+		JustBeforeEach(func() {
+			err = statefulSetDesirer.Desire(lrp)
+		})
+
+		It("should create the statefulset in the namespace of the space", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(namespacer.EnsureNamespaceCallCount()).To(Equal(1))
+			orgGUID, spaceGUID := namespacer.EnsureNamespaceArgsForCall(0)
+			Expect(orgGUID).To(Equal("the-org-guid"))
+			Expect(spaceGUID).To(Equal("the-space-guid"))
+
+			statefulSets, listErr := client.AppsV1().StatefulSets(spaceNamespace).List(meta.ListOptions{})
+			Expect(listErr).ToNot(HaveOccurred())
+			Expect(statefulSets.Items).To(HaveLen(1))
+		})
+
+		It("should label the pods of the app with the space", func() {
+			statefulSets, listErr := client.AppsV1().StatefulSets(spaceNamespace).List(meta.ListOptions{})
+			Expect(listErr).ToNot(HaveOccurred())
+			Expect(statefulSets.Items[0].Spec.Template.Labels).To(HaveKeyWithValue(SpaceGUIDLabel, "the-space-guid"))
+		})
+
+		It("should find the app by its identifier", func() {
+			actualLRP, getErr := statefulSetDesirer.Get(lrp.LRPIdentifier)
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(actualLRP.AppName).To(Equal("Baldur"))
+		})
+
+		It("should update the app in its namespace", func() {
+			lrp.TargetInstances = 5
+			Expect(statefulSetDesirer.Update(lrp)).To(Succeed())
+
+			statefulSets, listErr := client.AppsV1().StatefulSets(spaceNamespace).List(meta.ListOptions{})
+			Expect(listErr).ToNot(HaveOccurred())
+			Expect(*statefulSets.Items[0].Spec.Replicas).To(Equal(int32(5)))
+		})
+
+		It("should stop an instance in its namespace", func() {
+			_, createErr := client.CoreV1().Pods(spaceNamespace).Create(toPod("baldur-space-foo-random", 0, nil))
+			Expect(createErr).ToNot(HaveOccurred())
+
+			Expect(statefulSetDesirer.StopInstance(lrp.LRPIdentifier, 0)).To(Succeed())
+
+			pods, listErr := client.CoreV1().Pods(spaceNamespace).List(meta.ListOptions{})
+			Expect(listErr).ToNot(HaveOccurred())
+			Expect(pods.Items).To(BeEmpty())
+		})
+
+		It("should stop the app in its namespace", func() {
+			Expect(statefulSetDesirer.Stop(lrp.LRPIdentifier)).To(Succeed())
+
+			statefulSets, listErr := client.AppsV1().StatefulSets(spaceNamespace).List(meta.ListOptions{})
+			Expect(listErr).ToNot(HaveOccurred())
+			Expect(statefulSets.Items).To(BeEmpty())
+		})
+
+		Context("and the namespace cannot be created", func() {
+			BeforeEach(func() {
+				namespacer.EnsureNamespaceReturns("", opi.NewBackendUnavailableError("boom"))
+			})
+
+			It("should not create the app", func() {
+				Expect(opi.IsBackendUnavailable(err)).To(BeTrue())
+				Expect(listStatefulSets()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("When the app is placed in an isolation segment", func() {
+
+		var lrp *opi.LRP
+
+		JustBeforeEach(func() {
+			statefulSetDesirer.(*StatefulSetDesirer).Placement = PlacementPolicy{
+				IsolationSegments: map[string]eirini.IsolationSegment{
+					"dedicated": {
+						NodeSelector: map[string]string{"segment": "dedicated"},
+						Tolerations: []eirini.Toleration{
+							{Key: "segment", Operator: "Equal", Value: "dedicated", Effect: "NoSchedule"},
+						},
+						RuntimeClassName: "gvisor",
+					},
+				},
+			}
+			livenessProbeCreator.Returns(&corev1.Probe{})
+			readinessProbeCreator.Returns(&corev1.Probe{})
+			err = statefulSetDesirer.Desire(lrp)
+		})
+
+		Context("which is configured", func() {
+			BeforeEach(func() {
+				lrp = createLRP("Baldur", "my.example.route")
+				lrp.PlacementTags = []string{"dedicated"}
+			})
+
+			It("should run the app on the nodes of the segment", func() {
+				Expect(err).ToNot(HaveOccurred())
+				spec := getStatefulSetFromK8s(lrp).Spec.Template.Spec
+				Expect(spec.NodeSelector).To(Equal(map[string]string{"segment": "dedicated"}))
+				Expect(spec.Tolerations).To(ConsistOf(corev1.Toleration{
+					Key:      "segment",
+					Operator: corev1.TolerationOpEqual,
+					Value:    "dedicated",
+					Effect:   corev1.TaintEffectNoSchedule,
+				}))
+				Expect(spec.RuntimeClassName).To(PointTo(Equal("gvisor")))
+			})
+
+			It("should keep the placement tags", func() {
+				actualLRP, getErr := statefulSetDesirer.Get(lrp.LRPIdentifier)
+				Expect(getErr).ToNot(HaveOccurred())
+				Expect(actualLRP.PlacementTags).To(ConsistOf("dedicated"))
+			})
+		})
+
+		Context("which is not configured", func() {
+			BeforeEach(func() {
+				lrp = createLRP("Baldur", "my.example.route")
+				lrp.PlacementTags = []string{"unknown"}
+			})
+
+			It("should reject the app", func() {
+				Expect(opi.IsInvalid(err)).To(BeTrue())
+				Expect(listStatefulSets()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("When the services of the app cannot be created", func() {
+		var (
+			lrp        *opi.LRP
+			desireErr  error
+			failedOnce bool
+		)
+
+		BeforeEach(func() {
+			lrp = createLRP("Baldur", "my.example.route")
+			failedOnce = false
+			client.PrependReactor("create", "services", func(action testcore.Action) (bool, runtime.Object, error) {
+				if failedOnce {
+					return false, nil, nil
+				}
+				failedOnce = true
+				return true, nil, errors.New("boom")
+			})
+		})
+
+		JustBeforeEach(func() {
+			livenessProbeCreator.Returns(&corev1.Probe{})
+			readinessProbeCreator.Returns(&corev1.Probe{})
+			desireErr = statefulSetDesirer.Desire(lrp)
+		})
+
+		It("should fail the desire", func() {
+			Expect(desireErr).To(HaveOccurred())
+		})
+
+		Context("and the app is desired again", func() {
+			JustBeforeEach(func() {
+				Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+			})
+
+			It("should create the services", func() {
+				list, listErr := client.CoreV1().Services(namespace).List(meta.ListOptions{})
+				Expect(listErr).ToNot(HaveOccurred())
+				Expect(list.Items).To(HaveLen(2))
+			})
+		})
+	})
+
+	Context("When the app has internal routes", func() {
+		var lrp *opi.LRP
+
+		serviceNames := func() []string {
+			list, listErr := client.CoreV1().Services(namespace).List(meta.ListOptions{})
+			Expect(listErr).ToNot(HaveOccurred())
+			names := []string{}
+			for _, service := range list.Items {
+				names = append(names, service.Name)
+			}
+			return names
+		}
+
+		getService := func(name string) *corev1.Service {
+			service, getErr := client.CoreV1().Services(namespace).Get(name, meta.GetOptions{})
+			Expect(getErr).ToNot(HaveOccurred())
+			return service
+		}
+
+		BeforeEach(func() {
+			lrp = createLRP("Baldur", "my.example.route")
+			lrp.InternalRoutes = []string{"baldur.apps.internal"}
+		})
+
+		JustBeforeEach(func() {
+			livenessProbeCreator.Returns(&corev1.Probe{})
+			readinessProbeCreator.Returns(&corev1.Probe{})
+			Expect(statefulSetDesirer.Desire(lrp)).To(Succeed())
+		})
+
+		It("should create the services of the app", func() {
+			Expect(serviceNames()).To(ConsistOf(
+				"cf-baldur-space-foo-random",
+				"cf-baldur-space-foo-random-headless",
+				"cf-baldur-apps-internal",
+			))
+		})
+
+		It("should expose the app ports", func() {
+			service := getService("cf-baldur-space-foo-random")
+			Expect(service.Spec.Selector).To(Equal(map[string]string{
+				"guid":        "guid_1234",
+				"version":     "version_1234",
+				"source_type": "APP",
+			}))
+			Expect(service.Spec.Ports).To(HaveLen(2))
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(8888)))
+			Expect(service.Spec.Ports[1].Port).To(Equal(int32(9999)))
+		})
+
+		It("should resolve internal routes to the instances", func() {
+			service := getService("cf-baldur-apps-internal")
+			Expect(service.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+			Expect(service.Annotations).To(HaveKeyWithValue(eirini.InternalRoute, "baldur.apps.internal"))
+		})
+
+		It("should resolve internal routes to every version of the app", func() {
+			service := getService("cf-baldur-apps-internal")
+			Expect(service.Spec.Selector).To(Equal(map[string]string{
+				"guid":        "guid_1234",
+				"source_type": "APP",
+			}))
+		})
+
+		It("should give the instances DNS names", func() {
+			Expect(getStatefulSetFromK8s(lrp).Spec.ServiceName).To(Equal("cf-baldur-space-foo-random-headless"))
+		})
+
+		It("should keep the internal routes", func() {
+			actualLRP, getErr := statefulSetDesirer.Get(lrp.LRPIdentifier)
+			Expect(getErr).ToNot(HaveOccurred())
+			Expect(actualLRP.InternalRoutes).To(ConsistOf("baldur.apps.internal"))
+		})
+
+		Context("and the internal routes are updated", func() {
+			JustBeforeEach(func() {
+				lrp.InternalRoutes = []string{"odin.apps.internal"}
+				Expect(statefulSetDesirer.Update(lrp)).To(Succeed())
+			})
+
+			It("should replace the services of the internal routes", func() {
+				Expect(serviceNames()).To(ConsistOf(
+					"cf-baldur-space-foo-random",
+					"cf-baldur-space-foo-random-headless",
+					"cf-odin-apps-internal",
+				))
+			})
+		})
+
+		Context("and it is stopped", func() {
+			JustBeforeEach(func() {
+				Expect(statefulSetDesirer.Stop(lrp.LRPIdentifier)).To(Succeed())
+			})
+
+			It("should delete the services", func() {
+				Expect(serviceNames()).To(BeEmpty())
+			})
+		})
+
+		Context("and a new version of the app is desired", func() {
+			var newLRP *opi.LRP
+
+			JustBeforeEach(func() {
+				newLRP = createLRP("Baldur-v2", "my.example.route")
+				newLRP.Version = "version_5678"
+				newLRP.InternalRoutes = []string{"baldur.apps.internal"}
+				Expect(statefulSetDesirer.Desire(newLRP)).To(Succeed())
+			})
+
+			It("should share the service of the internal route", func() {
+				service := getService("cf-baldur-apps-internal")
+				Expect(service.Spec.Selector).To(HaveKeyWithValue("guid", "guid_1234"))
+				Expect(service.OwnerReferences).To(HaveLen(2))
+			})
+
+			Context("and the old version is stopped", func() {
+				JustBeforeEach(func() {
+					Expect(statefulSetDesirer.Stop(lrp.LRPIdentifier)).To(Succeed())
+				})
+
+				It("should keep the service of the internal route", func() {
+					service := getService("cf-baldur-apps-internal")
+					Expect(service.OwnerReferences).To(HaveLen(1))
+					Expect(service.OwnerReferences[0].Name).To(Equal("baldur-v2-space-foo-random"))
+				})
+			})
+		})
+
+		Context("and another app maps the internal route", func() {
+			var otherLRP *opi.LRP
+
+			JustBeforeEach(func() {
+				otherLRP = createLRP("Freya", "my.example.route")
+				otherLRP.GUID = "guid_5678"
+				otherLRP.InternalRoutes = []string{"baldur.apps.internal"}
+				Expect(statefulSetDesirer.Desire(otherLRP)).To(Succeed())
+			})
+
+			It("should keep resolving the route to the first app", func() {
+				service := getService("cf-baldur-apps-internal")
+				Expect(service.Spec.Selector).To(HaveKeyWithValue("guid", "guid_1234"))
+			})
+
+			Context("and the first app is stopped", func() {
+				JustBeforeEach(func() {
+					Expect(statefulSetDesirer.Stop(lrp.LRPIdentifier)).To(Succeed())
+				})
+
+				It("should resolve the route to the other app", func() {
+					service := getService("cf-baldur-apps-internal")
+					Expect(service.Spec.Selector).To(HaveKeyWithValue("guid", "guid_5678"))
+				})
+
+				Context("and the other app unmaps the route", func() {
+					JustBeforeEach(func() {
+						otherLRP.InternalRoutes = nil
+						Expect(statefulSetDesirer.Update(otherLRP)).To(Succeed())
+					})
+
+					It("should delete the service of the internal route", func() {
+						Expect(serviceNames()).To(ConsistOf(
+							"cf-freya-space-foo-random",
+							"cf-freya-space-foo-random-headless",
+						))
+					})
+				})
+			})
+		})
+
+		Context("and the service of the internal route was written by an older release", func() {
+			BeforeEach(func() {
+				service := &corev1.Service{}
+				service.Name = "cf-baldur-apps-internal"
+				service.Labels = map[string]string{
+					"guid":        "guid_1234",
+					"version":     "version_0",
+					"source_type": "APP",
+				}
+				service.Spec.Selector = service.Labels
+				_, createErr := client.CoreV1().Services(namespace).Create(service)
+				Expect(createErr).ToNot(HaveOccurred())
+			})
+
+			It("should adopt the service", func() {
+				service := getService("cf-baldur-apps-internal")
+				Expect(service.Spec.Selector).To(Equal(map[string]string{
+					"guid":        "guid_1234",
+					"source_type": "APP",
+				}))
+				Expect(service.Annotations).To(HaveKeyWithValue(eirini.InternalRoute, "baldur.apps.internal"))
+			})
+		})
 	})
 })
 
@@ -681,6 +1854,7 @@ func toStatefulSet(lrp *opi.LRP) *appsv1.StatefulSet {
 	statefulSet.Annotations = lrp.Metadata
 	statefulSet.Annotations[eirini.RegisteredRoutes] = lrp.Metadata[cf.VcapAppUris]
 	statefulSet.Annotations[cf.VcapSpaceName] = lrp.SpaceName
+	statefulSet.Annotations[eirini.CPUWeight] = strconv.Itoa(int(lrp.CPUWeight))
 
 	return statefulSet
 }
@@ -734,6 +1908,7 @@ func createLRP(name, routes string) *opi.LRP {
 		RunningInstances: 0,
 		MemoryMB:         1024,
 		Image:            "busybox",
+		Env:              map[string]string{"HOWARD": "the alien"},
 		Ports:            []int32{8888, 9999},
 		Metadata: map[string]string{
 			cf.ProcessGUID: name + "-guid",
@@ -753,6 +1928,14 @@ func createLRP(name, routes string) *opi.LRP {
 	}
 }
 
+func envNames(env []corev1.EnvVar) []string {
+	names := []string{}
+	for _, e := range env {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
 func cleanupMetadata(m map[string]string) map[string]string {
 	var fields = []string{
 		"process_guid",
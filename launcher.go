@@ -1,5 +1,12 @@
 package eirini
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"code.cloudfoundry.org/eirini/opi"
+)
+
 const (
 	Launch   = "/lifecycle/launch"
 	Launcher = "/lifecycle/launcher"
@@ -7,16 +14,37 @@ const (
 
 var InitProcess = []string{"dumb-init", "--"}
 
-func SetupEnv(startCmd string) map[string]string {
+type instancePort struct {
+	External int32 `json:"external"`
+	Internal int32 `json:"internal"`
+}
+
+// SetupEnv returns the environment of the lifecycle launcher. The instance
+// address is the one of the first port, and CF_INSTANCE_PORTS lists every
+// port.
+func SetupEnv(startCmd string, ports []int32) map[string]string {
+	if len(ports) == 0 {
+		ports = []int32{opi.DefaultPort}
+	}
+
+	instancePorts := make([]instancePort, 0, len(ports))
+	for _, port := range ports {
+		instancePorts = append(instancePorts, instancePort{External: port, Internal: port})
+	}
+	instancePortsJSON, err := json.Marshal(instancePorts)
+	if err != nil {
+		panic(err)
+	}
+
 	return map[string]string{
 		"HOME": "/home/vcap/app",
 		"LANG": "en_US.UTF-8",
 		"PATH": "/usr/local/bin:/usr/bin:/bin",
 		"USER": "vcap",
 
-		"CF_INSTANCE_ADDR":  "0.0.0.0:8080",
-		"CF_INSTANCE_PORT":  "8080",
-		"CF_INSTANCE_PORTS": `[{"external":8080,"internal":8080}]`,
+		"CF_INSTANCE_ADDR":  fmt.Sprintf("0.0.0.0:%d", ports[0]),
+		"CF_INSTANCE_PORT":  fmt.Sprint(ports[0]),
+		"CF_INSTANCE_PORTS": string(instancePortsJSON),
 		"TMPDIR":            "/home/vcap/tmp",
 		"START_COMMAND":     startCmd,
 	}
@@ -19,8 +19,17 @@ const (
 	EnvCompletionCallback = "COMPLETION_CALLBACK"
 	EnvEiriniAddress      = "EIRINI_ADDRESS"
 
-	RegisteredRoutes = "routes"
-	OriginalRequest  = "original_request"
+	RegisteredRoutes         = "routes"
+	OriginalRequest          = "original_request"
+	OriginalRequestConfigMap = "original_request_configmap"
+	InternalRoutes           = "internal_routes"
+	InternalRoute            = "internal_route"
+	InternalRouteClaims      = "internal_route_claims"
+	PlacementTags            = "placement_tags"
+	CPUWeight                = "cpu_weight"
+	CompletionCallback       = "completion_callback"
+	CompletionHandled        = "completion_handled"
+	InstanceIndex            = "instance_index"
 
 	RecipeBuildPacksDir    = "/var/lib/buildpacks"
 	RecipeBuildPacksName   = "recipe-buildpacks"
@@ -69,11 +78,125 @@ type Properties struct {
 	KubeConfigPath string `yaml:"kube_config_path"`
 
 	RootfsVersion string `yaml:"rootfs_version"`
+
+	// UpdatePartition is the number of instances of an app which keep the
+	// previous version during a rollout until the others are ready. Only
+	// the statefulset lrp_backend supports it.
+	UpdatePartition int32 `yaml:"update_partition"`
+	// MaxUnavailable is the number or percentage of the instances of an app
+	// which may be down during a rollout, e.g. 1 or 25% (the default). Only
+	// the deployment lrp_backend supports it.
+	MaxUnavailable string `yaml:"max_unavailable"`
+
+	DesireConflictPolicy string `yaml:"desire_conflict_policy"`
+	LRPBackend           string `yaml:"lrp_backend"`
+
+	// NamespaceStrategy is one of single (the default), per-org or
+	// per-space. The latter create a namespace for each org or space next
+	// to KubeNamespace, which keeps running staging jobs.
+	NamespaceStrategy string `yaml:"namespace_strategy"`
+
+	// PodAntiAffinity is none (the default), preferred or required, and
+	// keeps the instances of an app off the same node
+	PodAntiAffinity string `yaml:"pod_anti_affinity"`
+	// SpreadZones prefers to put the instances of an app in different zones
+	SpreadZones bool `yaml:"spread_zones"`
+	// IsolationSegments maps the placement tags of isolation segments to
+	// the nodes which run their apps
+	IsolationSegments map[string]IsolationSegment `yaml:"isolation_segments"`
+
+	SecurityContext  SecurityContext  `yaml:"security_context"`
+	SecurityGroups   SecurityGroups   `yaml:"security_groups"`
+	NetworkPolicies  NetworkPolicies  `yaml:"network_policies"`
+	CPU              CPU              `yaml:"cpu"`
+	Memory           Memory           `yaml:"memory"`
+	GracefulShutdown GracefulShutdown `yaml:"graceful_shutdown"`
+}
+
+// GracefulShutdown gives app instances DrainSeconds to finish serving the
+// requests routed to them before they are stopped, and GracePeriodSeconds
+// in total before they are killed
+type GracefulShutdown struct {
+	GracePeriodSeconds int64 `yaml:"grace_period_seconds"`
+	DrainSeconds       int64 `yaml:"drain_seconds"`
+}
+
+// Memory overcommits the memory of nodes by letting apps request only
+// RequestRatio of their memory quota, and at least MinRequestMB.
+// OrgRequestRatios override the ratio for the apps of orgs, by org guid.
+type Memory struct {
+	RequestRatio     float64            `yaml:"request_ratio"`
+	MinRequestMB     int64              `yaml:"min_request_mb"`
+	OrgRequestRatios map[string]float64 `yaml:"org_request_ratios"`
+}
+
+// CPU decides how much CPU apps, tasks and staging are entitled to. Policy
+// is shares (the default), memory or limits. The latter two entitle
+// containers to MillicoresPerGB of their memory, and limits requests that
+// divided by OvercommitRatio.
+type CPU struct {
+	Policy          string  `yaml:"policy"`
+	MillicoresPerGB int64   `yaml:"millicores_per_gb"`
+	OvercommitRatio float64 `yaml:"overcommit_ratio"`
+}
+
+// SecurityGroups restricts the egress of apps, tasks and staging to what
+// their application security groups allow
+type SecurityGroups struct {
+	Enabled bool `yaml:"enabled"`
+	// CCAPI is where the v3 API of the Cloud Controller accepts the CC
+	// client certificate, and defaults to CcInternalAPI
+	CCAPI               string `yaml:"cc_api"`
+	SyncIntervalSeconds int    `yaml:"sync_interval_seconds"`
+}
+
+// SecurityContext hardens the containers of apps, tasks and staging. By
+// default they run as the vcap user without privilege escalation or
+// capabilities, and RunAsUser and RunAsGroup can override the ids.
+type SecurityContext struct {
+	// Unconfined leaves containers with the user and capabilities of their image
+	Unconfined             bool  `yaml:"unconfined"`
+	RunAsUser              int64 `yaml:"run_as_user"`
+	RunAsGroup             int64 `yaml:"run_as_group"`
+	ReadOnlyRootFilesystem bool  `yaml:"read_only_root_filesystem"`
+}
+
+// NetworkPolicies enforces the container networking policies of apps. They
+// are fetched from the internal API of the policy server, or read from
+// PolicyFile when it is set.
+type NetworkPolicies struct {
+	Enabled         bool   `yaml:"enabled"`
+	PolicyServerAPI string `yaml:"policy_server_api"`
+	CertPath        string `yaml:"cert_path"`
+	KeyPath         string `yaml:"key_path"`
+	CAPath          string `yaml:"ca_path"`
+	PolicyFile      string `yaml:"policy_file"`
+	// IngressCIDRs are outside of the cluster and can connect to every
+	// app, e.g. the gorouters
+	IngressCIDRs        []string `yaml:"ingress_cidrs"`
+	SyncIntervalSeconds int      `yaml:"sync_interval_seconds"`
+}
+
+// IsolationSegment confines apps to dedicated nodes, usually ones that are
+// labelled and tainted for the tenant
+type IsolationSegment struct {
+	NodeSelector     map[string]string `yaml:"node_selector"`
+	Tolerations      []Toleration      `yaml:"tolerations"`
+	RuntimeClassName string            `yaml:"runtime_class_name"`
+}
+
+type Toleration struct {
+	Key      string `yaml:"key"`
+	Operator string `yaml:"operator"`
+	Value    string `yaml:"value"`
+	Effect   string `yaml:"effect"`
 }
 
 //go:generate counterfeiter . Stager
 type Stager interface {
 	Stage(string, cf.StagingRequest) error
+	GetStagingStatus(string) (*cf.StagingStatus, error)
+	CancelStaging(string) error
 	CompleteStaging(*models.TaskCallbackResponse) error
 }
 
@@ -96,10 +219,18 @@ type Bifrost interface {
 	Update(ctx context.Context, update cf.UpdateDesiredLRPRequest) error
 	Stop(ctx context.Context, identifier opi.LRPIdentifier) error
 	StopInstance(ctx context.Context, identifier opi.LRPIdentifier, index uint) error
-	GetApp(ctx context.Context, identifier opi.LRPIdentifier) *models.DesiredLRP
+	GetApp(ctx context.Context, identifier opi.LRPIdentifier) (*models.DesiredLRP, error)
 	GetInstances(ctx context.Context, identifier opi.LRPIdentifier) ([]*cf.Instance, error)
 }
 
+//go:generate counterfeiter . TaskBifrost
+type TaskBifrost interface {
+	TransferTask(ctx context.Context, taskGUID string, request cf.TaskRequest) error
+	GetTask(ctx context.Context, taskGUID string) (*cf.TaskResponse, error)
+	CancelTask(ctx context.Context, taskGUID string) error
+	CompleteTask(ctx context.Context, taskGUID string) error
+}
+
 func GetInternalServiceName(appName string) string {
 	//Prefix service as the appName could start with numerical characters, which is not allowed
 	return fmt.Sprintf("cf-%s", appName)
@@ -109,3 +240,16 @@ func GetInternalHeadlessServiceName(appName string) string {
 	//Prefix service as the appName could start with numerical characters, which is not allowed
 	return fmt.Sprintf("cf-%s-headless", appName)
 }
+
+// SensitiveEnvVars hold service credentials, so they are kept out of
+// workload specs and annotations
+var SensitiveEnvVars = []string{"VCAP_SERVICES", "DATABASE_URL"}
+
+func IsSensitiveEnvVar(name string) bool {
+	for _, sensitive := range SensitiveEnvVars {
+		if name == sensitive {
+			return true
+		}
+	}
+	return false
+}
@@ -23,6 +23,13 @@ type VcapApp struct {
 	Version   string   `json:"version"`
 	AppUris   []string `json:"application_uris"`
 	SpaceName string   `json:"space_name"`
+	SpaceID   string   `json:"space_id"`
+	OrgID     string   `json:"organization_id"`
+}
+
+// InternalRoute is an entry of the internal-router routes of an app
+type InternalRoute struct {
+	Hostname string `json:"hostname"`
 }
 
 type VolumeMount struct {
@@ -31,25 +38,32 @@ type VolumeMount struct {
 }
 
 type DesireLRPRequest struct {
-	GUID                    string                      `json:"guid"`
-	Version                 string                      `json:"version"`
-	ProcessGUID             string                      `json:"process_guid"`
-	Ports                   []int32                     `json:"ports"`
-	Routes                  map[string]*json.RawMessage `json:"routes"`
-	DockerImageURL          string                      `json:"docker_image"`
-	DropletHash             string                      `json:"droplet_hash"`
-	DropletGUID             string                      `json:"droplet_guid"`
-	StartCommand            string                      `json:"start_command"`
-	Environment             map[string]string           `json:"environment"`
-	NumInstances            int                         `json:"instances"`
-	LastUpdated             string                      `json:"last_updated"`
-	HealthCheckType         string                      `json:"health_check_type"`
-	HealthCheckHTTPEndpoint string                      `json:"health_check_http_endpoint"`
-	HealthCheckTimeoutMs    uint                        `json:"health_check_timeout_ms"`
-	MemoryMB                int64                       `json:"memory_mb"`
-	CPUWeight               uint8                       `json:"cpu_weight"`
-	VolumeMounts            []VolumeMount               `json:"volume_mounts"`
-	LRP                     string
+	GUID                             string                      `json:"guid"`
+	Version                          string                      `json:"version"`
+	ProcessGUID                      string                      `json:"process_guid"`
+	Ports                            []int32                     `json:"ports"`
+	Routes                           map[string]*json.RawMessage `json:"routes"`
+	DockerImageURL                   string                      `json:"docker_image"`
+	DropletHash                      string                      `json:"droplet_hash"`
+	DropletGUID                      string                      `json:"droplet_guid"`
+	StartCommand                     string                      `json:"start_command"`
+	Environment                      map[string]string           `json:"environment"`
+	NumInstances                     int                         `json:"instances"`
+	LastUpdated                      string                      `json:"last_updated"`
+	HealthCheckType                  string                      `json:"health_check_type"`
+	HealthCheckHTTPEndpoint          string                      `json:"health_check_http_endpoint"`
+	HealthCheckTimeoutMs             uint                        `json:"health_check_timeout_ms"`
+	HealthCheckPort                  int32                       `json:"health_check_port,omitempty"`
+	HealthCheckReadinessHTTPEndpoint string                      `json:"health_check_readiness_http_endpoint,omitempty"`
+	HealthCheckCommand               []string                    `json:"health_check_command,omitempty"`
+	HealthCheckInvocationTimeoutMs   uint                        `json:"health_check_invocation_timeout_ms,omitempty"`
+	HealthCheckIntervalMs            uint                        `json:"health_check_interval_ms,omitempty"`
+	MemoryMB                         int64                       `json:"memory_mb"`
+	DiskMB                           int64                       `json:"disk_mb"`
+	CPUWeight                        uint8                       `json:"cpu_weight"`
+	VolumeMounts                     []VolumeMount               `json:"volume_mounts"`
+	PlacementTags                    []string                    `json:"placement_tags"`
+	LRP                              string
 }
 
 type StagingRequest struct {
@@ -57,6 +71,8 @@ type StagingRequest struct {
 	CompletionCallback string                `json:"completion_callback"`
 	Environment        []EnvironmentVariable `json:"environment"`
 	LifecycleData      LifecycleData         `json:"lifecycle_data"`
+	MemoryMB           int64                 `json:"memory_mb"`
+	DiskMB             int64                 `json:"disk_mb"`
 }
 
 type LifecycleData struct {
@@ -77,10 +93,45 @@ type EnvironmentVariable struct {
 	Value string `json:"value"`
 }
 
+type TaskRequest struct {
+	AppGUID            string                `json:"app_guid"`
+	Name               string                `json:"name"`
+	Command            string                `json:"command"`
+	Environment        []EnvironmentVariable `json:"environment"`
+	CompletionCallback string                `json:"completion_callback"`
+	DockerImageURL     string                `json:"docker_image"`
+	DropletHash        string                `json:"droplet_hash"`
+	DropletGUID        string                `json:"droplet_guid"`
+	MemoryMB           int64                 `json:"memory_mb"`
+	DiskMB             int64                 `json:"disk_mb"`
+}
+
+type TaskResponse struct {
+	TaskGUID      string `json:"task_guid"`
+	State         string `json:"state"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
 type UpdateDesiredLRPRequest struct {
 	models.UpdateDesiredLRPRequest
-	GUID    string `json:"guid"`
-	Version string `json:"version"`
+	GUID                             string            `json:"guid"`
+	Version                          string            `json:"version"`
+	MemoryMB                         *int64            `json:"memory_mb,omitempty"`
+	DiskMB                           *int64            `json:"disk_mb,omitempty"`
+	CPUWeight                        *uint8            `json:"cpu_weight,omitempty"`
+	Environment                      map[string]string `json:"environment,omitempty"`
+	HealthCheckType                  *string           `json:"health_check_type,omitempty"`
+	HealthCheckHTTPEndpoint          *string           `json:"health_check_http_endpoint,omitempty"`
+	HealthCheckTimeoutMs             *uint             `json:"health_check_timeout_ms,omitempty"`
+	HealthCheckPort                  *int32            `json:"health_check_port,omitempty"`
+	HealthCheckReadinessHTTPEndpoint *string           `json:"health_check_readiness_http_endpoint,omitempty"`
+	HealthCheckCommand               []string          `json:"health_check_command,omitempty"`
+	HealthCheckInvocationTimeoutMs   *uint             `json:"health_check_invocation_timeout_ms,omitempty"`
+	HealthCheckIntervalMs            *uint             `json:"health_check_interval_ms,omitempty"`
+	Ports                            []int32           `json:"ports,omitempty"`
+	DockerImageURL                   string            `json:"docker_image,omitempty"`
+	DropletHash                      string            `json:"droplet_hash,omitempty"`
+	DropletGUID                      string            `json:"droplet_guid,omitempty"`
 }
 
 type GetInstancesResponse struct {
@@ -94,6 +145,7 @@ type Instance struct {
 	Since          int64  `json:"since"`
 	State          string `json:"state"`
 	PlacementError string `json:"placement_error,omitempty"`
+	Zone           string `json:"zone,omitempty"`
 }
 
 type Route struct {
@@ -111,6 +163,16 @@ type AppCrashedRequest struct {
 	CrashTimestamp  int64  `json:"crash_timestamp"`
 }
 
-type StagingError struct {
-	Message string `json:"message"`
+type StagingStatus struct {
+	StagingGUID   string         `json:"staging_guid"`
+	State         string         `json:"state"`
+	FailureReason string         `json:"failure_reason,omitempty"`
+	Phases        []StagingPhase `json:"phases"`
+}
+
+type StagingPhase struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Reason   string `json:"reason,omitempty"`
+	ExitCode int32  `json:"exit_code,omitempty"`
 }
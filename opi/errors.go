@@ -0,0 +1,82 @@
+package opi
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+type ErrorReason string
+
+const (
+	ReasonNotFound           ErrorReason = "NotFound"
+	ReasonAlreadyExists      ErrorReason = "AlreadyExists"
+	ReasonConflict           ErrorReason = "Conflict"
+	ReasonInvalid            ErrorReason = "Invalid"
+	ReasonBackendUnavailable ErrorReason = "BackendUnavailable"
+	ReasonUnknown            ErrorReason = "Unknown"
+)
+
+// Error is returned by the opi desirers so that callers can tell apart
+// failures they can recover from (e.g. a missing app) from backend outages
+type Error struct {
+	Reason  ErrorReason
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func NewNotFoundError(format string, args ...interface{}) error {
+	return newError(ReasonNotFound, format, args...)
+}
+
+func NewAlreadyExistsError(format string, args ...interface{}) error {
+	return newError(ReasonAlreadyExists, format, args...)
+}
+
+func NewConflictError(format string, args ...interface{}) error {
+	return newError(ReasonConflict, format, args...)
+}
+
+func NewInvalidError(format string, args ...interface{}) error {
+	return newError(ReasonInvalid, format, args...)
+}
+
+func NewBackendUnavailableError(format string, args ...interface{}) error {
+	return newError(ReasonBackendUnavailable, format, args...)
+}
+
+func newError(reason ErrorReason, format string, args ...interface{}) error {
+	return &Error{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// ReasonForError returns the reason of an opi error, looking through any
+// errors.Wrap calls. Errors which are not opi errors have an unknown reason.
+func ReasonForError(err error) ErrorReason {
+	if opiErr, ok := errors.Cause(err).(*Error); ok {
+		return opiErr.Reason
+	}
+	return ReasonUnknown
+}
+
+func IsNotFound(err error) bool {
+	return ReasonForError(err) == ReasonNotFound
+}
+
+func IsAlreadyExists(err error) bool {
+	return ReasonForError(err) == ReasonAlreadyExists
+}
+
+func IsConflict(err error) bool {
+	return ReasonForError(err) == ReasonConflict
+}
+
+func IsInvalid(err error) bool {
+	return ReasonForError(err) == ReasonInvalid
+}
+
+func IsBackendUnavailable(err error) bool {
+	return ReasonForError(err) == ReasonBackendUnavailable
+}
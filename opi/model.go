@@ -11,6 +11,11 @@ const (
 	CrashedState            = "CRASHED"
 	UnknownState            = "UNKNOWN"
 	InsufficientMemoryError = "Insufficient resources: memory"
+
+	TaskPendingState   = "PENDING"
+	TaskRunningState   = "RUNNING"
+	TaskSucceededState = "SUCCEEDED"
+	TaskFailedState    = "FAILED"
 )
 
 type LRPIdentifier struct {
@@ -28,18 +33,41 @@ type LRP struct {
 	LRPIdentifier
 	AppName          string
 	SpaceName        string
+	SpaceGUID        string
+	OrgGUID          string
 	Image            string
 	Command          []string
 	Env              map[string]string
 	Health           Healtcheck
 	Ports            []int32
+	InternalRoutes   []string
 	TargetInstances  int
 	RunningInstances int
 	Metadata         map[string]string
 	MemoryMB         int64
+	DiskMB           int64
 	CPUWeight        uint8
 	VolumeMounts     []VolumeMount
+	PlacementTags    []string
 	LRP              string
+	// KeepSensitiveEnv is set by updates without an environment, whose Env
+	// lacks the sensitive variables the app already has
+	KeepSensitiveEnv bool
+}
+
+// DefaultPort is the port of apps which do not declare any
+const DefaultPort int32 = 8080
+
+// HealthCheckPort is the port which the health check of an LRP targets: the
+// requested one, or else its first port
+func (lrp *LRP) HealthCheckPort() int32 {
+	if lrp.Health.Port != 0 {
+		return lrp.Health.Port
+	}
+	if len(lrp.Ports) > 0 {
+		return lrp.Ports[0]
+	}
+	return DefaultPort
 }
 
 type VolumeMount struct {
@@ -52,21 +80,62 @@ type Instance struct {
 	Since          int64
 	State          string
 	PlacementError string
+	Zone           string
 }
 
+// Health check types of CF. The process type has no probe, as an instance
+// is restarted whenever its process exits anyway.
+const (
+	HTTPHealthCheck    = "http"
+	PortHealthCheck    = "port"
+	ProcessHealthCheck = "process"
+	ExecHealthCheck    = "exec"
+)
+
+// Healtcheck describes how the health of the instances of an LRP is
+// checked. TimeoutMs is the time an instance has to become healthy after it
+// starts, InvocationTimeoutMs the time a single check may take and
+// IntervalMs the time between checks. ReadinessEndpoint replaces Endpoint
+// when checking whether an instance is ready to receive traffic.
 type Healtcheck struct {
-	Type      string
-	Port      int32
-	Endpoint  string
-	TimeoutMs uint
+	Type                string
+	Port                int32
+	Endpoint            string
+	ReadinessEndpoint   string
+	Command             []string
+	TimeoutMs           uint
+	InvocationTimeoutMs uint
+	IntervalMs          uint
 }
 
 // A Task is a one-off process that is run exactly once and returns a
 // result
 type Task struct {
-	Image   string
-	Command []string
-	Env     map[string]string
+	GUID               string
+	AppGUID            string
+	SpaceGUID          string
+	OrgGUID            string
+	Image              string
+	Command            []string
+	Env                map[string]string
+	MemoryMB           int64
+	DiskMB             int64
+	CompletionCallback string
+}
+
+type TaskStatus struct {
+	GUID               string
+	State              string
+	FailureReason      string
+	CompletionCallback string
+	Containers         []TaskContainerStatus
+}
+
+type TaskContainerStatus struct {
+	Name     string
+	State    string
+	Reason   string
+	ExitCode int32
 }
 
 type StagingTask struct {
@@ -91,5 +160,6 @@ type Desirer interface {
 type TaskDesirer interface {
 	Desire(task *Task) error
 	DesireStaging(task *StagingTask) error
+	Get(name string) (*TaskStatus, error)
 	Delete(name string) error
 }
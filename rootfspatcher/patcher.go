@@ -6,6 +6,7 @@ import (
 	"code.cloudfoundry.org/lager"
 	"github.com/pkg/errors"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -21,6 +22,9 @@ type StatefulSetPatcher struct {
 	Version      string
 	StatefulSets StatefulSetUpdaterLister
 	Logger       lager.Logger
+	// PatchTemplate migrates the pod templates of the StatefulSets as well
+	// when set, e.g. to a new security context
+	PatchTemplate func(*corev1.PodTemplateSpec)
 }
 
 func (p StatefulSetPatcher) Patch() error {
@@ -36,6 +40,9 @@ func (p StatefulSetPatcher) Patch() error {
 		statesfulset := s
 		statesfulset.Labels[RootfsVersionLabel] = p.Version
 		statesfulset.Spec.Template.Labels[RootfsVersionLabel] = p.Version
+		if p.PatchTemplate != nil {
+			p.PatchTemplate(&statesfulset.Spec.Template)
+		}
 		_, err := p.StatefulSets.Update(&statesfulset)
 		if err != nil {
 			p.Logger.Error("failed to patch", err)
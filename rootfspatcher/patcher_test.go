@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"code.cloudfoundry.org/eirini/k8s"
 	. "code.cloudfoundry.org/eirini/rootfspatcher"
 	"code.cloudfoundry.org/eirini/rootfspatcher/rootfspatcherfakes"
 	"code.cloudfoundry.org/lager/lagertest"
@@ -95,6 +96,44 @@ var _ = Describe("Patcher", func() {
 			})
 		})
 
+		Context("When the pod templates are patched as well", func() {
+			BeforeEach(func() {
+				patcher = StatefulSetPatcher{
+					Version:      newVersion,
+					StatefulSets: statefulsetUpdaterLister,
+					Logger:       logger,
+					PatchTemplate: func(template *corev1.PodTemplateSpec) {
+						template.Spec.ServiceAccountName = "patched"
+					},
+				}
+			})
+
+			It("should update the pod templates", func() {
+				updatedStatefulset := statefulsetUpdaterLister.UpdateArgsForCall(0)
+				Expect(updatedStatefulset.Spec.Template.Spec.ServiceAccountName).To(Equal("patched"))
+				Expect(updatedStatefulset.Spec.Template.Labels).To(HaveKeyWithValue(RootfsVersionLabel, newVersion))
+			})
+		})
+
+		Context("When the pod templates are hardened", func() {
+			BeforeEach(func() {
+				patcher = StatefulSetPatcher{
+					Version:       newVersion,
+					StatefulSets:  statefulsetUpdaterLister,
+					Logger:        logger,
+					PatchTemplate: k8s.SecurityPolicy{RunAsUser: 1000, RunAsGroup: 1001}.Apply,
+				}
+			})
+
+			It("should run the apps with the configured ids", func() {
+				updatedStatefulset := statefulsetUpdaterLister.UpdateArgsForCall(0)
+				securityContext := updatedStatefulset.Spec.Template.Spec.SecurityContext
+				Expect(*securityContext.RunAsUser).To(Equal(int64(1000)))
+				Expect(*securityContext.RunAsGroup).To(Equal(int64(1001)))
+				Expect(*securityContext.FSGroup).To(Equal(int64(1001)))
+			})
+		})
+
 		Context("When an additional statefulset exists", func() {
 			BeforeEach(func() {
 				stsList.Items = append(stsList.Items, createStatefulSet("another-app", "version2"))
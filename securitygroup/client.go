@@ -0,0 +1,99 @@
+package securitygroup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Rule struct {
+	Protocol    string `json:"protocol"`
+	Destination string `json:"destination"`
+	Ports       string `json:"ports,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type SecurityGroup struct {
+	GUID  string `json:"guid"`
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+//go:generate counterfeiter . CcClient
+type CcClient interface {
+	// RunningSecurityGroups returns the security groups of the running apps
+	// and tasks of a space, including the globally enabled ones
+	RunningSecurityGroups(spaceGUID string) ([]SecurityGroup, error)
+	// StagingSecurityGroups returns the security groups of the staging
+	// jobs of a space, including the globally enabled ones
+	StagingSecurityGroups(spaceGUID string) ([]SecurityGroup, error)
+}
+
+type securityGroupsPage struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []SecurityGroup `json:"resources"`
+}
+
+type ccClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewCcClient returns a client of the v3 API of the Cloud Controller at
+// baseURL, which has to accept the client certificate of httpClient
+func NewCcClient(baseURL string, httpClient *http.Client) CcClient {
+	return &ccClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+func (c *ccClient) RunningSecurityGroups(spaceGUID string) ([]SecurityGroup, error) {
+	return c.listSecurityGroups(fmt.Sprintf("%s/v3/spaces/%s/running_security_groups", c.baseURL, spaceGUID))
+}
+
+func (c *ccClient) StagingSecurityGroups(spaceGUID string) ([]SecurityGroup, error) {
+	return c.listSecurityGroups(fmt.Sprintf("%s/v3/spaces/%s/staging_security_groups", c.baseURL, spaceGUID))
+}
+
+func (c *ccClient) listSecurityGroups(url string) ([]SecurityGroup, error) {
+	groups := []SecurityGroup{}
+	for url != "" {
+		page, err := c.getPage(url)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, page.Resources...)
+
+		url = ""
+		if page.Pagination.Next != nil {
+			url = page.Pagination.Next.Href
+		}
+	}
+	return groups, nil
+}
+
+func (c *ccClient) getPage(url string) (*securityGroupsPage, error) {
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get security groups")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get security groups: %s returned status code %d", url, resp.StatusCode)
+	}
+
+	var page securityGroupsPage
+	if err = json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, errors.Wrap(err, "failed to decode security groups")
+	}
+	return &page, nil
+}
@@ -0,0 +1,94 @@
+package securitygroup_test
+
+import (
+	"fmt"
+	"net/http"
+
+	. "code.cloudfoundry.org/eirini/securitygroup"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("CcClient", func() {
+
+	var (
+		server   *ghttp.Server
+		client   CcClient
+		groups   []SecurityGroup
+		getError error
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		client = NewCcClient(server.URL(), &http.Client{})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("When getting the running security groups of a space", func() {
+
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/spaces/space-guid/running_security_groups"),
+					ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`{
+						"pagination": {"next": {"href": "%s/v3/spaces/space-guid/running_security_groups?page=2"}},
+						"resources": [{"guid": "dns-guid", "name": "dns", "rules": [{"protocol": "udp", "destination": "0.0.0.0/0", "ports": "53"}]}]
+					}`, server.URL())),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/spaces/space-guid/running_security_groups", "page=2"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"pagination": {"next": null},
+						"resources": [{"guid": "db-guid", "name": "db", "rules": [{"protocol": "tcp", "destination": "10.0.0.1-10.0.0.9", "ports": "5432"}]}]
+					}`),
+				),
+			)
+		})
+
+		JustBeforeEach(func() {
+			groups, getError = client.RunningSecurityGroups("space-guid")
+		})
+
+		It("should return the groups of every page", func() {
+			Expect(getError).ToNot(HaveOccurred())
+			Expect(groups).To(Equal([]SecurityGroup{
+				{GUID: "dns-guid", Name: "dns", Rules: []Rule{{Protocol: "udp", Destination: "0.0.0.0/0", Ports: "53"}}},
+				{GUID: "db-guid", Name: "db", Rules: []Rule{{Protocol: "tcp", Destination: "10.0.0.1-10.0.0.9", Ports: "5432"}}},
+			}))
+		})
+	})
+
+	Context("When getting the staging security groups of a space", func() {
+
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/spaces/space-guid/staging_security_groups"),
+					ghttp.RespondWith(http.StatusOK, `{"pagination": {}, "resources": []}`),
+				),
+			)
+		})
+
+		It("should request the staging groups", func() {
+			groups, getError = client.StagingSecurityGroups("space-guid")
+			Expect(getError).ToNot(HaveOccurred())
+			Expect(groups).To(BeEmpty())
+		})
+	})
+
+	Context("When the Cloud Controller fails", func() {
+
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, ""))
+		})
+
+		It("should return an error", func() {
+			_, getError = client.RunningSecurityGroups("space-guid")
+			Expect(getError).To(MatchError(ContainSubstring("status code 403")))
+		})
+	})
+})
@@ -0,0 +1,287 @@
+package securitygroup
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/lager"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// LifecycleLabel marks the network policies of security groups with the
+	// lifecycle they apply to
+	LifecycleLabel = "asg_lifecycle"
+
+	LifecycleRunning = "running"
+	LifecycleStaging = "staging"
+
+	dnsPort = 53
+
+	denyEgressPolicyName = "asg-deny-egress"
+)
+
+func policyName(lifecycle, spaceGUID string) string {
+	return fmt.Sprintf("asg-%s-%s", lifecycle, spaceGUID)
+}
+
+// lifecycleOf returns the lifecycle of a pod: staging jobs get the staging
+// security groups, apps and tasks the running ones
+func lifecycleOf(sourceType string) string {
+	if sourceType == k8s.StagingSourceType {
+		return LifecycleStaging
+	}
+	return LifecycleRunning
+}
+
+func lifecycleSourceTypes(lifecycle string) []string {
+	if lifecycle == LifecycleStaging {
+		return []string{k8s.StagingSourceType}
+	}
+	return []string{k8s.AppSourceType, k8s.TaskSourceType}
+}
+
+// toNetworkPolicy restricts the egress of the pods of a space and lifecycle
+// to what its security groups allow, like the egress of Diego containers
+func toNetworkPolicy(lifecycle, spaceGUID string, groups []SecurityGroup, logger lager.Logger) *networkingv1.NetworkPolicy {
+	egress := clusterEgressRules()
+	for _, group := range groups {
+		for _, rule := range group.Rules {
+			egressRule, err := toEgressRule(rule)
+			if err != nil {
+				logger.Info("skipping-security-group-rule", lager.Data{
+					"security-group": group.Name,
+					"space-guid":     spaceGUID,
+					"reason":         err.Error(),
+				})
+				continue
+			}
+			egress = append(egress, egressRule)
+		}
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: policyName(lifecycle, spaceGUID),
+			Labels: map[string]string{
+				k8s.SpaceGUIDLabel: spaceGUID,
+				LifecycleLabel:     lifecycle,
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: meta.LabelSelector{
+				MatchLabels: map[string]string{k8s.SpaceGUIDLabel: spaceGUID},
+				MatchExpressions: []meta.LabelSelectorRequirement{
+					{
+						Key:      "source_type",
+						Operator: meta.LabelSelectorOpIn,
+						Values:   lifecycleSourceTypes(lifecycle),
+					},
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress:      egress,
+		},
+	}
+}
+
+// denyEgressPolicy selects every CF pod in a namespace without allowing any
+// egress, so that pods are only allowed what the policy of their space
+// allows. It has no lifecycle label, so it is never deleted as stale.
+// denyEgressPolicy only selects pods with a space, as the security groups
+// can only allow egress to the pods of a space
+func denyEgressPolicy() *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: meta.ObjectMeta{Name: denyEgressPolicyName},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: meta.LabelSelector{
+				MatchExpressions: []meta.LabelSelectorRequirement{
+					{
+						Key:      "source_type",
+						Operator: meta.LabelSelectorOpIn,
+						Values:   []string{k8s.AppSourceType, k8s.TaskSourceType, k8s.StagingSourceType},
+					},
+					{
+						Key:      k8s.SpaceGUIDLabel,
+						Operator: meta.LabelSelectorOpExists,
+					},
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		},
+	}
+}
+
+// clusterEgressRules let pods resolve names with the cluster DNS, and reach
+// other apps, whose ingress is controlled by container networking policies
+// rather than by security groups
+func clusterEgressRules() []networkingv1.NetworkPolicyEgressRule {
+	dns := intstr.FromInt(dnsPort)
+	return []networkingv1.NetworkPolicyEgressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: protocol(corev1.ProtocolUDP), Port: &dns},
+				{Protocol: protocol(corev1.ProtocolTCP), Port: &dns},
+			},
+		},
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: &meta.LabelSelector{},
+					PodSelector: &meta.LabelSelector{
+						MatchLabels: map[string]string{"source_type": k8s.AppSourceType},
+					},
+				},
+			},
+		},
+	}
+}
+
+// toEgressRule translates a security group rule. ICMP cannot be expressed
+// in a NetworkPolicy, and neither can large port ranges before Kubernetes
+// 1.21, so such rules are rejected.
+func toEgressRule(rule Rule) (networkingv1.NetworkPolicyEgressRule, error) {
+	var egressRule networkingv1.NetworkPolicyEgressRule
+
+	for _, destination := range strings.Split(rule.Destination, ",") {
+		cidrs, err := toCIDRs(strings.TrimSpace(destination))
+		if err != nil {
+			return egressRule, err
+		}
+		for _, cidr := range cidrs {
+			egressRule.To = append(egressRule.To, networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{CIDR: cidr},
+			})
+		}
+	}
+
+	switch p := strings.ToLower(rule.Protocol); p {
+	case "all":
+	case "tcp", "udp":
+		ports, err := toPorts(corev1.Protocol(strings.ToUpper(p)), rule.Ports)
+		if err != nil {
+			return egressRule, err
+		}
+		egressRule.Ports = ports
+	default:
+		return egressRule, fmt.Errorf("unsupported protocol %q", rule.Protocol)
+	}
+	return egressRule, nil
+}
+
+// toCIDRs translates a destination, which is an IP address, a CIDR or a
+// range of IPv4 addresses like 10.0.0.1-10.0.0.10
+func toCIDRs(destination string) ([]string, error) {
+	if strings.Contains(destination, "/") {
+		_, ipNet, err := net.ParseCIDR(destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination %q", destination)
+		}
+		return []string{ipNet.String()}, nil
+	}
+
+	if bounds := strings.Split(destination, "-"); len(bounds) == 2 {
+		first, last := net.ParseIP(strings.TrimSpace(bounds[0])).To4(), net.ParseIP(strings.TrimSpace(bounds[1])).To4()
+		if first == nil || last == nil || binary.BigEndian.Uint32(first) > binary.BigEndian.Uint32(last) {
+			return nil, fmt.Errorf("invalid destination %q", destination)
+		}
+		return rangeToCIDRs(binary.BigEndian.Uint32(first), binary.BigEndian.Uint32(last)), nil
+	}
+
+	ip := net.ParseIP(destination)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid destination %q", destination)
+	}
+	if ip.To4() != nil {
+		return []string{ip.String() + "/32"}, nil
+	}
+	return []string{ip.String() + "/128"}, nil
+}
+
+// rangeToCIDRs covers a range of IPv4 addresses with the fewest CIDRs
+func rangeToCIDRs(first, last uint32) []string {
+	cidrs := []string{}
+	for start := uint64(first); start <= uint64(last); {
+		prefixLength := 32
+		for prefixLength > 0 {
+			size := uint64(1) << uint(33-prefixLength)
+			if start%size != 0 || start+size-1 > uint64(last) {
+				break
+			}
+			prefixLength--
+		}
+
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, uint32(start))
+		cidrs = append(cidrs, fmt.Sprintf("%s/%d", ip, prefixLength))
+		start += uint64(1) << uint(32-prefixLength)
+	}
+	return cidrs
+}
+
+// toPorts translates ports like 80,443 or 8080-8090. A rule without ports
+// allows every port of its protocol. Rules with more than
+// k8s.MaxNetworkPolicyPorts ports are rejected rather than widened.
+func toPorts(proto corev1.Protocol, ports string) ([]networkingv1.NetworkPolicyPort, error) {
+	if strings.TrimSpace(ports) == "" {
+		return []networkingv1.NetworkPolicyPort{{Protocol: protocol(proto)}}, nil
+	}
+
+	result := []networkingv1.NetworkPolicyPort{}
+	for _, portRange := range strings.Split(ports, ",") {
+		first, last, err := parsePortRange(strings.TrimSpace(portRange))
+		if err != nil {
+			return nil, err
+		}
+		rangePorts, err := k8s.NetworkPolicyPorts(proto, first, last)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rangePorts...)
+		if len(result) > k8s.MaxNetworkPolicyPorts {
+			return nil, fmt.Errorf("ports %q are more than %d ports", ports, k8s.MaxNetworkPolicyPorts)
+		}
+	}
+	return result, nil
+}
+
+func parsePortRange(portRange string) (first, last int, err error) {
+	bounds := strings.Split(portRange, "-")
+	if len(bounds) > 2 {
+		return 0, 0, fmt.Errorf("invalid ports %q", portRange)
+	}
+
+	first, err = parsePort(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	last = first
+	if len(bounds) == 2 {
+		if last, err = parsePort(bounds[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if first > last {
+		return 0, 0, fmt.Errorf("invalid ports %q", portRange)
+	}
+	return first, last, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+func protocol(p corev1.Protocol) *corev1.Protocol {
+	return &p
+}
@@ -0,0 +1,13 @@
+package securitygroup_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSecurityGroup(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SecurityGroup Suite")
+}
@@ -0,0 +1,185 @@
+package securitygroup
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"code.cloudfoundry.org/eirini/k8s"
+	"code.cloudfoundry.org/eirini/route"
+	"code.cloudfoundry.org/lager"
+	"github.com/pkg/errors"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Syncer keeps a NetworkPolicy for the running and the staging pods of
+// every space which has pods in a namespace. The policies of spaces whose
+// security groups cannot be fetched are left as they are. Every namespace
+// with CF pods denies them egress by default, so the pods of a space are
+// restricted even before its policy is applied.
+type Syncer struct {
+	client    kubernetes.Interface
+	ccClient  CcClient
+	namespace string
+	scheduler route.TaskScheduler
+	logger    lager.Logger
+}
+
+// stalePolicyAge keeps the policies applied for new workloads and jobs
+// until their pods exist
+const stalePolicyAge = time.Minute
+
+type spaceLifecycle struct {
+	spaceGUID string
+	lifecycle string
+}
+
+func NewSyncer(client kubernetes.Interface, ccClient CcClient, namespace string, scheduler route.TaskScheduler, logger lager.Logger) *Syncer {
+	return &Syncer{
+		client:    client,
+		ccClient:  ccClient,
+		namespace: namespace,
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+func (s *Syncer) Start() {
+	s.scheduler.Schedule(s.Sync)
+}
+
+func (s *Syncer) Sync() error {
+	pods, err := s.client.CoreV1().Pods(s.namespace).List(meta.ListOptions{
+		LabelSelector: fmt.Sprintf("%s,source_type in (%s,%s,%s)", k8s.SpaceGUIDLabel, k8s.AppSourceType, k8s.TaskSourceType, k8s.StagingSourceType),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list pods")
+	}
+
+	namespaces := map[string]bool{}
+	if s.namespace != meta.NamespaceAll {
+		namespaces[s.namespace] = true
+	}
+	for _, pod := range pods.Items {
+		namespaces[pod.Namespace] = true
+	}
+	for namespace := range namespaces {
+		if err = s.applyNetworkPolicy(namespace, denyEgressPolicy()); err != nil {
+			s.logger.Error("failed-to-apply-network-policy", err, lager.Data{"namespace": namespace, "name": denyEgressPolicyName})
+		}
+	}
+
+	groups := map[spaceLifecycle][]SecurityGroup{}
+	failed := map[spaceLifecycle]bool{}
+	desired := map[string]bool{}
+	for _, pod := range pods.Items {
+		key := spaceLifecycle{
+			spaceGUID: pod.Labels[k8s.SpaceGUIDLabel],
+			lifecycle: lifecycleOf(pod.Labels["source_type"]),
+		}
+		name := pod.Namespace + "/" + policyName(key.lifecycle, key.spaceGUID)
+		if desired[name] {
+			continue
+		}
+		desired[name] = true
+
+		spaceGroups, ok := groups[key]
+		if !ok && !failed[key] {
+			spaceGroups, err = s.securityGroups(key)
+			if err != nil {
+				s.logger.Error("failed-to-get-security-groups", err, lager.Data{"space-guid": key.spaceGUID, "lifecycle": key.lifecycle})
+				failed[key] = true
+			}
+			groups[key] = spaceGroups
+		}
+		if failed[key] {
+			continue
+		}
+
+		policy := toNetworkPolicy(key.lifecycle, key.spaceGUID, spaceGroups, s.logger)
+		if err = s.applyNetworkPolicy(pod.Namespace, policy); err != nil {
+			s.logger.Error("failed-to-apply-network-policy", err, lager.Data{"namespace": pod.Namespace, "name": policy.Name})
+		}
+	}
+
+	return s.deleteStaleNetworkPolicies(desired)
+}
+
+// SpaceDesired applies the security groups of a space to the pods with a
+// source type in a namespace, before they are created. It only fails when
+// the pods could not be denied egress: when the security groups cannot be
+// fetched, the pods have no egress until the next sync. Pods without a space
+// are not restricted.
+func (s *Syncer) SpaceDesired(namespace, spaceGUID, sourceType string) error {
+	if spaceGUID == "" {
+		return nil
+	}
+
+	if err := s.applyNetworkPolicy(namespace, denyEgressPolicy()); err != nil {
+		return err
+	}
+
+	key := spaceLifecycle{spaceGUID: spaceGUID, lifecycle: lifecycleOf(sourceType)}
+	groups, err := s.securityGroups(key)
+	if err != nil {
+		s.logger.Error("failed-to-get-security-groups", err, lager.Data{"space-guid": key.spaceGUID, "lifecycle": key.lifecycle})
+		return nil
+	}
+
+	policy := toNetworkPolicy(key.lifecycle, key.spaceGUID, groups, s.logger)
+	if err = s.applyNetworkPolicy(namespace, policy); err != nil {
+		s.logger.Error("failed-to-apply-network-policy", err, lager.Data{"namespace": namespace, "name": policy.Name})
+	}
+	return nil
+}
+
+func (s *Syncer) securityGroups(key spaceLifecycle) ([]SecurityGroup, error) {
+	if key.lifecycle == LifecycleStaging {
+		return s.ccClient.StagingSecurityGroups(key.spaceGUID)
+	}
+	return s.ccClient.RunningSecurityGroups(key.spaceGUID)
+}
+
+func (s *Syncer) applyNetworkPolicy(namespace string, desired *networkingv1.NetworkPolicy) error {
+	policies := s.client.NetworkingV1().NetworkPolicies(namespace)
+	current, err := policies.Get(desired.Name, meta.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = policies.Create(desired)
+		return errors.Wrap(err, "failed to create network policy")
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to get network policy")
+	}
+
+	if reflect.DeepEqual(current.Labels, desired.Labels) && reflect.DeepEqual(current.Spec, desired.Spec) {
+		return nil
+	}
+	current.Labels = desired.Labels
+	current.Spec = desired.Spec
+	_, err = policies.Update(current)
+	return errors.Wrap(err, "failed to update network policy")
+}
+
+// deleteStaleNetworkPolicies deletes the policies of spaces which no longer
+// have running or staging pods in a namespace, unless they were applied
+// for pods which are yet to be created
+func (s *Syncer) deleteStaleNetworkPolicies(desired map[string]bool) error {
+	list, err := s.client.NetworkingV1().NetworkPolicies(s.namespace).List(meta.ListOptions{LabelSelector: LifecycleLabel})
+	if err != nil {
+		return errors.Wrap(err, "failed to list network policies")
+	}
+
+	for _, policy := range list.Items {
+		if desired[policy.Namespace+"/"+policy.Name] || time.Since(policy.CreationTimestamp.Time) < stalePolicyAge {
+			continue
+		}
+		err = s.client.NetworkingV1().NetworkPolicies(policy.Namespace).Delete(policy.Name, nil)
+		if err != nil && !apierrors.IsNotFound(err) {
+			s.logger.Error("failed-to-delete-network-policy", err, lager.Data{"namespace": policy.Namespace, "name": policy.Name})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package securitygroup_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/eirini/route/routefakes"
+	. "code.cloudfoundry.org/eirini/securitygroup"
+	"code.cloudfoundry.org/eirini/securitygroup/securitygroupfakes"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Syncer", func() {
+
+	var (
+		client    *fake.Clientset
+		ccClient  *securitygroupfakes.FakeCcClient
+		scheduler *routefakes.FakeTaskScheduler
+		syncer    *Syncer
+		syncErr   error
+	)
+
+	createPod := func(namespace, name, spaceGUID, sourceType string) {
+		_, err := client.CoreV1().Pods(namespace).Create(&corev1.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"space_guid": spaceGUID, "source_type": sourceType},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	getPolicy := func(namespace, name string) (*networkingv1.NetworkPolicy, error) {
+		return client.NetworkingV1().NetworkPolicies(namespace).Get(name, meta.GetOptions{})
+	}
+
+	port := func(protocol corev1.Protocol, number int) networkingv1.NetworkPolicyPort {
+		p := intstr.FromInt(number)
+		return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p}
+	}
+
+	allPorts := func(protocol corev1.Protocol) networkingv1.NetworkPolicyPort {
+		return networkingv1.NetworkPolicyPort{Protocol: &protocol}
+	}
+
+	ipBlock := func(cidr string) networkingv1.NetworkPolicyPeer {
+		return networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}
+	}
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset()
+		ccClient = new(securitygroupfakes.FakeCcClient)
+		scheduler = new(routefakes.FakeTaskScheduler)
+		syncer = NewSyncer(client, ccClient, "", scheduler, lagertest.NewTestLogger("security-group-syncer"))
+
+		ccClient.RunningSecurityGroupsReturns([]SecurityGroup{
+			{
+				Name: "db",
+				Rules: []Rule{
+					{Protocol: "tcp", Destination: "10.0.0.1-10.0.0.6", Ports: "5432,6000-6001"},
+					{Protocol: "icmp", Destination: "0.0.0.0/0"},
+				},
+			},
+			{
+				Name:  "public",
+				Rules: []Rule{{Protocol: "all", Destination: "8.8.8.8,192.168.1.0/24"}},
+			},
+		}, nil)
+		ccClient.StagingSecurityGroupsReturns([]SecurityGroup{
+			{Name: "registry", Rules: []Rule{{Protocol: "udp", Destination: "10.1.1.1"}}},
+		}, nil)
+	})
+
+	JustBeforeEach(func() {
+		syncErr = syncer.Sync()
+	})
+
+	It("should schedule the sync", func() {
+		syncer.Start()
+		Expect(scheduler.ScheduleCallCount()).To(Equal(1))
+	})
+
+	Context("When a space has app and task pods", func() {
+
+		BeforeEach(func() {
+			createPod("space-ns", "app-0", "space-guid", "APP")
+			createPod("space-ns", "app-1", "space-guid", "APP")
+			createPod("space-ns", "task", "space-guid", "TASK")
+		})
+
+		It("should fetch the running security groups of the space once", func() {
+			Expect(syncErr).ToNot(HaveOccurred())
+			Expect(ccClient.RunningSecurityGroupsCallCount()).To(Equal(1))
+			Expect(ccClient.RunningSecurityGroupsArgsForCall(0)).To(Equal("space-guid"))
+			Expect(ccClient.StagingSecurityGroupsCallCount()).To(Equal(0))
+		})
+
+		It("should select the app and task pods of the space", func() {
+			policy, err := getPolicy("space-ns", "asg-running-space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Labels).To(Equal(map[string]string{
+				"space_guid":    "space-guid",
+				"asg_lifecycle": "running",
+			}))
+			Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeEgress))
+			Expect(policy.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{"space_guid": "space-guid"}))
+			Expect(policy.Spec.PodSelector.MatchExpressions).To(ConsistOf(meta.LabelSelectorRequirement{
+				Key:      "source_type",
+				Operator: meta.LabelSelectorOpIn,
+				Values:   []string{"APP", "TASK"},
+			}))
+		})
+
+		It("should allow DNS and traffic to other apps", func() {
+			policy, err := getPolicy("space-ns", "asg-running-space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Spec.Egress[0].To).To(BeEmpty())
+			Expect(policy.Spec.Egress[0].Ports).To(ConsistOf(port(corev1.ProtocolUDP, 53), port(corev1.ProtocolTCP, 53)))
+			Expect(policy.Spec.Egress[1].To).To(ConsistOf(networkingv1.NetworkPolicyPeer{
+				NamespaceSelector: &meta.LabelSelector{},
+				PodSelector:       &meta.LabelSelector{MatchLabels: map[string]string{"source_type": "APP"}},
+			}))
+		})
+
+		It("should translate the rules of the security groups", func() {
+			policy, err := getPolicy("space-ns", "asg-running-space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Spec.Egress).To(HaveLen(4))
+			Expect(policy.Spec.Egress[2]).To(Equal(networkingv1.NetworkPolicyEgressRule{
+				To: []networkingv1.NetworkPolicyPeer{
+					ipBlock("10.0.0.1/32"), ipBlock("10.0.0.2/31"), ipBlock("10.0.0.4/31"), ipBlock("10.0.0.6/32"),
+				},
+				Ports: []networkingv1.NetworkPolicyPort{
+					port(corev1.ProtocolTCP, 5432), port(corev1.ProtocolTCP, 6000), port(corev1.ProtocolTCP, 6001),
+				},
+			}))
+			Expect(policy.Spec.Egress[3]).To(Equal(networkingv1.NetworkPolicyEgressRule{
+				To: []networkingv1.NetworkPolicyPeer{ipBlock("8.8.8.8/32"), ipBlock("192.168.1.0/24")},
+			}))
+		})
+
+		It("should not create a staging policy", func() {
+			_, err := getPolicy("space-ns", "asg-staging-space-guid")
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("and the security groups change", func() {
+
+			BeforeEach(func() {
+				Expect(syncer.Sync()).To(Succeed())
+				ccClient.RunningSecurityGroupsReturns([]SecurityGroup{}, nil)
+			})
+
+			It("should update the policy", func() {
+				policy, err := getPolicy("space-ns", "asg-running-space-guid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policy.Spec.Egress).To(HaveLen(2))
+			})
+		})
+
+		Context("and a rule has more ports than can be listed", func() {
+
+			BeforeEach(func() {
+				ccClient.RunningSecurityGroupsReturns([]SecurityGroup{
+					{
+						Name: "wide",
+						Rules: []Rule{
+							{Protocol: "tcp", Destination: "10.0.0.1", Ports: "1000-3000"},
+							{Protocol: "tcp", Destination: "10.0.0.2", Ports: "1-1000,2000-2100"},
+							{Protocol: "udp", Destination: "10.0.0.3", Ports: "1-65535"},
+						},
+					},
+				}, nil)
+			})
+
+			It("should skip the rule rather than allow every port", func() {
+				policy, err := getPolicy("space-ns", "asg-running-space-guid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policy.Spec.Egress).To(HaveLen(3))
+				Expect(policy.Spec.Egress[2]).To(Equal(networkingv1.NetworkPolicyEgressRule{
+					To:    []networkingv1.NetworkPolicyPeer{ipBlock("10.0.0.3/32")},
+					Ports: []networkingv1.NetworkPolicyPort{allPorts(corev1.ProtocolUDP)},
+				}))
+			})
+		})
+
+		Context("and the security groups cannot be fetched", func() {
+
+			BeforeEach(func() {
+				Expect(syncer.Sync()).To(Succeed())
+				ccClient.RunningSecurityGroupsReturns(nil, errors.New("boom"))
+			})
+
+			It("should keep the policy", func() {
+				policy, err := getPolicy("space-ns", "asg-running-space-guid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policy.Spec.Egress).To(HaveLen(4))
+			})
+		})
+	})
+
+	Context("When a space is staging", func() {
+
+		BeforeEach(func() {
+			createPod("eirini", "staging", "space-guid", "STG")
+		})
+
+		It("should apply the staging security groups to the staging pods", func() {
+			Expect(ccClient.StagingSecurityGroupsCallCount()).To(Equal(1))
+
+			policy, err := getPolicy("eirini", "asg-staging-space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Spec.PodSelector.MatchExpressions[0].Values).To(ConsistOf("STG"))
+			Expect(policy.Spec.Egress[2]).To(Equal(networkingv1.NetworkPolicyEgressRule{
+				To:    []networkingv1.NetworkPolicyPeer{ipBlock("10.1.1.1/32")},
+				Ports: []networkingv1.NetworkPolicyPort{allPorts(corev1.ProtocolUDP)},
+			}))
+		})
+	})
+
+	Context("When a namespace has CF pods", func() {
+
+		BeforeEach(func() {
+			createPod("space-ns", "app-0", "space-guid", "APP")
+		})
+
+		It("should deny the egress of the pods with a space by default", func() {
+			policy, err := getPolicy("space-ns", "asg-deny-egress")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeEgress))
+			Expect(policy.Spec.Egress).To(BeEmpty())
+			Expect(policy.Spec.PodSelector.MatchLabels).To(BeEmpty())
+			Expect(policy.Spec.PodSelector.MatchExpressions).To(ConsistOf(
+				meta.LabelSelectorRequirement{
+					Key:      "source_type",
+					Operator: meta.LabelSelectorOpIn,
+					Values:   []string{"APP", "TASK", "STG"},
+				},
+				meta.LabelSelectorRequirement{
+					Key:      "space_guid",
+					Operator: meta.LabelSelectorOpExists,
+				},
+			))
+		})
+
+		Context("and the space no longer has pods", func() {
+
+			BeforeEach(func() {
+				Expect(syncer.Sync()).To(Succeed())
+				Expect(client.CoreV1().Pods("space-ns").Delete("app-0", nil)).To(Succeed())
+			})
+
+			It("should keep denying egress", func() {
+				_, err := getPolicy("space-ns", "asg-deny-egress")
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("When the pods of a space are about to be created", func() {
+
+		var (
+			spaceGUID string
+			desireErr error
+		)
+
+		BeforeEach(func() {
+			spaceGUID = "space-guid"
+		})
+
+		JustBeforeEach(func() {
+			desireErr = syncer.SpaceDesired("space-ns", spaceGUID, "STG")
+		})
+
+		It("should deny egress and apply the policy of the space right away", func() {
+			Expect(desireErr).ToNot(HaveOccurred())
+			_, err := getPolicy("space-ns", "asg-deny-egress")
+			Expect(err).ToNot(HaveOccurred())
+
+			policy, err := getPolicy("space-ns", "asg-staging-space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Spec.PodSelector.MatchExpressions[0].Values).To(ConsistOf("STG"))
+			Expect(ccClient.StagingSecurityGroupsArgsForCall(0)).To(Equal("space-guid"))
+		})
+
+		It("should keep the policy while the pods are created", func() {
+			policy, err := getPolicy("space-ns", "asg-staging-space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			policy.CreationTimestamp = meta.Now()
+			_, err = client.NetworkingV1().NetworkPolicies("space-ns").Update(policy)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(syncer.Sync()).To(Succeed())
+			_, err = getPolicy("space-ns", "asg-staging-space-guid")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("and the security groups cannot be fetched", func() {
+
+			BeforeEach(func() {
+				ccClient.StagingSecurityGroupsReturns(nil, errors.New("boom"))
+			})
+
+			It("should still deny egress", func() {
+				Expect(desireErr).ToNot(HaveOccurred())
+				_, err := getPolicy("space-ns", "asg-deny-egress")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = getPolicy("space-ns", "asg-staging-space-guid")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("and the pods have no space", func() {
+
+			BeforeEach(func() {
+				spaceGUID = ""
+			})
+
+			It("should not restrict their egress", func() {
+				Expect(desireErr).ToNot(HaveOccurred())
+				Expect(ccClient.StagingSecurityGroupsCallCount()).To(Equal(0))
+
+				policies, err := client.NetworkingV1().NetworkPolicies("space-ns").List(meta.ListOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policies.Items).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("When a space no longer has pods", func() {
+
+		BeforeEach(func() {
+			createPod("space-ns", "app-0", "space-guid", "APP")
+			Expect(syncer.Sync()).To(Succeed())
+			Expect(client.CoreV1().Pods("space-ns").Delete("app-0", nil)).To(Succeed())
+		})
+
+		It("should delete its policy", func() {
+			Expect(syncErr).ToNot(HaveOccurred())
+			_, err := getPolicy("space-ns", "asg-running-space-guid")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
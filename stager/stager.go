@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/eirini"
@@ -14,6 +15,11 @@ import (
 	"code.cloudfoundry.org/runtimeschema/cc_messages"
 )
 
+const (
+	StagingCancelledReason = "staging was cancelled"
+	stagingContainerPrefix = "opi-task-"
+)
+
 type Stager struct {
 	Desirer    opi.TaskDesirer
 	Config     *eirini.StagerConfig
@@ -60,43 +66,102 @@ func (s *Stager) createStagingTask(stagingGUID string, request cf.StagingRequest
 	}
 
 	stagingEnv := mergeEnvVriables(eiriniEnv, request.Environment)
+	vcap := s.parseVcapApplication(stagingEnv["VCAP_APPLICATION"])
 
 	stagingTask := &opi.StagingTask{
 		DownloaderImage: s.Config.DownloaderImage,
 		UploaderImage:   s.Config.UploaderImage,
 		ExecutorImage:   s.Config.ExecutorImage,
-		Task:            &opi.Task{Env: stagingEnv},
+		Task: &opi.Task{
+			Env:       stagingEnv,
+			SpaceGUID: vcap.SpaceID,
+			OrgGUID:   vcap.OrgID,
+			MemoryMB:  request.MemoryMB,
+			DiskMB:    request.DiskMB,
+		},
 	}
 	return stagingTask, nil
 }
 
-func (s *Stager) CompleteStaging(task *models.TaskCallbackResponse) error {
-	l := s.Logger.Session("complete-staging", lager.Data{"task-guid": task.TaskGuid})
+func (s *Stager) GetStagingStatus(stagingGUID string) (*cf.StagingStatus, error) {
+	status, err := s.Desirer.Get(stagingGUID)
+	if err != nil {
+		s.Logger.Error("failed-to-get-staging-task", err, lager.Data{"staging-guid": stagingGUID})
+		return nil, err
+	}
 
-	callbackBody, err := s.constructStagingResponse(task)
+	phases := []cf.StagingPhase{}
+	for _, container := range status.Containers {
+		phases = append(phases, cf.StagingPhase{
+			Name:     strings.TrimPrefix(container.Name, stagingContainerPrefix),
+			State:    container.State,
+			Reason:   container.Reason,
+			ExitCode: container.ExitCode,
+		})
+	}
+
+	return &cf.StagingStatus{
+		StagingGUID:   status.GUID,
+		State:         status.State,
+		FailureReason: status.FailureReason,
+		Phases:        phases,
+	}, nil
+}
+
+func (s *Stager) CancelStaging(stagingGUID string) error {
+	l := s.Logger.Session("cancel-staging", lager.Data{"staging-guid": stagingGUID})
+
+	status, err := s.Desirer.Get(stagingGUID)
 	if err != nil {
-		l.Error("failed-to-construct-staging-response", err)
+		l.Error("failed-to-get-staging-task", err)
+		return err
+	}
+
+	if err = s.Desirer.Delete(stagingGUID); err != nil {
+		l.Error("failed-to-delete-staging-task", err)
 		return err
 	}
 
+	task := &models.TaskCallbackResponse{
+		TaskGuid:      stagingGUID,
+		Failed:        true,
+		FailureReason: StagingCancelledReason,
+	}
+
+	return s.sendStagingResponse(l, status.CompletionCallback, task)
+}
+
+func (s *Stager) CompleteStaging(task *models.TaskCallbackResponse) error {
+	l := s.Logger.Session("complete-staging", lager.Data{"task-guid": task.TaskGuid})
+
 	callbackURI, err := s.getCallbackURI(task)
 	if err != nil {
 		l.Error("failed-to-parse-callback-uri", err)
 		return err
 	}
 
-	request, err := http.NewRequest("POST", callbackURI, bytes.NewBuffer(callbackBody))
+	if err = s.sendStagingResponse(l, callbackURI, task); err != nil {
+		return err
+	}
+
+	return s.Desirer.Delete(task.TaskGuid)
+}
+
+func (s *Stager) sendStagingResponse(l lager.Logger, callbackURI string, task *models.TaskCallbackResponse) error {
+	callbackBody, err := s.constructStagingResponse(task)
 	if err != nil {
-		l.Error("failed-to-create-callback-request", err)
+		l.Error("failed-to-construct-staging-response", err)
 		return err
 	}
-	request.Header.Set("Content-Type", "application/json")
 
-	if err := s.executeRequest(request); err != nil {
+	request, err := http.NewRequest("POST", callbackURI, bytes.NewBuffer(callbackBody))
+	if err != nil {
+		l.Error("failed-to-create-callback-request", err)
 		return err
 	}
+	request.Header.Set("Content-Type", "application/json")
 
-	return s.Desirer.Delete(task.TaskGuid)
+	return s.executeRequest(request)
 }
 
 func (s *Stager) executeRequest(request *http.Request) error {
@@ -145,6 +210,20 @@ func (s *Stager) getCallbackURI(task *models.TaskCallbackResponse) (string, erro
 	return annotation.CompletionCallback, nil
 }
 
+// parseVcapApplication returns the space and org of the app being staged.
+// Staging does not depend on them, so a request without them is staged
+// as before.
+func (s *Stager) parseVcapApplication(vcapJSON string) cf.VcapApp {
+	var vcap cf.VcapApp
+	if vcapJSON == "" {
+		return vcap
+	}
+	if err := json.Unmarshal([]byte(vcapJSON), &vcap); err != nil {
+		s.Logger.Info("failed-to-parse-vcap-application", lager.Data{"error": err.Error()})
+	}
+	return vcap
+}
+
 func mergeEnvVriables(eiriniEnv map[string]string, cfEnvs []cf.EnvironmentVariable) map[string]string {
 	for _, env := range cfEnvs {
 		if _, present := eiriniEnv[env.Name]; !present {
@@ -111,6 +111,36 @@ var _ = Describe("Stager", func() {
 			}))
 		})
 
+		Context("and the request has the VCAP_APPLICATION of the app", func() {
+
+			BeforeEach(func() {
+				request.Environment = append(request.Environment, cf.EnvironmentVariable{
+					Name:  "VCAP_APPLICATION",
+					Value: `{"space_id":"space-guid","organization_id":"org-guid"}`,
+				})
+			})
+
+			It("should desire the task in the space of the app", func() {
+				task := taskDesirer.DesireStagingArgsForCall(0)
+				Expect(task.SpaceGUID).To(Equal("space-guid"))
+				Expect(task.OrgGUID).To(Equal("org-guid"))
+			})
+		})
+
+		Context("and the request has memory and disk limits", func() {
+
+			BeforeEach(func() {
+				request.MemoryMB = 1024
+				request.DiskMB = 4096
+			})
+
+			It("should desire the task with the memory and disk limits", func() {
+				task := taskDesirer.DesireStagingArgsForCall(0)
+				Expect(task.MemoryMB).To(Equal(int64(1024)))
+				Expect(task.DiskMB).To(Equal(int64(4096)))
+			})
+		})
+
 		Context("and desiring the task fails", func() {
 
 			BeforeEach(func() {
@@ -250,4 +280,126 @@ var _ = Describe("Stager", func() {
 		})
 
 	})
+
+	Context("When getting the staging status", func() {
+		var status *cf.StagingStatus
+
+		BeforeEach(func() {
+			taskDesirer.GetReturns(&opi.TaskStatus{
+				GUID:  "staging-id-123",
+				State: opi.TaskRunningState,
+				Containers: []opi.TaskContainerStatus{
+					{Name: "opi-task-downloader", State: opi.TaskSucceededState, Reason: "Completed"},
+					{Name: "opi-task-executor", State: opi.TaskRunningState},
+					{Name: "opi-task-uploader", State: opi.TaskPendingState, Reason: "PodInitializing"},
+				},
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			status, err = stager.GetStagingStatus("staging-id-123")
+		})
+
+		It("should get the staging task", func() {
+			Expect(taskDesirer.GetCallCount()).To(Equal(1))
+			Expect(taskDesirer.GetArgsForCall(0)).To(Equal("staging-id-123"))
+		})
+
+		It("should return the staging phases", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(&cf.StagingStatus{
+				StagingGUID: "staging-id-123",
+				State:       opi.TaskRunningState,
+				Phases: []cf.StagingPhase{
+					{Name: "downloader", State: opi.TaskSucceededState, Reason: "Completed"},
+					{Name: "executor", State: opi.TaskRunningState},
+					{Name: "uploader", State: opi.TaskPendingState, Reason: "PodInitializing"},
+				},
+			}))
+		})
+
+		Context("and the staging task cannot be found", func() {
+			BeforeEach(func() {
+				taskDesirer.GetReturns(nil, errors.New("not found"))
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("When cancelling staging", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/call/me/maybe"),
+					ghttp.VerifyJSON(`{
+						"error": {
+							"id": "StagingError",
+							"message": "staging was cancelled"
+						}
+					}`),
+				),
+			)
+
+			taskDesirer.GetReturns(&opi.TaskStatus{
+				GUID:               "staging-id-123",
+				State:              opi.TaskRunningState,
+				CompletionCallback: server.URL() + "/call/me/maybe",
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			err = stager.CancelStaging("staging-id-123")
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should delete the staging task", func() {
+			Expect(taskDesirer.DeleteCallCount()).To(Equal(1))
+			Expect(taskDesirer.DeleteArgsForCall(0)).To(Equal("staging-id-123"))
+		})
+
+		It("should report the cancellation", func() {
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		Context("and the staging task cannot be found", func() {
+			BeforeEach(func() {
+				taskDesirer.GetReturns(nil, errors.New("not found"))
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should not delete anything", func() {
+				Expect(taskDesirer.DeleteCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("and deleting the staging task fails", func() {
+			BeforeEach(func() {
+				taskDesirer.DeleteReturns(errors.New("boom"))
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should not report the cancellation", func() {
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
 })
@@ -0,0 +1,44 @@
+package util
+
+import "sync"
+
+// KeyedMutex serializes work on the same key, while work on different keys
+// can still run concurrently. The zero value is ready to use.
+type KeyedMutex struct {
+	mutex sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// Lock blocks until the lock for key is acquired and returns the function
+// which releases it
+func (m *KeyedMutex) Lock(key string) func() {
+	m.mutex.Lock()
+	if m.locks == nil {
+		m.locks = map[string]*keyedLock{}
+	}
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		m.locks[key] = lock
+	}
+	lock.waiters++
+	m.mutex.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		m.mutex.Lock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(m.locks, key)
+		}
+		m.mutex.Unlock()
+	}
+}
@@ -0,0 +1,66 @@
+package util_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/eirini/util"
+)
+
+var _ = Describe("KeyedMutex", func() {
+	var mutex *util.KeyedMutex
+
+	BeforeEach(func() {
+		mutex = &util.KeyedMutex{}
+	})
+
+	It("should serialize work on the same key", func() {
+		unlock := mutex.Lock("key")
+
+		acquired := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			release := mutex.Lock("key")
+			close(acquired)
+			release()
+		}()
+
+		Consistently(acquired, 100*time.Millisecond).ShouldNot(BeClosed())
+		unlock()
+		Eventually(acquired).Should(BeClosed())
+	})
+
+	It("should not block work on different keys", func() {
+		unlock := mutex.Lock("key")
+		defer unlock()
+
+		acquired := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			release := mutex.Lock("other-key")
+			close(acquired)
+			release()
+		}()
+
+		Eventually(acquired).Should(BeClosed())
+	})
+
+	It("should allow many concurrent users of the same key", func() {
+		counter := 0
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock := mutex.Lock("key")
+				defer unlock()
+				counter++
+			}()
+		}
+		wg.Wait()
+		Expect(counter).To(Equal(50))
+	})
+})